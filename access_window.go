@@ -0,0 +1,114 @@
+package main
+
+import (
+    "database/sql"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "showcase_desktop/widgets"
+)
+
+
+// runCheckAccess lets an admin ask what a user may do on an object and shows the roles and rules behind the answer
+func runCheckAccess(inWindow *app.Window, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var userTextbox         widget.Editor
+    var objectTextbox       widget.Editor
+    var actionTextbox       widget.Editor
+    var checkBtn            widget.Clickable
+    var explanationList     widget.List
+    var explanationLines    []string
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Check access")
+    explanationList.Axis     = layout.Vertical
+
+    inWindow.Option(app.Title(tr().Text("Check access")), app.Size(unit.Dp(1050), unit.Dp(600)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            if checkBtn.Clicked(gtx) {
+                explanationLines = nil
+                statusMsg        = ""
+
+                subject, err := userSubject(inS3db, userTextbox.Text())
+                if err != nil {
+                    statusMsg = errorText(err)
+                } else if len(objectTextbox.Text()) == 0 || len(actionTextbox.Text()) == 0 {
+                    statusMsg = tr().Text("Please enter an object and an action")
+                } else {
+                    explanation, err := explainAccess(inEnforcer, subject, objectTextbox.Text(), actionTextbox.Text())
+                    names, namesErr  := subjectNames(inS3db)
+                    if err == nil {
+                        err = namesErr
+                    }
+                    if err != nil {
+                        statusMsg = tr().Sprintf("Could not check the access: %s", errorText(err))
+                    } else {
+                        explanationLines = explanation.Lines(names)
+                    }
+                }
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                // Who wants to do what
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &userTextbox, tr().Text("Username, e.g. Petar")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &objectTextbox, tr().Text("Object, e.g. report_text")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &actionTextbox, tr().Text("Action, read or write")).Layout(gtx) },
+                    )
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Button(theme, &checkBtn, tr().Text("Check")).Layout(gtx)
+                }),
+
+                // Decision, role chain and matching rules
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &explanationList).Layout(gtx, len(explanationLines), func(gtx layout.Context, index int) layout.Dimensions {
+                        return layout.UniformInset(unit.Dp(5)).Layout(gtx, material.Body1(theme.Theme, explanationLines[index]).Layout)
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+    "crypto/sha256"
+    "database/sql"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+)
+
+
+// Casbin object of the admin's audit log viewer
+const auditObject = "audit_log"
+
+// Types of audit events
+const (
+    auditSignIn             = "sign_in"
+    auditSignInFailed       = "sign_in_failed"
+    auditSignOut            = "sign_out"
+    auditDenied             = "denied"
+    auditPolicyAdd          = "policy_add"
+    auditPolicyRemove       = "policy_remove"
+    auditPolicySave         = "policy_save"
+    auditTimeEntryUpdate    = "time_entry_update"
+    auditTimeEntryDelete    = "time_entry_delete"
+    auditTimeEntryRestore   = "time_entry_restore"
+    auditTimesheet          = "timesheet"
+)
+
+var auditEventTypes = []string{auditSignIn, auditSignInFailed, auditSignOut, auditDenied, auditPolicyAdd, auditPolicyRemove,
+    auditPolicySave, auditTimeEntryUpdate, auditTimeEntryDelete, auditTimeEntryRestore, auditTimesheet}
+
+// Hash the first event of the chain points back to
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// AuditEvent is one row of the append-only audit log
+type AuditEvent struct {
+    AuditEventID        int
+    CreatedAt           string      // RFC 3339 in UTC, part of the hash so kept as written
+    ActorID             int         // zero when nobody is signed in
+    ActorName           string
+    OrganizationID      int         // zero for events outside an organization, e.g. signing in
+    EventType           string
+    Object              string
+    Before              string
+    After               string
+    PrevHash            string
+    Hash                string
+}
+
+func (e AuditEvent) Text() string {
+    text := fmt.Sprintf("%s  %s  %s", localDateTime(e.CreatedAt), e.ActorName, auditEventTypeText(e.EventType))
+    if e.Object != "" {
+        text += " " + e.Object
+    }
+    switch {
+    case e.Before != "" && e.After != "":
+        text += fmt.Sprintf(": %s -> %s", e.Before, e.After)
+    case e.Before != "":
+        text += ": " + e.Before
+    case e.After != "":
+        text += ": " + e.After
+    }
+    return text
+}
+
+// chainHash hashes the event's fields together with the hash of the event before it
+func (e AuditEvent) chainHash() string {
+    sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%q|%d|%q|%d|%q|%q|%q|%q",
+        e.PrevHash, e.CreatedAt, e.ActorID, e.ActorName, e.OrganizationID, e.EventType, e.Object, e.Before, e.After)))
+    return hex.EncodeToString(sum[:])
+}
+
+// AuditFilter narrows the audit log viewer, empty fields match everything
+type AuditFilter struct {
+    OrganizationID      int
+    EventType           string
+    ActorName           string
+    From                string      // YYYY-MM-DD, inclusive
+    To                  string      // YYYY-MM-DD, inclusive
+    Limit               int
+}
+
+
+// recordAuditEvent appends the event to the chain. Pass a transaction to record a change together with the event.
+// The unique prev_hash keeps two writers from forking the chain, the later one fails instead.
+func recordAuditEvent(inDB dbRunner, inEvent AuditEvent) error {
+    if inEvent.ActorName == "" && inEvent.ActorID != 0 {
+        err := inDB.QueryRow("SELECT username FROM user_dim WHERE user_id = ?", inEvent.ActorID).Scan(&inEvent.ActorName)
+        if err != nil && !errors.Is(err, sql.ErrNoRows) {
+            return err
+        }
+    }
+
+    err := inDB.QueryRow("SELECT hash FROM audit_event ORDER BY audit_event_id DESC LIMIT 1").Scan(&inEvent.PrevHash)
+    if errors.Is(err, sql.ErrNoRows) {
+        inEvent.PrevHash = auditGenesisHash
+    } else if err != nil {
+        return err
+    }
+
+    inEvent.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+    inEvent.Hash      = inEvent.chainHash()
+
+    _, err = inDB.Exec(`
+INSERT INTO audit_event (created_at, actor_id, actor_name, organization_id, event_type, object, before_value, after_value, prev_hash, hash)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, inEvent.CreatedAt, inEvent.ActorID, inEvent.ActorName, inEvent.OrganizationID, inEvent.EventType, inEvent.Object,
+        inEvent.Before, inEvent.After, inEvent.PrevHash, inEvent.Hash)
+    if err != nil {
+        return fmt.Errorf("could not write the audit log: %w", err)
+    }
+
+    return nil
+}
+
+// logAuditEvent records an event about a change that is already made, a failure is only logged
+func logAuditEvent(inDB dbRunner, inEvent AuditEvent) {
+    if err := recordAuditEvent(inDB, inEvent); err != nil {
+        log.Print(err)
+    }
+}
+
+// listAuditEvents returns the newest events of the organization matching the filter. Events outside any
+// organization, like sign-ins, are part of every organization's log.
+func listAuditEvents(inDB dbRunner, inFilter AuditFilter) ([]AuditEvent, error) {
+    where := []string{"organization_id IN (0, ?)"}
+    args  := []any{inFilter.OrganizationID}
+
+    if inFilter.EventType != "" {
+        where = append(where, "event_type = ?")
+        args  = append(args, inFilter.EventType)
+    }
+    if name := strings.TrimSpace(inFilter.ActorName); name != "" {
+        where = append(where, "actor_name = ? COLLATE NOCASE")
+        args  = append(args, name)
+    }
+    // Timestamps compare as text, everything on a day sorts between it and the next day
+    if inFilter.From != "" {
+        from, err := time.Parse("2006-01-02", inFilter.From)
+        if err != nil {
+            return nil, newError("date %q is not YYYY-MM-DD", inFilter.From)
+        }
+        where = append(where, "created_at >= ?")
+        args  = append(args, dateKey(from))
+    }
+    if inFilter.To != "" {
+        to, err := time.Parse("2006-01-02", inFilter.To)
+        if err != nil {
+            return nil, newError("date %q is not YYYY-MM-DD", inFilter.To)
+        }
+        where = append(where, "created_at < ?")
+        args  = append(args, dateKey(to.AddDate(0, 0, 1)))
+    }
+    limit := inFilter.Limit
+    if limit <= 0 {
+        limit = 500
+    }
+    args = append(args, limit)
+
+    rows, err := inDB.Query(`
+SELECT
+      audit_event_id
+    , created_at
+    , actor_id
+    , actor_name
+    , organization_id
+    , event_type
+    , object
+    , before_value
+    , after_value
+    , prev_hash
+    , hash
+FROM
+    audit_event
+WHERE
+    `+strings.Join(where, "\n    AND ")+`
+ORDER BY
+    audit_event_id DESC
+LIMIT ?
+    `, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var events []AuditEvent
+    for rows.Next() {
+        var event AuditEvent
+        if err := rows.Scan(&event.AuditEventID, &event.CreatedAt, &event.ActorID, &event.ActorName, &event.OrganizationID, &event.EventType,
+            &event.Object, &event.Before, &event.After, &event.PrevHash, &event.Hash); err != nil {
+            return nil, err
+        }
+        events = append(events, event)
+    }
+
+    return events, rows.Err()
+}
+
+// verifyAuditChain walks the whole log and returns the ID of the first event whose hash does not match,
+// zero when the chain is intact. Removing events from the end can't be seen from the chain alone.
+func verifyAuditChain(inDB dbRunner) (int, error) {
+    rows, err := inDB.Query(`
+SELECT
+      audit_event_id
+    , created_at
+    , actor_id
+    , actor_name
+    , organization_id
+    , event_type
+    , object
+    , before_value
+    , after_value
+    , prev_hash
+    , hash
+FROM
+    audit_event
+ORDER BY
+    audit_event_id
+    `)
+    if err != nil {
+        return 0, err
+    }
+    defer rows.Close()
+
+    prevHash := auditGenesisHash
+    for rows.Next() {
+        var event AuditEvent
+        if err := rows.Scan(&event.AuditEventID, &event.CreatedAt, &event.ActorID, &event.ActorName, &event.OrganizationID, &event.EventType,
+            &event.Object, &event.Before, &event.After, &event.PrevHash, &event.Hash); err != nil {
+            return 0, err
+        }
+        if event.PrevHash != prevHash || event.Hash != event.chainHash() {
+            return event.AuditEventID, nil
+        }
+        prevHash = event.Hash
+    }
+
+    return 0, rows.Err()
+}
+
+// auditEvent starts an event of the enforcer's signed in user in its organization
+func (e *OrgEnforcer) auditEvent(inEventType string, inObject string, inBefore string, inAfter string) AuditEvent {
+    return AuditEvent{
+        ActorID:        e.ActorID,
+        OrganizationID: e.OrganizationID,
+        EventType:      inEventType,
+        Object:         inObject,
+        Before:         inBefore,
+        After:          inAfter,
+    }
+}
+
+// checkActorWrite asks again if the signed in user may write the object, a window may outlive the policy that let
+// it open. A denial is audited.
+func (e *OrgEnforcer) checkActorWrite(inDB dbRunner, inObject string, inDenied error) error {
+    allowed, err := e.Enforce(fmt.Sprintf("u%d", e.ActorID), inObject, "write")
+    if err != nil {
+        return err
+    }
+    if !allowed {
+        logAuditEvent(inDB, e.auditEvent(auditDenied, inObject, "", "write"))
+        return inDenied
+    }
+
+    return nil
+}
+
+// timeEntryAuditValue is how an entry shows in the audit log
+func timeEntryAuditValue(inEntry TimeEntry) string {
+    return fmt.Sprintf("%s %s %d min", dateKey(inEntry.EntryDate), inEntry.ClientName, inEntry.Minutes)
+}
+
+// casbinRuleAuditValue is a rule as the adapter stores it, e.g. "p, r1, o1, invoice, write, allow, true"
+func casbinRuleAuditValue(inPtype string, inRule []string) string {
+    return inPtype + ", " + strings.Join(inRule, ", ")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+func Test_verifyAuditChain(t *testing.T) {
+    db, _ := openTestDb(t)
+
+    for _, eventType := range []string{auditSignIn, auditPolicyAdd, auditSignOut} {
+        if err := recordAuditEvent(db, AuditEvent{ActorID: 1, OrganizationID: 1, EventType: eventType, After: "x"}); err != nil {
+            t.Fatalf("recordAuditEvent() error = %v", err)
+        }
+    }
+    if brokenID, err := verifyAuditChain(db); brokenID != 0 || err != nil {
+        t.Fatalf("verifyAuditChain() = %d, %v, want an intact chain", brokenID, err)
+    }
+
+    // Rows can't be changed through SQL
+    if _, err := db.Exec("UPDATE audit_event SET after_value = 'y'"); err == nil {
+        t.Errorf("audit_event allowed an update")
+    }
+    if _, err := db.Exec("DELETE FROM audit_event"); err == nil {
+        t.Errorf("audit_event allowed a delete")
+    }
+
+    // ... and when someone gets around the triggers, the chain breaks at the changed row
+    var secondID int
+    db.QueryRow("SELECT audit_event_id FROM audit_event ORDER BY audit_event_id LIMIT 1 OFFSET 1").Scan(&secondID)
+    db.Exec("DROP TRIGGER audit_event_no_update")
+    db.Exec("UPDATE audit_event SET after_value = 'y' WHERE audit_event_id = ?", secondID)
+    if brokenID, _ := verifyAuditChain(db); brokenID != secondID {
+        t.Errorf("verifyAuditChain() after tampering = %d, want %d", brokenID, secondID)
+    }
+}
+
+func Test_auditedChanges(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    enforcer.SetActor(1)
+    day        := time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)
+
+    if success, _ := checkSignIn("Ray", "wrong", db); success {
+        t.Fatal("checkSignIn() with a wrong password succeeded")
+    }
+    entry, err := addTimeEntry(db, 1, 2, "ACME", day, 60)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 90, day); err != nil {
+        t.Fatal(err)
+    }
+    updateTimeEntry(db, enforcer, 3, entry.TimeEntryID, 30, day)
+    if _, err := enforcer.AddPolicy("r2", "o1", "invoice", "read", "allow", "true"); err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name    string
+        filter  AuditFilter
+        want    string
+    }{
+        {"failed sign-in",      AuditFilter{OrganizationID: 1, EventType: auditSignInFailed},                   "Ray  sign-in failed"},
+        {"entry change",        AuditFilter{OrganizationID: 1, EventType: auditTimeEntryUpdate},               "2030-01-08 ACME 60 min -> 2030-01-08 ACME 90 min"},
+        {"denial",              AuditFilter{OrganizationID: 1, EventType: auditDenied, ActorName: "petar"},     "Petar  denied time_entry"},
+        {"adapter change",      AuditFilter{OrganizationID: 1, EventType: auditPolicyAdd},                      "Ray  rule added policy: p, r2, o1, invoice, read, allow, true"},
+        {"other organization",  AuditFilter{OrganizationID: 2, EventType: auditPolicyAdd},                      ""},
+        {"before the dates",    AuditFilter{OrganizationID: 1, EventType: auditPolicyAdd, To: "2020-01-01"},    ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            events, err := listAuditEvents(db, tt.filter)
+            if err != nil {
+                t.Fatalf("listAuditEvents() error = %v", err)
+            }
+            if tt.want == "" {
+                if len(events) != 0 {
+                    t.Errorf("listAuditEvents() = %v, want none", events)
+                }
+                return
+            }
+            if len(events) != 1 || !strings.Contains(events[0].Text(), tt.want) {
+                t.Errorf("listAuditEvents() = %v, want one event %q", events, tt.want)
+            }
+        })
+    }
+
+    if brokenID, err := verifyAuditChain(db); brokenID != 0 || err != nil {
+        t.Errorf("verifyAuditChain() = %d, %v, want an intact chain", brokenID, err)
+    }
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+    "database/sql"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "showcase_desktop/widgets"
+    "strings"
+)
+
+
+// runAuditLog lets an admin filter the organization's audit log and shows whether its hash chain is intact
+func runAuditLog(inWindow *app.Window, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var actorTextbox        widget.Editor
+    var typeTextbox         widget.Editor
+    var fromTextbox         widget.Editor
+    var toTextbox           widget.Editor
+    var filterBtn           widget.Clickable
+    var eventList           widget.List
+    var events              []AuditEvent
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Audit log")
+    typeHint                := tr().Sprintf("Event, e.g. %s", strings.Join(auditEventTypes[:4], ", "))
+    eventList.Axis           = layout.Vertical
+
+    refreshEvents := func() {
+        var err error
+        events, err = listAuditEvents(inS3db, AuditFilter{
+            OrganizationID: inEnforcer.OrganizationID,
+            EventType:      strings.TrimSpace(typeTextbox.Text()),
+            ActorName:      actorTextbox.Text(),
+            From:           strings.TrimSpace(fromTextbox.Text()),
+            To:             strings.TrimSpace(toTextbox.Text()),
+        })
+        if err != nil {
+            statusMsg = errorText(err)
+            return
+        }
+
+        // The whole chain is checked, not only the rows shown
+        brokenID, err := verifyAuditChain(inS3db)
+        switch {
+        case err != nil:
+            log.Print(err)
+            statusMsg = tr().Plural(len(events), "%d event, the chain could not be checked", "%d events, the chain could not be checked")
+        case brokenID != 0:
+            statusMsg = tr().Plural(len(events), "%d event - the log was changed at event %d or later!", "%d events - the log was changed at event %d or later!",
+                len(events), brokenID)
+        default:
+            statusMsg = tr().Plural(len(events), "%d event, the chain is intact", "%d events, the chain is intact")
+        }
+    }
+    refreshEvents()
+
+    inWindow.Option(app.Title(tr().Text("Audit log")), app.Size(unit.Dp(1100), unit.Dp(700)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            if filterBtn.Clicked(gtx) {
+                refreshEvents()
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                // Who, what and when
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &actorTextbox, tr().Text("User")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &typeTextbox, typeHint).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &fromTextbox, tr().Text("From YYYY-MM-DD")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &toTextbox, tr().Text("To YYYY-MM-DD")).Layout(gtx) },
+                        material.Button(theme.Theme, &filterBtn, tr().Text("Filter")).Layout,
+                    )
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+                // Newest events first
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &eventList).Layout(gtx, len(events), func(gtx layout.Context, index int) layout.Dimensions {
+                        return layout.UniformInset(unit.Dp(3)).Layout(gtx, material.Body2(theme.Theme, events[index].Text()).Layout)
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
+
+
+// auditEventTypeText is the event type in the user's language, the filter still takes the stored type
+func auditEventTypeText(inEventType string) string {
+    switch inEventType {
+    case auditSignIn:
+        return tr().Text("signed in")
+    case auditSignInFailed:
+        return tr().Text("sign-in failed")
+    case auditSignOut:
+        return tr().Text("signed out")
+    case auditDenied:
+        return tr().Text("denied")
+    case auditPolicyAdd:
+        return tr().Text("rule added")
+    case auditPolicyRemove:
+        return tr().Text("rule removed")
+    case auditPolicySave:
+        return tr().Text("policy saved")
+    case auditTimeEntryUpdate:
+        return tr().Text("entry changed")
+    case auditTimeEntryDelete:
+        return tr().Text("entry deleted")
+    case auditTimeEntryRestore:
+        return tr().Text("entry restored")
+    case auditTimesheet:
+        return tr().Text("timesheet changed")
+    }
+    return inEventType
+}
@@ -0,0 +1,595 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+
+// Invoice kinds - both are numbered from the same sequence
+const (
+    invoiceKindInvoice    = "invoice"
+    invoiceKindCreditNote = "credit_note"
+)
+
+// Casbin object guarding rate cards, invoices and credit notes
+const invoiceObject = "invoice"
+
+var (
+    errNoRateCard       = errors.New("no rate card matches")
+    errNothingToInvoice = errors.New("no approved, unbilled time for this client and period")
+    errAlreadyCredited  = errors.New("invoice has already been credited")
+    errInvoiceDenied    = errors.New("you shall not pass!.. the invoices")
+)
+
+// Client is a row of the client registry, names are unique within an organization
+type Client struct {
+    ClientID    int
+    ClientName  string
+    Currency    string
+}
+
+// RateCard is an hourly rate, zero ClientID, RoleID or UserID means "any"
+type RateCard struct {
+    RateCardID      int
+    ClientID        int
+    RoleID          int
+    UserID          int
+    HourlyRateCents int64
+    Currency        string
+    ValidFrom       time.Time
+    ValidTo         time.Time       // zero means open-ended
+}
+
+// Invoice is an issued invoice or credit note, loaded together with its lines
+type Invoice struct {
+    InvoiceID           int
+    InvoiceNumber       int
+    Kind                string
+    ClientID            int
+    ClientName          string
+    Currency            string
+    IssueDate           time.Time
+    PeriodFrom          time.Time
+    PeriodTo            time.Time
+    CreditedInvoiceID   int
+    CreditedNumber      int
+    Note                string
+    TotalCents          int64
+    Lines               []InvoiceLine
+}
+
+// InvoiceLine groups the entries of one user billed at one rate
+type InvoiceLine struct {
+    LineNo          int
+    UserID          int
+    Description     string
+    Minutes         int
+    HourlyRateCents int64
+    AmountCents     int64
+    TimeEntryIDs    []int
+}
+
+// DocumentNumber is the number printed on the document, e.g. INV-000042 or CN-000043
+func (inv Invoice) DocumentNumber() string {
+    prefix := "INV"
+    if inv.Kind == invoiceKindCreditNote {
+        prefix = "CN"
+    }
+    return fmt.Sprintf("%s-%06d", prefix, inv.InvoiceNumber)
+}
+
+// CreditedDocumentNumber is the number of the invoice a credit note cancels
+func (inv Invoice) CreditedDocumentNumber() string {
+    return Invoice{Kind: invoiceKindInvoice, InvoiceNumber: inv.CreditedNumber}.DocumentNumber()
+}
+
+
+// formatCents shows an amount of cents as "1234.50"
+func formatCents(inCents int64) string {
+    sign := ""
+    if inCents < 0 {
+        sign    = "-"
+        inCents = -inCents
+    }
+    return fmt.Sprintf("%s%d.%02d", sign, inCents/100, inCents%100)
+}
+
+// parseCents reads amounts like "60", "60.5" or "60,50" into cents
+func parseCents(inText string) (int64, error) {
+    inText       = strings.Replace(strings.TrimSpace(inText), ",", ".", 1)
+    whole, frac, _ := strings.Cut(inText, ".")
+
+    if len(frac) > 2 {
+        return 0, newError("invalid amount %q, at most two decimals", inText)
+    }
+    frac += strings.Repeat("0", 2-len(frac))
+
+    units, uErr := strconv.ParseInt(whole, 10, 64)
+    cents, cErr := strconv.ParseInt(frac, 10, 64)
+    if uErr != nil || cErr != nil || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+        return 0, newError("invalid amount %q", inText)
+    }
+
+    return units*100 + cents, nil
+}
+
+// lineAmount rounds minutes at an hourly rate to whole cents
+func lineAmount(inMinutes int, inHourlyRateCents int64) int64 {
+    return (int64(inMinutes)*inHourlyRateCents + 30) / 60
+}
+
+func nullableID(inID int) any {
+    if inID == 0 {
+        return nil
+    }
+    return inID
+}
+
+
+// DB functions for the client registry and rate cards
+func ensureClient(inDB dbRunner, inOrganizationID int, inClientName string) (Client, error) {
+    var client Client
+
+    _, err := inDB.Exec("INSERT OR IGNORE INTO client_dim (client_name, organization_id) VALUES (?, ?)", strings.TrimSpace(inClientName), inOrganizationID)
+    if err != nil {
+        return client, err
+    }
+
+    err = inDB.QueryRow("SELECT client_id, client_name, currency FROM client_dim WHERE organization_id = ? AND client_name = ?", inOrganizationID, strings.TrimSpace(inClientName)).
+        Scan(&client.ClientID, &client.ClientName, &client.Currency)
+
+    return client, err
+}
+
+func addRateCard(inDB *sql.DB, inOrganizationID int, inRate RateCard) error {
+    var validTo any
+
+    if !inRate.ValidTo.IsZero() {
+        validTo = dateKey(inRate.ValidTo)
+    }
+    if inRate.Currency == "" {
+        inRate.Currency = "EUR"
+    }
+
+    _, err := inDB.Exec(`INSERT INTO rate_card (organization_id, client_id, role_id, user_id, hourly_rate_cents, currency, valid_from, valid_to) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+        inOrganizationID, nullableID(inRate.ClientID), nullableID(inRate.RoleID), nullableID(inRate.UserID), inRate.HourlyRateCents, inRate.Currency,
+        dateKey(inRate.ValidFrom), validTo)
+
+    return err
+}
+
+// resolveRate picks the most specific rate card for a user on a client at a date.
+// A user rate beats a role rate beats a general one, and a client-specific rate beats an any-client one on the same level.
+// Only the rate cards of the client's organization count, role rates go by the roles the user holds there.
+func resolveRate(inTx dbRunner, inClientID int, inUserID int, inDate time.Time) (int64, string, error) {
+    var rateCents int64
+    var currency  string
+
+    err := inTx.QueryRow(`
+SELECT
+      rc.hourly_rate_cents
+    , rc.currency
+FROM
+    rate_card                   AS rc
+    JOIN client_dim             AS cd
+        ON cd.client_id = ?
+WHERE
+        rc.organization_id = cd.organization_id
+    AND (rc.client_id IS NULL OR rc.client_id = cd.client_id)
+    AND (rc.user_id   IS NULL OR rc.user_id   = ?)
+    AND (rc.role_id   IS NULL OR rc.role_id IN (
+            SELECT CAST(object AS INTEGER) FROM auth_user_role_map_policy
+            WHERE subject = ? AND organization_id = cd.organization_id
+        ))
+    AND rc.valid_from <= ?
+    AND (rc.valid_to IS NULL OR rc.valid_to >= ?)
+ORDER BY
+      (rc.user_id IS NOT NULL) * 4 + (rc.role_id IS NOT NULL) * 2 + (rc.client_id IS NOT NULL) DESC
+    , rc.valid_from DESC
+LIMIT 1
+    `, inClientID, inUserID, inUserID, dateKey(inDate), dateKey(inDate)).Scan(&rateCents, &currency)
+
+    if errors.Is(err, sql.ErrNoRows) {
+        return 0, "", fmt.Errorf("%w: %w", errNoRateCard, newError("user %d, client %d, %s", inUserID, inClientID, inDate))
+    }
+
+    return rateCents, currency, err
+}
+
+
+// DB functions for invoices
+type billableEntry struct {
+    timeEntryID int
+    timesheetID int
+    userID      int
+    username    string
+    entryDate   time.Time
+    minutes     int
+}
+
+// generateInvoice bills every approved and not yet billed entry of the organization's client in the period, apart
+// from entries on a task or project that is not billable.
+// Entries are grouped per user and rate into lines, and their timesheets get locked, all in one transaction.
+func generateInvoice(inDB *sql.DB, inOrganizationID int, inClientName string, inPeriodFrom time.Time, inPeriodTo time.Time, inIssueDate time.Time) (Invoice, error) {
+    tx, err := inDB.Begin()
+    if err != nil {
+        return Invoice{}, err
+    }
+    defer tx.Rollback()
+
+    client, err := ensureClient(tx, inOrganizationID, inClientName)
+    if err != nil {
+        return Invoice{}, err
+    }
+
+    rows, err := tx.Query(`
+SELECT
+      te.time_entry_id
+    , te.timesheet_id
+    , te.user_id
+    , COALESCE(ud.username, '')
+    , te.entry_date
+    , te.minutes_spent
+FROM
+    time_entry                  AS te
+    JOIN timesheet              AS ts
+        ON ts.timesheet_id = te.timesheet_id
+    LEFT JOIN user_dim          AS ud
+        ON ud.user_id = te.user_id
+    LEFT JOIN task              AS tk
+        ON tk.task_id = te.task_id
+    LEFT JOIN project           AS pr
+        ON pr.project_id = COALESCE(tk.project_id, te.project_id)
+WHERE
+        te.client_name = ? COLLATE NOCASE
+    AND ts.organization_id = ?
+    AND ts.state IN (?, ?)
+    AND COALESCE(tk.billable, 1) = 1
+    AND COALESCE(pr.billable, 1) = 1
+    AND te.entry_date BETWEEN ? AND ?
+    AND te.time_entry_id NOT IN (
+        SELECT
+            ile.time_entry_id
+        FROM
+            invoice_line_entry      AS ile
+            JOIN invoice_line       AS il
+                ON il.invoice_line_id = ile.invoice_line_id
+            JOIN invoice            AS i
+                ON i.invoice_id = il.invoice_id
+        WHERE
+            i.invoice_id NOT IN (SELECT credited_invoice_id FROM invoice WHERE credited_invoice_id IS NOT NULL)
+    )
+ORDER BY
+    ud.username, te.entry_date, te.time_entry_id
+    `, client.ClientName, inOrganizationID, timesheetApproved, timesheetLocked, dateKey(inPeriodFrom), dateKey(inPeriodTo))
+    if err != nil {
+        return Invoice{}, err
+    }
+
+    var entries []billableEntry
+    for rows.Next() {
+        var be billableEntry
+        if err := rows.Scan(&be.timeEntryID, &be.timesheetID, &be.userID, &be.username, &be.entryDate, &be.minutes); err != nil {
+            rows.Close()
+            return Invoice{}, err
+        }
+        entries = append(entries, be)
+    }
+    rows.Close()
+    if len(entries) == 0 {
+        return Invoice{}, errNothingToInvoice
+    }
+
+    // One line per user and rate, rates can change inside the period
+    type lineKey struct {
+        userID    int
+        rateCents int64
+    }
+    var keys  []lineKey
+    lines     := map[lineKey]*InvoiceLine{}
+    names     := map[int]string{}
+    timesheets := map[int]bool{}
+
+    for _, be := range entries {
+        rateCents, currency, err := resolveRate(tx, client.ClientID, be.userID, be.entryDate)
+        if err != nil {
+            return Invoice{}, err
+        }
+        if currency != client.Currency {
+            return Invoice{}, newError("rate for %s is in %s, but %s is billed in %s", be.username, currency, client.ClientName, client.Currency)
+        }
+
+        key := lineKey{userID: be.userID, rateCents: rateCents}
+        if lines[key] == nil {
+            lines[key] = &InvoiceLine{UserID: be.userID, HourlyRateCents: rateCents}
+            keys       = append(keys, key)
+        }
+        lines[key].Minutes      += be.minutes
+        lines[key].TimeEntryIDs  = append(lines[key].TimeEntryIDs, be.timeEntryID)
+        names[be.userID]         = be.username
+        timesheets[be.timesheetID] = true
+    }
+
+    inv := Invoice{
+        Kind:       invoiceKindInvoice,
+        ClientID:   client.ClientID,
+        ClientName: client.ClientName,
+        Currency:   client.Currency,
+        IssueDate:  inIssueDate,
+        PeriodFrom: inPeriodFrom,
+        PeriodTo:   inPeriodTo,
+    }
+    for i, key := range keys {
+        line            := lines[key]
+        line.LineNo      = i + 1
+        line.AmountCents = lineAmount(line.Minutes, line.HourlyRateCents)
+        line.Description = fmt.Sprintf("%s - %s at %s/h", names[key.userID], formatMinutes(line.Minutes), formatCents(line.HourlyRateCents))
+        inv.TotalCents  += line.AmountCents
+        inv.Lines        = append(inv.Lines, *line)
+    }
+
+    if err := insertInvoice(tx, &inv); err != nil {
+        return Invoice{}, err
+    }
+
+    // Billed weeks can't change anymore
+    for timesheetID := range timesheets {
+        _, err := tx.Exec("UPDATE timesheet SET state = ? WHERE timesheet_id = ? AND state = ?", timesheetLocked, timesheetID, timesheetApproved)
+        if err != nil {
+            return Invoice{}, err
+        }
+    }
+
+    return inv, tx.Commit()
+}
+
+// creditInvoice issues a credit note cancelling the whole invoice, which also frees its entries for re-billing
+func creditInvoice(inDB *sql.DB, inOrganizationID int, inInvoiceID int, inIssueDate time.Time, inNote string) (Invoice, error) {
+    original, err := getInvoice(inDB, inOrganizationID, inInvoiceID)
+    if err != nil {
+        return Invoice{}, err
+    }
+    if original.Kind != invoiceKindInvoice {
+        return Invoice{}, newError("%s is not an invoice", original.DocumentNumber())
+    }
+
+    var credits int
+    err = inDB.QueryRow("SELECT COUNT(*) FROM invoice WHERE credited_invoice_id = ?", inInvoiceID).Scan(&credits)
+    if err != nil {
+        return Invoice{}, err
+    }
+    if credits > 0 {
+        return Invoice{}, errAlreadyCredited
+    }
+
+    credit := Invoice{
+        Kind:               invoiceKindCreditNote,
+        ClientID:           original.ClientID,
+        ClientName:         original.ClientName,
+        Currency:           original.Currency,
+        IssueDate:          inIssueDate,
+        PeriodFrom:         original.PeriodFrom,
+        PeriodTo:           original.PeriodTo,
+        CreditedInvoiceID:  original.InvoiceID,
+        CreditedNumber:     original.InvoiceNumber,
+        Note:               inNote,
+        TotalCents:         -original.TotalCents,
+    }
+    for _, line := range original.Lines {
+        line.Minutes      = -line.Minutes
+        line.AmountCents  = -line.AmountCents
+        line.Description  = fmt.Sprintf("Credit for %s: %s", original.DocumentNumber(), line.Description)
+        line.TimeEntryIDs = nil
+        credit.Lines      = append(credit.Lines, line)
+    }
+
+    tx, err := inDB.Begin()
+    if err != nil {
+        return Invoice{}, err
+    }
+    defer tx.Rollback()
+
+    if err := insertInvoice(tx, &credit); err != nil {
+        return Invoice{}, err
+    }
+
+    return credit, tx.Commit()
+}
+
+// insertInvoice takes the next number and stores the document with its lines
+func insertInvoice(inTx *sql.Tx, inInvoice *Invoice) error {
+    err := inTx.QueryRow("SELECT COALESCE(MAX(invoice_number), 0) + 1 FROM invoice").Scan(&inInvoice.InvoiceNumber)
+    if err != nil {
+        return err
+    }
+
+    result, err := inTx.Exec(`INSERT INTO invoice (invoice_number, kind, client_id, currency, issue_date, period_from, period_to, credited_invoice_id, note, total_cents) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+        inInvoice.InvoiceNumber, inInvoice.Kind, inInvoice.ClientID, inInvoice.Currency, dateKey(inInvoice.IssueDate),
+        dateKey(inInvoice.PeriodFrom), dateKey(inInvoice.PeriodTo), nullableID(inInvoice.CreditedInvoiceID), inInvoice.Note, inInvoice.TotalCents)
+    if err != nil {
+        return err
+    }
+    invoiceID, _       := result.LastInsertId()
+    inInvoice.InvoiceID = int(invoiceID)
+
+    for _, line := range inInvoice.Lines {
+        result, err := inTx.Exec(`INSERT INTO invoice_line (invoice_id, line_no, user_id, description, minutes_spent, hourly_rate_cents, amount_cents) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+            inInvoice.InvoiceID, line.LineNo, line.UserID, line.Description, line.Minutes, line.HourlyRateCents, line.AmountCents)
+        if err != nil {
+            return err
+        }
+        lineID, _ := result.LastInsertId()
+
+        for _, entryID := range line.TimeEntryIDs {
+            if _, err := inTx.Exec("INSERT INTO invoice_line_entry (invoice_line_id, time_entry_id) VALUES (?, ?)", lineID, entryID); err != nil {
+                return err
+            }
+        }
+    }
+
+    return nil
+}
+
+// listInvoices lists the documents issued to the organization's clients
+func listInvoices(inDB *sql.DB, inOrganizationID int) ([]Invoice, error) {
+    return queryInvoices(inDB, "cd.organization_id = ?", inOrganizationID)
+}
+
+func getInvoice(inDB *sql.DB, inOrganizationID int, inInvoiceID int) (Invoice, error) {
+    invoices, err := queryInvoices(inDB, "cd.organization_id = ? AND i.invoice_id = ?", inOrganizationID, inInvoiceID)
+    if err != nil {
+        return Invoice{}, err
+    }
+    if len(invoices) == 0 {
+        return Invoice{}, newError("invoice %d not found", inInvoiceID)
+    }
+
+    inv := invoices[0]
+
+    rows, err := inDB.Query(`
+SELECT
+      il.invoice_line_id
+    , il.line_no
+    , il.user_id
+    , il.description
+    , il.minutes_spent
+    , il.hourly_rate_cents
+    , il.amount_cents
+    , COALESCE(ile.time_entry_id, 0)
+FROM
+    invoice_line                    AS il
+    LEFT JOIN invoice_line_entry    AS ile
+        ON ile.invoice_line_id = il.invoice_line_id
+WHERE
+    il.invoice_id = ?
+ORDER BY
+    il.line_no, ile.time_entry_id
+    `, inInvoiceID)
+    if err != nil {
+        return Invoice{}, err
+    }
+    defer rows.Close()
+
+    lastLineID := 0
+    for rows.Next() {
+        var line   InvoiceLine
+        var lineID int
+        var entryID int
+
+        if err := rows.Scan(&lineID, &line.LineNo, &line.UserID, &line.Description, &line.Minutes, &line.HourlyRateCents, &line.AmountCents, &entryID); err != nil {
+            return Invoice{}, err
+        }
+        if lineID != lastLineID {
+            inv.Lines  = append(inv.Lines, line)
+            lastLineID = lineID
+        }
+        if entryID != 0 {
+            last             := &inv.Lines[len(inv.Lines)-1]
+            last.TimeEntryIDs = append(last.TimeEntryIDs, entryID)
+        }
+    }
+
+    return inv, rows.Err()
+}
+
+func queryInvoices(inDB *sql.DB, inWhere string, inArgs ...any) ([]Invoice, error) {
+    rows, err := inDB.Query(fmt.Sprintf(`
+SELECT
+      i.invoice_id
+    , i.invoice_number
+    , i.kind
+    , i.client_id
+    , cd.client_name
+    , i.currency
+    , i.issue_date
+    , i.period_from
+    , i.period_to
+    , COALESCE(i.credited_invoice_id, 0)
+    , COALESCE(ci.invoice_number, 0)
+    , i.note
+    , i.total_cents
+FROM
+    invoice             AS i
+    JOIN client_dim     AS cd
+        ON cd.client_id = i.client_id
+    LEFT JOIN invoice   AS ci
+        ON ci.invoice_id = i.credited_invoice_id
+WHERE
+    %s
+ORDER BY
+    i.invoice_number
+    `, inWhere), inArgs...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var invoices []Invoice
+    for rows.Next() {
+        var inv Invoice
+        err := rows.Scan(&inv.InvoiceID, &inv.InvoiceNumber, &inv.Kind, &inv.ClientID, &inv.ClientName, &inv.Currency,
+            &inv.IssueDate, &inv.PeriodFrom, &inv.PeriodTo, &inv.CreditedInvoiceID, &inv.CreditedNumber, &inv.Note, &inv.TotalCents)
+        if err != nil {
+            return nil, err
+        }
+        invoices = append(invoices, inv)
+    }
+
+    return invoices, rows.Err()
+}
+
+// parseDateOr reads a YYYY-MM-DD date, an empty text gives the fallback
+func parseDateOr(inText string, inFallback time.Time) (time.Time, error) {
+    if strings.TrimSpace(inText) == "" {
+        return inFallback, nil
+    }
+
+    date, err := time.Parse("2006-01-02", strings.TrimSpace(inText))
+    if err != nil {
+        return time.Time{}, newError("invalid date %q, expected YYYY-MM-DD", inText)
+    }
+
+    return date, nil
+}
+
+// rateCardIDs looks up the client, role and user of an organization's rate card by name, empty names mean "any"
+func rateCardIDs(inDB *sql.DB, inOrganizationID int, inClientName string, inRoleName string, inUsername string) (int, int, int, error) {
+    var clientID int
+
+    // A rate card for a new client registers the client
+    if strings.TrimSpace(inClientName) != "" {
+        client, err := ensureClient(inDB, inOrganizationID, inClientName)
+        if err != nil {
+            return 0, 0, 0, err
+        }
+        clientID = client.ClientID
+    }
+
+    lookups := []struct {
+        name  string
+        query string
+        id    int
+    }{
+        {inRoleName,   "SELECT role_dim_id FROM auth_role_dim WHERE role_name = ?", 0},
+        {inUsername,   "SELECT user_id FROM user_dim WHERE username = ?", 0},
+    }
+    for i := range lookups {
+        name := strings.TrimSpace(lookups[i].name)
+        if name == "" {
+            continue
+        }
+        if err := inDB.QueryRow(lookups[i].query, name).Scan(&lookups[i].id); err != nil {
+            if errors.Is(err, sql.ErrNoRows) {
+                return 0, 0, 0, newError("%q does not exist", name)
+            }
+            return 0, 0, 0, err
+        }
+    }
+
+    return clientID, lookups[0].id, lookups[1].id, nil
+}
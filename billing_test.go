@@ -0,0 +1,230 @@
+package main
+
+import (
+    "bytes"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "strings"
+    "testing"
+    "time"
+)
+
+// approvedWeek logs time for a user on ACME and gets the week approved by Ray
+func approvedWeek(t *testing.T, inDB *sql.DB, inDbPath string, inUserID int, inMinutes int, inDay time.Time) {
+    t.Helper()
+
+    enforcer   := openTestEnforcer(t, inDbPath)
+    entry, err := addTimeEntry(inDB, 1, inUserID, "ACME", inDay, inMinutes)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, step := range []struct {
+        actorID int
+        action  string
+    }{{inUserID, timesheetActSubmit}, {1, timesheetActApprove}} {
+        if _, err := transitionTimesheet(inDB, enforcer, step.actorID, entry.TimesheetID, step.action, ""); err != nil {
+            t.Fatal(err)
+        }
+    }
+}
+
+func Test_resolveRate(t *testing.T) {
+    db, _ := openTestDb(t)
+    day   := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    // A client of Steaby without rates of its own, Globex is a client of the labs
+    initech, err := ensureClient(db, 1, "Initech")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name     string
+        clientID int
+        userID   int
+        date     time.Time
+        want     int64
+        wantErr  error
+    }{
+        {"user rate beats role rate",     1, 2, day, 8000, nil},
+        {"role rate on the client",       1, 3, day, 6000, nil},
+        {"general rate for the admin",    1, 1, day, 5000, nil},
+        {"general rate on other client",  initech.ClientID, 3, day, 5000, nil},
+        {"no rates of other org",         2, 1, day, 0, errNoRateCard},
+        {"nothing before rates exist",    1, 3, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0, errNoRateCard},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tx, _ := db.Begin()
+            defer tx.Rollback()
+
+            got, _, err := resolveRate(tx, tt.clientID, tt.userID, tt.date)
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("resolveRate() error = %v, want %v", err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("resolveRate() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func Test_invoiceLifecycle(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    day         := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+    from, to    := day.AddDate(0, 0, -10), day.AddDate(0, 0, 10)
+
+    approvedWeek(t, db, dbPath, 2, 90, day)
+    approvedWeek(t, db, dbPath, 3, 45, day)
+
+    inv, err := generateInvoice(db, 1, "acme", from, to, day)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Petar 0.75h at 60 and Tadej 1.5h at 80
+    if inv.DocumentNumber() != "INV-000001" || inv.TotalCents != 4500+12000 || len(inv.Lines) != 2 {
+        t.Fatalf("generateInvoice() = %+v", inv)
+    }
+
+    // Billed weeks are locked and nothing is billed twice
+    ts, _ := getOrCreateTimesheet(db, 1, 2, day)
+    if ts.State != timesheetLocked {
+        t.Errorf("billed timesheet state = %v, want %v", ts.State, timesheetLocked)
+    }
+    if _, err := generateInvoice(db, 1, "ACME", from, to, day); !errors.Is(err, errNothingToInvoice) {
+        t.Errorf("second generateInvoice() error = %v, want %v", err, errNothingToInvoice)
+    }
+
+    // Issued invoices are immutable
+    if _, err := db.Exec("UPDATE invoice SET total_cents = 0 WHERE invoice_id = ?", inv.InvoiceID); err == nil {
+        t.Error("invoice could be updated")
+    }
+    if _, err := db.Exec("DELETE FROM invoice_line WHERE invoice_id = ?", inv.InvoiceID); err == nil {
+        t.Error("invoice lines could be deleted")
+    }
+
+    credit, err := creditInvoice(db, 1, inv.InvoiceID, day, "wrong rate")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if credit.DocumentNumber() != "CN-000002" || credit.TotalCents != -inv.TotalCents || credit.CreditedDocumentNumber() != "INV-000001" {
+        t.Errorf("creditInvoice() = %+v", credit)
+    }
+    if _, err := creditInvoice(db, 1, inv.InvoiceID, day, ""); !errors.Is(err, errAlreadyCredited) {
+        t.Errorf("second creditInvoice() error = %v, want %v", err, errAlreadyCredited)
+    }
+
+    // The credit note frees the entries, so they can be billed again
+    reissued, err := generateInvoice(db, 1, "ACME", from, to, day)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if reissued.InvoiceNumber != 3 || reissued.TotalCents != inv.TotalCents {
+        t.Errorf("reissued invoice = %+v", reissued)
+    }
+
+    loaded, err := getInvoice(db, 1, reissued.InvoiceID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(loaded.Lines) != 2 || len(loaded.Lines[0].TimeEntryIDs) != 1 || loaded.ClientName != "ACME" {
+        t.Errorf("getInvoice() = %+v", loaded)
+    }
+}
+
+func Test_generateInvoiceBillable(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    day        := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    // Development is billed, Warranty fixes is a task and Pre-sales a project that are not
+    var timesheetID int
+    for _, logged := range []struct {
+        taskID  int
+        minutes int
+    }{{2, 60}, {4, 30}, {5, 15}} {
+        entry, err := addTaskTimeEntry(db, enforcer, 2, logged.taskID, day, logged.minutes)
+        if err != nil {
+            t.Fatal(err)
+        }
+        timesheetID = entry.TimesheetID
+    }
+    for _, step := range []struct {
+        actorID int
+        action  string
+    }{{2, timesheetActSubmit}, {1, timesheetActApprove}} {
+        if _, err := transitionTimesheet(db, enforcer, step.actorID, timesheetID, step.action, ""); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    inv, err := generateInvoice(db, 1, "ACME", day.AddDate(0, 0, -1), day.AddDate(0, 0, 1), day)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Tadej's hour of development at 80
+    if inv.TotalCents != 8000 || len(inv.Lines) != 1 || inv.Lines[0].Minutes != 60 || len(inv.Lines[0].TimeEntryIDs) != 1 {
+        t.Errorf("generateInvoice() = %+v, want only the billable hour", inv)
+    }
+}
+
+func Test_renderInvoice(t *testing.T) {
+    inv := Invoice{
+        InvoiceNumber:     7,
+        Kind:              invoiceKindCreditNote,
+        ClientName:        "ACME (Europe)",
+        Currency:          "EUR",
+        CreditedInvoiceID: 6,
+        CreditedNumber:    6,
+        TotalCents:        -12000,
+        Lines:             []InvoiceLine{{LineNo: 1, Description: "Tadej", Minutes: -90, HourlyRateCents: 8000, AmountCents: -12000}},
+    }
+
+    pdf := renderInvoicePDF(inv)
+    if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.Contains(pdf, []byte("(CREDIT NOTE CN-000007) Tj")) || !bytes.Contains(pdf, []byte(`ACME \(Europe\)`)) {
+        t.Errorf("renderInvoicePDF() = %s", pdf)
+    }
+
+    ubl, err := renderInvoiceUBL(inv)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, want := range []string{"<CreditNote xmlns=", "<cbc:CreditNoteTypeCode>381<", `<cbc:CreditedQuantity unitCode="HUR">1.50<`, `<cbc:PayableAmount currencyID="EUR">120.00<`, "<cbc:ID>INV-000006</cbc:ID>"} {
+        if !strings.Contains(string(ubl), want) {
+            t.Errorf("renderInvoiceUBL() misses %q:\n%s", want, ubl)
+        }
+    }
+
+    jsonDoc, err := renderInvoiceJSON(inv)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var decoded map[string]any
+    if err := json.Unmarshal(jsonDoc, &decoded); err != nil || decoded["total"] != "-120.00" || decoded["credits"] != "INV-000006" {
+        t.Errorf("renderInvoiceJSON() = %s", jsonDoc)
+    }
+}
+
+func Test_parseCents(t *testing.T) {
+    tests := []struct {
+        text    string
+        want    int64
+        wantErr bool
+    }{
+        {"60",     6000, false},
+        {"60.5",   6050, false},
+        {"60,05",  6005, false},
+        {"60.005", 0,    true},
+        {"-1",     0,    true},
+        {"-0.5",   0,    true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.text, func(t *testing.T) {
+            got, err := parseCents(tt.text)
+            if (err != nil) != tt.wantErr || got != tt.want {
+                t.Errorf("parseCents() = %v, %v, want %v, wantErr %v", got, err, tt.want, tt.wantErr)
+            }
+        })
+    }
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "showcase_desktop/widgets"
+    "strings"
+    "time"
+)
+
+
+// invoiceRow keeps the buttons of one issued document between frames
+type invoiceRow struct {
+    invoice     Invoice
+    credited    bool
+    exportBtn   widget.Clickable
+    creditBtn   widget.Clickable
+}
+
+
+// runBilling lets users with write access on "invoice" manage the organization's rate cards, generate invoices and
+// issue credit notes
+func runBilling(inWindow *app.Window, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var rateClientTextbox   widget.Editor
+    var rateRoleTextbox     widget.Editor
+    var rateUserTextbox     widget.Editor
+    var rateTextbox         widget.Editor
+    var rateFromTextbox     widget.Editor
+    var rateToTextbox       widget.Editor
+    var addRateBtn          widget.Clickable
+    var invClientTextbox    widget.Editor
+    var invFromTextbox      widget.Editor
+    var invToTextbox        widget.Editor
+    var creditNoteTextbox   widget.Editor
+    var generateBtn         widget.Clickable
+    var invoiceList         widget.List
+    var rows                []*invoiceRow
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Billing")
+    invoiceList.Axis         = layout.Vertical
+
+    refreshRows := func() {
+        invoices, err := listInvoices(inS3db, inEnforcer.OrganizationID)
+        if err != nil {
+            log.Print(err)
+            statusMsg = tr().Text("Could not load invoices")
+            return
+        }
+
+        credited := map[int]bool{}
+        for _, inv := range invoices {
+            credited[inv.CreditedInvoiceID] = true
+        }
+
+        rows = rows[:0]
+        for _, inv := range invoices {
+            rows = append(rows, &invoiceRow{invoice: inv, credited: credited[inv.InvoiceID]})
+        }
+    }
+    refreshRows()
+
+    // Forms put three inputs side by side, so the window needs to be wider than the default
+    inWindow.Option(app.Title(tr().Text("Billing")), app.Size(unit.Dp(1050), unit.Dp(800)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            // Every change asks again, the policy may have changed since the window opened
+            mayChange := func() bool {
+                if err := inEnforcer.checkActorWrite(inS3db, invoiceObject, errInvoiceDenied); err != nil {
+                    statusMsg = errorText(err)
+                    return false
+                }
+                return true
+            }
+
+            // Add a rate card
+            if addRateBtn.Clicked(gtx) && mayChange() {
+                statusMsg = addRateCardFromForm(inS3db, inEnforcer.OrganizationID, rateClientTextbox.Text(), rateRoleTextbox.Text(), rateUserTextbox.Text(),
+                    rateTextbox.Text(), rateFromTextbox.Text(), rateToTextbox.Text())
+            }
+
+            // Generate an invoice from approved time
+            if generateBtn.Clicked(gtx) && mayChange() {
+                statusMsg = generateInvoiceFromForm(inS3db, inEnforcer.OrganizationID, invClientTextbox.Text(), invFromTextbox.Text(), invToTextbox.Text())
+                refreshRows()
+            }
+
+            // Export or credit an issued document
+            for _, row := range rows {
+                if row.exportBtn.Clicked(gtx) {
+                    inv, err := getInvoice(inS3db, inEnforcer.OrganizationID, row.invoice.InvoiceID)
+                    if err == nil {
+                        var paths []string
+                        paths, err = exportInvoice(inv, invoiceFolder)
+                        statusMsg  = tr().Sprintf("Exported %s", strings.Join(paths, ", "))
+                    }
+                    if err != nil {
+                        statusMsg = tr().Sprintf("Could not export %s: %s", row.invoice.DocumentNumber(), errorText(err))
+                    }
+                }
+                if row.creditBtn.Clicked(gtx) && mayChange() {
+                    credit, err := creditInvoice(inS3db, inEnforcer.OrganizationID, row.invoice.InvoiceID, time.Now(), creditNoteTextbox.Text())
+                    if err != nil {
+                        statusMsg = tr().Sprintf("Could not credit %s: %s", row.invoice.DocumentNumber(), errorText(err))
+                    } else {
+                        statusMsg = tr().Sprintf("Issued %s for %s", credit.DocumentNumber(), row.invoice.DocumentNumber())
+                        creditNoteTextbox.SetText("")
+                    }
+                    refreshRows()
+                    break
+                }
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                // Result of the last action
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                // Rate card form
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &rateClientTextbox, tr().Text("Client (empty = any)")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &rateRoleTextbox, tr().Text("Role (empty = any)")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &rateUserTextbox, tr().Text("User (empty = any)")).Layout(gtx) },
+                    )
+                }),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &rateTextbox, tr().Text("Hourly rate, e.g. 60.00")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &rateFromTextbox, tr().Text("Valid from (empty = today)")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &rateToTextbox, tr().Text("Valid to (empty = open)")).Layout(gtx) },
+                    )
+                }),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Button(theme, &addRateBtn, tr().Text("Add rate")).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                // Invoice form
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &invClientTextbox, tr().Text("Client to invoice")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &invFromTextbox, tr().Text("Period from YYYY-MM-DD")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &invToTextbox, tr().Text("Period to YYYY-MM-DD")).Layout(gtx) },
+                    )
+                }),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Button(theme, &generateBtn, tr().Text("Generate invoice")).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                // Reason printed on the next credit note
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.InputBox(theme, &creditNoteTextbox, tr().Text("Reason for the next credit note")).Layout(gtx)
+                }),
+
+                // Issued invoices and credit notes
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &invoiceList).Layout(gtx, len(rows), func(gtx layout.Context, index int) layout.Dimensions {
+                        return invoiceRowElement(gtx, theme, rows[index])
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
+
+
+// addRateCardFromForm validates the rate card inputs and returns the message to show
+func addRateCardFromForm(inS3db *sql.DB, inOrganizationID int, inClientName string, inRoleName string, inUsername string, inRate string, inFrom string, inTo string) string {
+    clientID, roleID, userID, err := rateCardIDs(inS3db, inOrganizationID, inClientName, inRoleName, inUsername)
+    if err != nil {
+        return tr().Sprintf("Could not add the rate: %s", errorText(err))
+    }
+    rateCents, err := parseCents(inRate)
+    if err != nil {
+        return errorText(err)
+    }
+    validFrom, err := parseDateOr(inFrom, time.Now())
+    if err != nil {
+        return errorText(err)
+    }
+    validTo, err := parseDateOr(inTo, time.Time{})
+    if err != nil {
+        return errorText(err)
+    }
+
+    rate := RateCard{ClientID: clientID, RoleID: roleID, UserID: userID, HourlyRateCents: rateCents, ValidFrom: validFrom, ValidTo: validTo}
+    if err := addRateCard(inS3db, inOrganizationID, rate); err != nil {
+        return tr().Sprintf("Could not add the rate: %s", errorText(err))
+    }
+
+    return tr().Sprintf("Added a rate of %s/h from %s", tr().Money(rateCents, ""), tr().Date(validFrom))
+}
+
+// generateInvoiceFromForm validates the invoice inputs and returns the message to show
+func generateInvoiceFromForm(inS3db *sql.DB, inOrganizationID int, inClientName string, inFrom string, inTo string) string {
+    if strings.TrimSpace(inClientName) == "" {
+        return tr().Text("Please enter the client to invoice")
+    }
+    periodFrom, err := parseDateOr(inFrom, time.Time{})
+    if err != nil || periodFrom.IsZero() {
+        return tr().Text("Please enter the start of the period as YYYY-MM-DD")
+    }
+    periodTo, err := parseDateOr(inTo, time.Now())
+    if err != nil {
+        return errorText(err)
+    }
+
+    inv, err := generateInvoice(inS3db, inOrganizationID, inClientName, periodFrom, periodTo, time.Now())
+    if err != nil {
+        return tr().Sprintf("Could not generate the invoice: %s", errorText(err))
+    }
+
+    return tr().Sprintf("Issued %s over %s", inv.DocumentNumber(), tr().Money(inv.TotalCents, inv.Currency))
+}
+
+
+// formRowElement puts a few inputs next to each other, each taking an equal share of the width
+func formRowElement(inGTX layout.Context, inInputs ...layout.Widget) layout.Dimensions {
+    var children []layout.FlexChild
+
+    for _, input := range inInputs {
+        children = append(children, layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+            return layout.UniformInset(unit.Dp(3)).Layout(gtx, input)
+        }))
+    }
+
+    return layout.Flex{Axis: layout.Horizontal}.Layout(inGTX, children...)
+}
+
+
+func invoiceRowElement(inGTX layout.Context, inTheme *widgets.Theme, inRow *invoiceRow) layout.Dimensions {
+    inv     := inRow.invoice
+    rowText := fmt.Sprintf("%s  %s  %s..%s  %s", inv.DocumentNumber(), inv.ClientName, tr().Date(inv.PeriodFrom), tr().Date(inv.PeriodTo), tr().Money(inv.TotalCents, inv.Currency))
+    if inRow.credited {
+        rowText += tr().Text("  (credited)")
+    }
+
+    return layout.UniformInset(unit.Dp(5)).Layout(inGTX, func(gtx layout.Context) layout.Dimensions {
+        return layout.Flex{
+            Axis:      layout.Horizontal,
+            Alignment: layout.Middle,
+        }.Layout(gtx,
+            // Document summary
+            layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                return material.Body1(inTheme.Theme, rowText).Layout(gtx)
+            }),
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                return material.Button(inTheme.Theme, &inRow.exportBtn, tr().Text("Export")).Layout(gtx)
+            }),
+            layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+            // Only invoices that are still standing can be credited
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                if inv.Kind != invoiceKindInvoice || inRow.credited {
+                    return layout.Dimensions{}
+                }
+                return material.Button(inTheme.Theme, &inRow.creditBtn, tr().Text("Credit")).Layout(gtx)
+            }),
+        )
+    })
+}
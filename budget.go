@@ -0,0 +1,315 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+)
+
+
+// Casbin object letting a user log time on a blocking project past its budget
+const budgetOverrideObject = "budget_override"
+
+// Used share of a budget, in percent, at which an alert is raised
+var budgetThresholds = []int{75, 90, 100}
+
+var errOverBudget = errors.New("project is over its budget")
+
+// BudgetStatus is how much of a project's budget is used, in minutes for an hours budget and in cents for a money one.
+// Approved counts approved and billed weeks, pending the weeks still on their way there. Rejected weeks are kept apart,
+// they use nothing until they are submitted again.
+type BudgetStatus struct {
+    Project         Project
+    Currency        string
+    ApprovedUsed    int64
+    PendingUsed     int64
+    RejectedUsed    int64
+}
+
+// BudgetAlert is a threshold a project crossed, raised once per project and threshold
+type BudgetAlert struct {
+    BudgetAlertID   int
+    ProjectID       int
+    ProjectName     string
+    ClientName      string
+    Threshold       int
+    UsedPercent     int
+    RaisedAt        time.Time
+}
+
+func (b BudgetStatus) Used() int64 {
+    return b.ApprovedUsed + b.PendingUsed
+}
+
+// Percent is the used share of the budget, rounded down
+func (b BudgetStatus) Percent() int {
+    if b.Project.BudgetAmount <= 0 {
+        return 0
+    }
+
+    return int(b.Used() * 100 / b.Project.BudgetAmount)
+}
+
+// Threshold is the highest alert threshold the project crossed, zero if none
+func (b BudgetStatus) Threshold() int {
+    crossed := 0
+    for _, threshold := range budgetThresholds {
+        if b.Percent() >= threshold {
+            crossed = threshold
+        }
+    }
+
+    return crossed
+}
+
+// Text is the status in the user's language, e.g. "ACME / Website: 76.50h of 100.00h used (76%), 10.00h pending"
+func (b BudgetStatus) Text() string {
+    format := func(inAmount int64) string {
+        if b.Project.BudgetKind == budgetMoney {
+            return tr().Money(inAmount, b.Currency)
+        }
+        return tr().Duration(int(inAmount))
+    }
+
+    return tr().Sprintf("%s / %s: %s of %s used (%d%%), %s pending", b.Project.ClientName, b.Project.ProjectName,
+        format(b.Used()), b.Project.BudgetText(b.Currency), b.Percent(), format(b.PendingUsed))
+}
+
+
+// entryBudgetCost is what an entry takes from the project's budget - its minutes, or its amount at the user's rate
+func entryBudgetCost(inDB dbRunner, inProject Project, inUserID int, inDate time.Time, inMinutes int) (int64, error) {
+    if inProject.BudgetKind != budgetMoney {
+        return int64(inMinutes), nil
+    }
+
+    rateCents, _, err := resolveRate(inDB, inProject.ClientID, inUserID, inDate)
+    if errors.Is(err, errNoRateCard) {
+        // Time nobody can bill doesn't use up money
+        return 0, nil
+    }
+    if err != nil {
+        return 0, err
+    }
+
+    return lineAmount(inMinutes, rateCents), nil
+}
+
+// projectBudgetStatus sums every entry logged on the project against its budget
+func projectBudgetStatus(inDB dbRunner, inProject Project) (BudgetStatus, error) {
+    status := BudgetStatus{Project: inProject}
+
+    err := inDB.QueryRow("SELECT currency FROM client_dim WHERE client_id = ?", inProject.ClientID).Scan(&status.Currency)
+    if err != nil {
+        return status, err
+    }
+
+    rows, err := inDB.Query(`
+SELECT
+      te.user_id
+    , te.entry_date
+    , te.minutes_spent
+    , ts.state
+FROM
+    time_entry                  AS te
+    JOIN timesheet              AS ts
+        ON ts.timesheet_id = te.timesheet_id
+WHERE
+    te.project_id = ?
+    `, inProject.ProjectID)
+    if err != nil {
+        return status, err
+    }
+
+    type usedEntry struct {
+        userID    int
+        entryDate time.Time
+        minutes   int
+        state     string
+    }
+    var entries []usedEntry
+    for rows.Next() {
+        var entry usedEntry
+        if err := rows.Scan(&entry.userID, &entry.entryDate, &entry.minutes, &entry.state); err != nil {
+            rows.Close()
+            return status, err
+        }
+        entries = append(entries, entry)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return status, err
+    }
+
+    // Rates are looked up once the rows are closed, so this works the same inside a transaction
+    for _, entry := range entries {
+        cost, err := entryBudgetCost(inDB, inProject, entry.userID, entry.entryDate, entry.minutes)
+        if err != nil {
+            return status, err
+        }
+        switch entry.state {
+        case timesheetApproved, timesheetLocked:
+            status.ApprovedUsed += cost
+        case timesheetRejected:
+            status.RejectedUsed += cost
+        default:
+            status.PendingUsed += cost
+        }
+    }
+
+    return status, nil
+}
+
+// budgetStatuses returns the budget use of every project with a budget the user may read
+func budgetStatuses(inDB dbRunner, inEnforcer *OrgEnforcer, inUserID int) ([]BudgetStatus, error) {
+    projects, err := listProjects(inDB, inEnforcer, inUserID, 0)
+    if err != nil {
+        return nil, err
+    }
+
+    var statuses []BudgetStatus
+    for _, project := range projects {
+        if project.BudgetKind == "" {
+            continue
+        }
+        status, err := projectBudgetStatus(inDB, project)
+        if err != nil {
+            return nil, err
+        }
+        statuses = append(statuses, status)
+    }
+
+    return statuses, nil
+}
+
+// checkBudget stops an entry that would take a blocking project over its budget,
+// unless the user has "write" on budget_override. Rejected weeks count here, they come back once resubmitted.
+// Time logged on a client only is on no project, so no budget is checked for it.
+func checkBudget(inDB dbRunner, inEnforcer *OrgEnforcer, inUserID int, inProject Project, inDate time.Time, inMinutes int) error {
+    if !inProject.BlockOverBudget || inProject.BudgetKind == "" {
+        return nil
+    }
+
+    status, err := projectBudgetStatus(inDB, inProject)
+    if err != nil {
+        return err
+    }
+    cost, err := entryBudgetCost(inDB, inProject, inUserID, inDate, inMinutes)
+    if err != nil {
+        return err
+    }
+    if status.Used()+status.RejectedUsed+cost <= inProject.BudgetAmount {
+        return nil
+    }
+
+    canOverride, err := inEnforcer.Enforce(fmt.Sprintf("u%d", inUserID), budgetOverrideObject, "write")
+    if err != nil {
+        return err
+    }
+    if canOverride {
+        return nil
+    }
+
+    logAuditEvent(inDB, AuditEvent{ActorID: inUserID, OrganizationID: inEnforcer.OrganizationID, EventType: auditDenied,
+        Object: fmt.Sprintf("%s %s", budgetOverrideObject, projectObject(inProject.ProjectID)), After: "write"})
+    return fmt.Errorf("%w: %s", errOverBudget, status.Text())
+}
+
+// raiseBudgetAlerts records the thresholds the project crossed and returns the ones that are new
+func raiseBudgetAlerts(inDB dbRunner, inStatus BudgetStatus) ([]int, error) {
+    var raised []int
+
+    for _, threshold := range budgetThresholds {
+        if inStatus.Percent() < threshold {
+            break
+        }
+        result, err := inDB.Exec("INSERT OR IGNORE INTO budget_alert (project_id, threshold, used_percent, raised_at) VALUES (?, ?, ?, ?)",
+            inStatus.Project.ProjectID, threshold, inStatus.Percent(), time.Now().UTC().Format("2006-01-02 15:04:05"))
+        if err != nil {
+            return raised, err
+        }
+        if count, _ := result.RowsAffected(); count > 0 {
+            raised = append(raised, threshold)
+        }
+    }
+
+    return raised, nil
+}
+
+// updateBudgetAlerts raises the alerts the project's entries crossed after one of them changed. The change is done
+// either way, so a failing alert only gets logged - an error would have the user log the same time again.
+func updateBudgetAlerts(inDB dbRunner, inProject Project) {
+    if inProject.BudgetKind == "" {
+        return
+    }
+
+    status, err := projectBudgetStatus(inDB, inProject)
+    if err == nil {
+        _, err = raiseBudgetAlerts(inDB, status)
+    }
+    if err != nil {
+        log.Printf("Failed to raise the budget alerts of %s: %v", inProject.ProjectName, err)
+    }
+}
+
+// listBudgetAlerts returns the alerts of the organization's projects the user may read, newest first
+func listBudgetAlerts(inDB dbRunner, inEnforcer *OrgEnforcer, inUserID int) ([]BudgetAlert, error) {
+    rows, err := inDB.Query(`
+SELECT
+      ba.budget_alert_id
+    , ba.project_id
+    , pr.project_name
+    , cd.client_name
+    , ba.threshold
+    , ba.used_percent
+    , ba.raised_at
+FROM
+    budget_alert                AS ba
+    JOIN project                AS pr
+        ON pr.project_id = ba.project_id
+    JOIN client_dim             AS cd
+        ON cd.client_id = pr.client_id
+WHERE
+    cd.organization_id = ?
+ORDER BY
+    ba.budget_alert_id DESC
+    `, inEnforcer.OrganizationID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var alerts []BudgetAlert
+    for rows.Next() {
+        var alert BudgetAlert
+        if err := rows.Scan(&alert.BudgetAlertID, &alert.ProjectID, &alert.ProjectName, &alert.ClientName, &alert.Threshold, &alert.UsedPercent, &alert.RaisedAt); err != nil {
+            return nil, err
+        }
+
+        canRead, err := inEnforcer.Enforce(fmt.Sprintf("u%d", inUserID), projectObject(alert.ProjectID), "read")
+        if err != nil {
+            return nil, err
+        }
+        if canRead {
+            alerts = append(alerts, alert)
+        }
+    }
+
+    return alerts, rows.Err()
+}
+
+// budgetAlertText sums up the projects past a threshold for the main window, empty when all are fine
+func budgetAlertText(inStatuses []BudgetStatus) string {
+    var parts []string
+    for _, status := range inStatuses {
+        if status.Threshold() > 0 {
+            parts = append(parts, tr().Sprintf("%s at %d%%", status.Project.ProjectName, status.Percent()))
+        }
+    }
+    if len(parts) == 0 {
+        return ""
+    }
+
+    return tr().Sprintf("Budget alert: %s", strings.Join(parts, ", "))
+}
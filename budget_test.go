@@ -0,0 +1,199 @@
+package main
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+func Test_BudgetStatus(t *testing.T) {
+    website := Project{ProjectName: "Website", BudgetKind: budgetHours, BudgetAmount: 6000}
+
+    tests := []struct {
+        name          string
+        status        BudgetStatus
+        wantPercent   int
+        wantThreshold int
+    }{
+        {"nothing used",        BudgetStatus{Project: website},                                      0,   0},
+        {"just under 75",       BudgetStatus{Project: website, ApprovedUsed: 4000, PendingUsed: 499}, 74,  0},
+        {"pending counts too",  BudgetStatus{Project: website, ApprovedUsed: 4000, PendingUsed: 500}, 75,  75},
+        {"past 90",             BudgetStatus{Project: website, PendingUsed: 5520},                    92,  90},
+        {"over the budget",     BudgetStatus{Project: website, ApprovedUsed: 6600},                   110, 100},
+        {"no budget",           BudgetStatus{Project: Project{}, ApprovedUsed: 600},                  0,   0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.status.Percent(); got != tt.wantPercent {
+                t.Errorf("Percent() = %d, want %d", got, tt.wantPercent)
+            }
+            if got := tt.status.Threshold(); got != tt.wantThreshold {
+                t.Errorf("Threshold() = %d, want %d", got, tt.wantThreshold)
+            }
+        })
+    }
+}
+
+func Test_budgetAlerts(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    day        := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    // Tadej logs on the website design task, the website blocks at 100h
+    tests := []struct {
+        name        string
+        minutes     int
+        wantErr     error
+        wantAlerts  int
+    }{
+        {"under every threshold",   4000, nil,           0},
+        {"crosses 75",              500,  nil,           1},
+        {"stays under 90",          300,  nil,           1},
+        {"crosses 90 and 100",      1200, nil,           3},
+        {"blocked past the budget", 60,   errOverBudget, 3},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := addTaskTimeEntry(db, enforcer, 2, 1, day, tt.minutes)
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("addTaskTimeEntry() error = %v, want %v", err, tt.wantErr)
+            }
+            alerts, err := listBudgetAlerts(db, enforcer, 2)
+            if err != nil || len(alerts) != tt.wantAlerts {
+                t.Errorf("listBudgetAlerts() = %+v, %v, want %d alerts", alerts, err, tt.wantAlerts)
+            }
+        })
+    }
+
+    denials, err := listAuditEvents(db, AuditFilter{OrganizationID: 1, EventType: auditDenied})
+    if err != nil || len(denials) != 1 || denials[0].Object != "budget_override project_1" {
+        t.Errorf("listAuditEvents() of the denials = %+v, %v, want the blocked entry", denials, err)
+    }
+
+    statuses, err := budgetStatuses(db, enforcer, 2)
+    if err != nil || len(statuses) != 2 {
+        t.Fatalf("budgetStatuses() = %+v, %v", statuses, err)
+    }
+    if got := budgetAlertText(statuses); got != "Budget alert: Website at 100%" {
+        t.Errorf("budgetAlertText() = %q", got)
+    }
+
+    // Users with write on budget_override may go past it
+    if _, err := db.Exec("INSERT INTO auth_user_policy (user_policy_id, subject, object, action, effect) VALUES (3, 2, 'budget_override', 'write', 'allow')"); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := addTaskTimeEntry(db, openTestEnforcer(t, dbPath), 2, 1, day, 60); err != nil {
+        t.Errorf("addTaskTimeEntry() with override error = %v", err)
+    }
+
+    // Petar may not read the website anymore, so they get no alerts for it
+    if _, err := db.Exec("INSERT INTO auth_user_policy (user_policy_id, subject, object, action, effect) VALUES (4, 3, 'project_1', 'read', 'deny')"); err != nil {
+        t.Fatal(err)
+    }
+    alerts, err := listBudgetAlerts(db, openTestEnforcer(t, dbPath), 3)
+    if err != nil || len(alerts) != 0 {
+        t.Errorf("listBudgetAlerts() for Petar = %+v, %v", alerts, err)
+    }
+}
+
+// Editing, restoring or undoing an entry can't take a project past its budget either
+func Test_budgetOnChange(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    day        := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    // A minute on the website, which blocks at 100h
+    entry, err := addTaskTimeEntry(db, enforcer, 2, 1, day, 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 7000, day); !errors.Is(err, errOverBudget) {
+        t.Errorf("updateTimeEntry() past the budget error = %v, want %v", err, errOverBudget)
+    }
+    if _, err := updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 4600, day); err != nil {
+        t.Fatal(err)
+    }
+    if alerts, err := listBudgetAlerts(db, enforcer, 2); err != nil || len(alerts) != 1 || alerts[0].Threshold != 75 {
+        t.Errorf("listBudgetAlerts() after the edit = %+v, %v, want the 75%% one", alerts, err)
+    }
+
+    // Going down always works, going back up to the old time only while there is room for it
+    if _, err := updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 60, day); err != nil {
+        t.Fatal(err)
+    }
+    // ... which Petar takes meanwhile
+    if _, err := addTaskTimeEntry(db, enforcer, 3, 2, day, 5000); err != nil {
+        t.Fatal(err)
+    }
+    versions, _ := timeEntryHistory(db, entry.TimeEntryID)
+    if _, err := restoreTimeEntryVersion(db, enforcer, 2, versions[1].VersionID, day); !errors.Is(err, errOverBudget) {
+        t.Errorf("restoreTimeEntryVersion() past the budget error = %v, want %v", err, errOverBudget)
+    }
+    if undone, err := undoTimeEntryChanges(db, enforcer, 2, 1, day); !errors.Is(err, errOverBudget) || undone != 0 {
+        t.Errorf("undoTimeEntryChanges() past the budget = %d, %v, want %v", undone, err, errOverBudget)
+    }
+}
+
+// The entry is logged even when its alert can't be raised, so the user doesn't log it twice
+func Test_budgetAlertFailure(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    day        := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    if _, err := db.Exec("DROP TABLE budget_alert"); err != nil {
+        t.Fatal(err)
+    }
+    entry, err := addTaskTimeEntry(db, openTestEnforcer(t, dbPath), 2, 1, day, 5000)
+    if err != nil || entry.TimeEntryID == 0 {
+        t.Fatalf("addTaskTimeEntry() with a failing alert = %+v, %v", entry, err)
+    }
+    if _, err := getTimeEntry(db, entry.TimeEntryID); err != nil {
+        t.Errorf("getTimeEntry() = %v", err)
+    }
+}
+
+func Test_moneyBudget(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    day        := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    // Tadej bills 80/h, so an hour on support tickets takes 80.00 of the 5000.00
+    entry, err := addTaskTimeEntry(db, enforcer, 2, 3, day, 60)
+    if err != nil {
+        t.Fatal(err)
+    }
+    support, err := getProject(db, 2)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    status, err := projectBudgetStatus(db, support)
+    if err != nil || status.PendingUsed != 8000 || status.ApprovedUsed != 0 || status.Currency != "EUR" {
+        t.Fatalf("projectBudgetStatus() = %+v, %v", status, err)
+    }
+
+    type step struct {
+        actorID int
+        action  string
+    }
+    transition := func(inSteps ...step) {
+        for _, step := range inSteps {
+            if _, err := transitionTimesheet(db, enforcer, step.actorID, entry.TimesheetID, step.action, "wrong rate"); err != nil {
+                t.Fatal(err)
+            }
+        }
+    }
+
+    // A rejected week is no longer pending
+    transition(step{2, timesheetActSubmit}, step{1, timesheetActReject})
+    status, err = projectBudgetStatus(db, support)
+    if err != nil || status.PendingUsed != 0 || status.RejectedUsed != 8000 || status.Used() != 0 {
+        t.Errorf("projectBudgetStatus() after rejection = %+v, %v", status, err)
+    }
+
+    // Approving the week moves the amount to approved
+    transition(step{2, timesheetActSubmit}, step{1, timesheetActApprove})
+    status, err = projectBudgetStatus(db, support)
+    if err != nil || status.PendingUsed != 0 || status.ApprovedUsed != 8000 || status.Percent() != 1 {
+        t.Errorf("projectBudgetStatus() after approval = %+v, %v", status, err)
+    }
+}
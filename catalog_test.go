@@ -0,0 +1,433 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "go/ast"
+    "go/parser"
+    "go/token"
+    "path/filepath"
+    "regexp"
+    "slices"
+    "showcase_desktop/i18n"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+    "unicode"
+)
+
+
+// uiFiles are the files that draw. Text reaches the screen from them through the elements, through the variables
+// ending in Text, Msg, Title or Hint, e.g. statusMsg, and through the strings their functions return - none of it
+// may be a literal the catalog doesn't see.
+// Messages passed to tr() are looked up in the catalogs wherever they are.
+var uiFiles = []string{"*_window.go", "*_screen.go", "guarded.go", "project_picker.go", "chart.go", "shortcuts.go"}
+
+// uiFuncs are the functions outside uiFiles whose strings the windows show. Everything they assign, append or return
+// is checked, not only the text variables.
+var uiFuncs = []string{
+    "AccessExplanation.Lines", "AuditEvent.Text", "BudgetStatus.Text", "Project.BudgetText", "TimeEntryVersion.Diff",
+    "TimeEntryVersion.Text", "changeText", "effectText", "errorText", "explainAccessText",
+    "localDateTime", "timesheetStateText",
+}
+
+// shownError tells whether the windows may show the sentinel error, errorText must translate every one they may.
+// Signing out ends a window instead.
+func shownError(inName string) bool {
+    return inName != "errSignedOut"
+}
+
+// uiTextVar is a variable the windows show as text
+var uiTextVar = regexp.MustCompile(`(Text|Msg|Title|Titles|Hint)$`)
+
+// formatVerb is a fmt verb, "%s  %s" has nothing to translate
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.\[\]*]*[a-zA-Z%]`)
+
+// errorVar is a variable holding an error, e.g. err or addErr
+var errorVar = regexp.MustCompile(`(^e|E)rr$`)
+
+// Test_userVisibleLiterals fails for every user-visible literal that doesn't go through tr(), and for every message
+// that misses a translation. CI runs it on its own, so a new label can't go in in English only.
+func Test_userVisibleLiterals(t *testing.T) {
+    fset := token.NewFileSet()
+
+    paths, err := filepath.Glob("*.go")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var messages []trMessage
+    sentinels      := map[string]string{}      // name -> message
+    errorTexts     := map[string]string{}      // sentinel name -> the message errorText translates
+    foundFuncs     := map[string]bool{}
+    for _, path := range paths {
+        if strings.HasSuffix(path, "_test.go") {
+            continue
+        }
+        file, err := parser.ParseFile(fset, path, nil, 0)
+        if err != nil {
+            t.Fatal(err)
+        }
+        messages = append(messages, trMessages(fset, file)...)
+        for name, message := range sentinelErrors(file) {
+            sentinels[name] = message
+        }
+
+        for _, decl := range file.Decls {
+            fun, ok := decl.(*ast.FuncDecl)
+            if !ok || !slices.Contains(uiFuncs, funcName(fun)) {
+                continue
+            }
+            foundFuncs[funcName(fun)] = true
+            for _, literal := range untranslatedLiterals(fun, true) {
+                t.Errorf("%s: %s is shown without going through tr()", fset.Position(literal.Pos()), literalSource(literal))
+            }
+            if fun.Name.Name == "errorText" {
+                errorTexts = errorTextMessages(fun)
+            }
+        }
+
+        if !isUIFile(path) {
+            continue
+        }
+        for _, literal := range untranslatedLiterals(file, false) {
+            t.Errorf("%s: %s is shown without going through tr()", fset.Position(literal.Pos()), literalSource(literal))
+        }
+        for _, shown := range untranslatedErrors(file) {
+            t.Errorf("%s: the error is shown without going through errorText()", fset.Position(shown.Pos()))
+        }
+    }
+
+    if len(messages) == 0 {
+        t.Fatal("found no tr() messages, the check is looking in the wrong place")
+    }
+    for _, name := range uiFuncs {
+        if !foundFuncs[name] {
+            t.Errorf("%s is in uiFuncs but there is no such function", name)
+        }
+    }
+    for name, message := range sentinels {
+        if got, ok := errorTexts[name]; shownError(name) && (!ok || got != message) {
+            t.Errorf("errorText() translates %s as %q, want %q", name, got, message)
+        }
+    }
+    for _, tag := range i18n.Languages {
+        if tag == i18n.English {
+            continue
+        }
+        for _, message := range messages {
+            wantForms := 1
+            if message.plural {
+                wantForms = i18n.PluralForms(tag)
+            }
+            if forms, ok := i18n.Lookup(tag, message.key); !ok || len(forms) != wantForms {
+                t.Errorf("%s: %q has %d of %d forms in %q", message.pos, message.key, len(forms), wantForms, tag)
+            }
+        }
+    }
+}
+
+// Test_errorText checks that the windows get the app's errors in the user's language, with their details
+func Test_errorText(t *testing.T) {
+    setLanguage(i18n.Slovenian)
+    t.Cleanup(func() { setLanguage(i18n.English) })
+
+    _, parseErr := parseTimeSpent("soon")
+    day         := time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)
+    tests := []struct {
+        name    string
+        err     error
+        want    string
+    }{
+        {"sentinel",             errTaskNotFound,                                                "naloge ni mogoče najti"},
+        {"wrapped sentinel",     fmt.Errorf("%w: it is %s", errOverBudget, "ACME / Website"),    "projekt je presegel proračun: it is ACME / Website"},
+        {"with details",         parseErr,                                                       `neveljaven porabljeni čas "soon"`},
+        {"details with a date",  fmt.Errorf("%w: %w", errNoRateCard, newError("user %d, client %d, %s", 2, 1, day)),
+            "nobena postavka se ne ujema: uporabnik 2, stranka 1, 8. 1. 2030"},
+        {"message argument",     fmt.Errorf("%w: %w", errTimesheetTransition, newError("the week is %s", catalogText(timesheetDraft))),
+            "časovnica ne more preiti v to stanje: teden je v stanju osnutek"},
+        {"around a line",        fmt.Errorf("%w: %w", newError("line %d", 3), parseErr),         `vrstica 3: neveljaven porabljeni čas "soon"`},
+        {"from elsewhere",       sql.ErrNoRows,                                                  sql.ErrNoRows.Error()},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := errorText(tt.err); got != tt.want {
+                t.Errorf("errorText() = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+// trMessage is a message key a tr() call passes
+type trMessage struct {
+    pos     token.Position
+    key     string
+    plural  bool
+}
+
+func isUIFile(inPath string) bool {
+    for _, pattern := range uiFiles {
+        if matched, _ := filepath.Match(pattern, inPath); matched {
+            return true
+        }
+    }
+    return false
+}
+
+// untranslatedLiterals returns the literals passed to an element, assigned to a text variable, appended or returned.
+// With inAnyVar every variable counts as a text variable.
+func untranslatedLiterals(inNode ast.Node, inAnyVar bool) []ast.Expr {
+    var found []ast.Expr
+
+    ast.Inspect(inNode, func(node ast.Node) bool {
+        switch node := node.(type) {
+        case *ast.CallExpr:
+            if isTrCall(node) {
+                return false
+            }
+            if isUICall(node) || exprName(node.Fun) == "append" {
+                for _, arg := range node.Args {
+                    if isTextLiteral(arg) {
+                        found = append(found, arg)
+                    }
+                }
+            }
+        case *ast.AssignStmt:
+            for i, lhs := range node.Lhs {
+                if i < len(node.Rhs) && (inAnyVar || uiTextVar.MatchString(exprName(lhs))) && isTextLiteral(node.Rhs[i]) {
+                    found = append(found, node.Rhs[i])
+                }
+            }
+        case *ast.ReturnStmt:
+            for _, result := range node.Results {
+                if isTextLiteral(result) {
+                    found = append(found, result)
+                }
+            }
+        case *ast.ValueSpec:
+            for i, name := range node.Names {
+                if i < len(node.Values) && uiTextVar.MatchString(name.Name) && isTextLiteral(node.Values[i]) {
+                    found = append(found, node.Values[i])
+                }
+            }
+        }
+        return true
+    })
+
+    return found
+}
+
+// untranslatedErrors returns the errors a UI file shows as they are, through Error() or as an argument of tr().
+// Logging them is fine.
+func untranslatedErrors(inFile *ast.File) []ast.Expr {
+    var found []ast.Expr
+
+    ast.Inspect(inFile, func(node ast.Node) bool {
+        call, ok := node.(*ast.CallExpr)
+        if !ok {
+            return true
+        }
+        if selector, ok := call.Fun.(*ast.SelectorExpr); ok {
+            if exprName(selector.X) == "log" {
+                return false
+            }
+            if selector.Sel.Name == "Error" && len(call.Args) == 0 {
+                found = append(found, call)
+            }
+        }
+        if isTrCall(call) {
+            for _, arg := range call.Args {
+                if errorVar.MatchString(exprName(arg)) {
+                    found = append(found, arg)
+                }
+            }
+        }
+        return true
+    })
+
+    return found
+}
+
+// sentinelErrors returns the package's error variables made with errors.New and their messages
+func sentinelErrors(inFile *ast.File) map[string]string {
+    sentinels := map[string]string{}
+
+    for _, decl := range inFile.Decls {
+        gen, ok := decl.(*ast.GenDecl)
+        if !ok || gen.Tok != token.VAR {
+            continue
+        }
+        for _, spec := range gen.Specs {
+            value := spec.(*ast.ValueSpec)
+            for i, name := range value.Names {
+                if i >= len(value.Values) {
+                    continue
+                }
+                call, ok := value.Values[i].(*ast.CallExpr)
+                if !ok || len(call.Args) != 1 {
+                    continue
+                }
+                if selector, ok := call.Fun.(*ast.SelectorExpr); ok && exprName(selector.X) == "errors" && selector.Sel.Name == "New" {
+                    if message, ok := stringLiteral(call.Args[0]); ok {
+                        sentinels[name.Name] = message
+                    }
+                }
+            }
+        }
+    }
+
+    return sentinels
+}
+
+// errorTextMessages returns the sentinels errorText knows and the message it translates each with
+func errorTextMessages(inFunc *ast.FuncDecl) map[string]string {
+    messages := map[string]string{}
+
+    ast.Inspect(inFunc, func(node ast.Node) bool {
+        pair, ok := node.(*ast.CompositeLit)
+        if !ok || len(pair.Elts) != 2 {
+            return true
+        }
+        sentinel, ok1 := pair.Elts[0].(*ast.Ident)
+        call, ok2     := pair.Elts[1].(*ast.CallExpr)
+        if ok1 && ok2 && isTrCall(call) && len(call.Args) == 1 {
+            if message, ok := stringLiteral(call.Args[0]); ok {
+                messages[sentinel.Name] = message
+            }
+        }
+        return true
+    })
+
+    return messages
+}
+
+// funcName is the function's name, with the receiver's type for methods, e.g. "AuditEvent.Text"
+func funcName(inFunc *ast.FuncDecl) string {
+    if inFunc.Recv == nil || len(inFunc.Recv.List) == 0 {
+        return inFunc.Name.Name
+    }
+    receiver := inFunc.Recv.List[0].Type
+    if star, ok := receiver.(*ast.StarExpr); ok {
+        receiver = star.X
+    }
+    return exprName(receiver) + "." + inFunc.Name.Name
+}
+
+// trMessages returns the literal keys of the tr() calls and of the errors made with newError. Formats with nothing
+// to translate, e.g. "%s, %s", are no keys.
+func trMessages(inFset *token.FileSet, inFile *ast.File) []trMessage {
+    var messages []trMessage
+
+    ast.Inspect(inFile, func(node ast.Node) bool {
+        call, ok := node.(*ast.CallExpr)
+        if !ok {
+            return true
+        }
+        if exprName(call.Fun) == "newError" && len(call.Args) > 0 {
+            if key, ok := stringLiteral(call.Args[0]); ok && isTextLiteral(call.Args[0]) {
+                messages = append(messages, trMessage{inFset.Position(call.Pos()), key, false})
+            }
+            return true
+        }
+        if !isTrCall(call) {
+            return true
+        }
+        method := call.Fun.(*ast.SelectorExpr).Sel.Name
+        keyArg := 0
+        if method == "Plural" {
+            keyArg = 2
+        }
+        if keyArg < len(call.Args) {
+            if key, ok := stringLiteral(call.Args[keyArg]); ok {
+                messages = append(messages, trMessage{inFset.Position(call.Pos()), key, method == "Plural"})
+            }
+        }
+        return true
+    })
+
+    return messages
+}
+
+// isTrCall is tr().Text, tr().Sprintf or tr().Plural
+func isTrCall(inCall *ast.CallExpr) bool {
+    selector, ok := inCall.Fun.(*ast.SelectorExpr)
+    if !ok {
+        return false
+    }
+    receiver, ok := selector.X.(*ast.CallExpr)
+    if !ok {
+        return false
+    }
+    ident, ok := receiver.Fun.(*ast.Ident)
+    return ok && ident.Name == "tr" && (selector.Sel.Name == "Text" || selector.Sel.Name == "Sprintf" || selector.Sel.Name == "Plural")
+}
+
+// isUICall is a call whose string arguments end up on the screen: Gio's and the app's elements and window options
+func isUICall(inCall *ast.CallExpr) bool {
+    switch fun := inCall.Fun.(type) {
+    case *ast.SelectorExpr:
+        if pkg, ok := fun.X.(*ast.Ident); ok {
+            return pkg.Name == "material" || pkg.Name == "widgets" || (pkg.Name == "app" && fun.Sel.Name == "Title")
+        }
+    case *ast.Ident:
+        return strings.HasSuffix(fun.Name, "Element")
+    }
+    return false
+}
+
+// isTextLiteral is a string literal with a letter in it, on its own, joined with +, in a slice or as a fmt.Sprintf format
+func isTextLiteral(inExpr ast.Expr) bool {
+    switch expr := inExpr.(type) {
+    case *ast.BasicLit:
+        text, ok := stringLiteral(expr)
+        return ok && strings.IndexFunc(formatVerb.ReplaceAllString(text, ""), unicode.IsLetter) >= 0
+    case *ast.BinaryExpr:
+        return expr.Op == token.ADD && (isTextLiteral(expr.X) || isTextLiteral(expr.Y))
+    case *ast.ParenExpr:
+        return isTextLiteral(expr.X)
+    case *ast.CompositeLit:
+        for _, elt := range expr.Elts {
+            if isTextLiteral(elt) {
+                return true
+            }
+        }
+    case *ast.CallExpr:
+        if fun, ok := expr.Fun.(*ast.SelectorExpr); ok && exprName(fun.X) == "fmt" && fun.Sel.Name == "Sprintf" && len(expr.Args) > 0 {
+            return isTextLiteral(expr.Args[0])
+        }
+    }
+    return false
+}
+
+func stringLiteral(inExpr ast.Expr) (string, bool) {
+    literal, ok := inExpr.(*ast.BasicLit)
+    if !ok || literal.Kind != token.STRING {
+        return "", false
+    }
+    text, err := strconv.Unquote(literal.Value)
+    return text, err == nil
+}
+
+// exprName is the name of a variable or a field, "" for anything else
+func exprName(inExpr ast.Expr) string {
+    switch expr := inExpr.(type) {
+    case *ast.Ident:
+        return expr.Name
+    case *ast.SelectorExpr:
+        return expr.Sel.Name
+    }
+    return ""
+}
+
+// literalSource is the first literal of the expression, for the error
+func literalSource(inExpr ast.Expr) string {
+    source := ""
+    ast.Inspect(inExpr, func(node ast.Node) bool {
+        if literal, ok := node.(*ast.BasicLit); ok && source == "" && literal.Kind == token.STRING {
+            source = literal.Value
+        }
+        return source == ""
+    })
+    return source
+}
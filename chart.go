@@ -0,0 +1,165 @@
+package main
+
+import (
+    "gioui.org/f32"
+    "gioui.org/layout"
+    "gioui.org/op/clip"
+    "gioui.org/op/paint"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "image"
+    "image/color"
+    "math"
+    "showcase_desktop/widgets"
+    "time"
+)
+
+
+// Colors of the chart series, they repeat when there are more clients than colors
+var chartPalette = []color.NRGBA{
+    {R: 127, G: 0,   B: 0,   A: 255},
+    {R: 0,   G: 92,  B: 153, A: 255},
+    {R: 127, G: 152, B: 0,   A: 255},
+    {R: 230, G: 140, B: 0,   A: 255},
+    {R: 102, G: 51,  B: 153, A: 255},
+    {R: 0,   G: 140, B: 120, A: 255},
+}
+
+func chartColor(inIndex int) color.NRGBA {
+    return chartPalette[inIndex%len(chartPalette)]
+}
+
+
+// barSegment is one client's part of one week's bar, clicking it drills down to its entries
+type barSegment struct {
+    week        time.Time
+    client      string
+    btn         widget.Clickable
+}
+
+// stackedBarChartElement draws one bar per week with a segment per client, the first client at the bottom.
+// inSegments holds the clickables as [week][client], the same shape as the report's minutes.
+func stackedBarChartElement(inGTX layout.Context, inTheme *widgets.Theme, inReport Report, inSegments [][]*barSegment) layout.Dimensions {
+    maxMinutes := inReport.MaxWeekMinutes()
+    if maxMinutes == 0 {
+        return material.Body1(inTheme.Theme, tr().Text("No time logged in this period")).Layout(inGTX)
+    }
+
+    var bars []layout.FlexChild
+    for weekIdx := range inReport.Weeks {
+        bars = append(bars, layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+            return barElement(gtx, inTheme, inReport, weekIdx, maxMinutes, inSegments[weekIdx])
+        }))
+    }
+
+    return layout.Flex{Axis: layout.Horizontal}.Layout(inGTX, bars...)
+}
+
+func barElement(inGTX layout.Context, inTheme *widgets.Theme, inReport Report, inWeekIdx int, inMaxMinutes int, inSegments []*barSegment) layout.Dimensions {
+    weekTotal := 0
+    for _, minutes := range inReport.Minutes[inWeekIdx] {
+        weekTotal += minutes
+    }
+
+    return layout.Flex{Axis: layout.Vertical}.Layout(inGTX,
+        // The bar itself, growing from the bottom
+        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+            chartHeight := gtx.Constraints.Max.Y
+            barWidth    := gtx.Constraints.Max.X * 6 / 10
+
+            // Segments are laid out top down, so the last client comes first
+            var segments []layout.FlexChild
+            for clientIdx := len(inReport.Clients) - 1; clientIdx >= 0; clientIdx-- {
+                minutes := inReport.Minutes[inWeekIdx][clientIdx]
+                if minutes == 0 {
+                    continue
+                }
+                segment := inSegments[clientIdx]
+                size    := image.Pt(barWidth, max(1, minutes*chartHeight/inMaxMinutes))
+                fill    := chartColor(clientIdx)
+                segments = append(segments, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return segment.btn.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+                        paint.FillShape(gtx.Ops, fill, clip.Rect{Max: size}.Op())
+                        return layout.Dimensions{Size: size}
+                    })
+                }))
+            }
+
+            return layout.Flex{
+                Axis:      layout.Vertical,
+                Spacing:   layout.SpaceStart,
+                Alignment: layout.Middle,
+            }.Layout(gtx, segments...)
+        }),
+
+        // Week and total under the bar
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            label := material.Caption(inTheme.Theme, tr().ShortDate(inReport.Weeks[inWeekIdx])+"\n"+tr().Duration(weekTotal))
+            return layout.Center.Layout(gtx, label.Layout)
+        }),
+    )
+}
+
+// legendElement shows which color belongs to which client
+func legendElement(inGTX layout.Context, inTheme *widgets.Theme, inNames []string) layout.Dimensions {
+    var items []layout.FlexChild
+    for idx, name := range inNames {
+        items = append(items,
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                size := image.Pt(gtx.Dp(unit.Dp(12)), gtx.Dp(unit.Dp(12)))
+                paint.FillShape(gtx.Ops, chartColor(idx), clip.Rect{Max: size}.Op())
+                return layout.Dimensions{Size: size}
+            }),
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                return layout.Inset{Left: unit.Dp(4), Right: unit.Dp(12)}.Layout(gtx, material.Caption(inTheme.Theme, name).Layout)
+            }),
+        )
+    }
+
+    return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(inGTX, items...)
+}
+
+// pieChartElement draws the values as slices of a circle, starting at the top and going clockwise
+func pieChartElement(inGTX layout.Context, inValues []int, inColors []color.NRGBA) layout.Dimensions {
+    diameter := min(inGTX.Constraints.Max.X, inGTX.Constraints.Max.Y, inGTX.Dp(unit.Dp(200)))
+    size     := image.Pt(diameter, diameter)
+
+    total := 0
+    for _, value := range inValues {
+        total += value
+    }
+    if total == 0 {
+        return layout.Dimensions{Size: size}
+    }
+
+    radius := float32(diameter) / 2
+    center := f32.Pt(radius, radius)
+    angle  := -math.Pi / 2
+
+    for idx, value := range inValues {
+        if value == 0 {
+            continue
+        }
+        sweep := 2 * math.Pi * float64(value) / float64(total)
+
+        // A full circle can't be drawn as an arc from the center, so a single slice is an ellipse
+        if value == total {
+            paint.FillShape(inGTX.Ops, inColors[idx], clip.Ellipse{Max: size}.Op(inGTX.Ops))
+            break
+        }
+
+        var path clip.Path
+        path.Begin(inGTX.Ops)
+        path.MoveTo(center)
+        path.LineTo(f32.Pt(center.X+radius*float32(math.Cos(angle)), center.Y+radius*float32(math.Sin(angle))))
+        // Y grows downwards, so a positive sweep goes clockwise on screen
+        path.ArcTo(center, center, float32(sweep))
+        path.Close()
+        paint.FillShape(inGTX.Ops, inColors[idx], clip.Outline{Path: path.End()}.Op())
+
+        angle += sweep
+    }
+
+    return layout.Dimensions{Size: size}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+    "database/sql"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+
+const cliUsage = `Usage: showcase_desktop [command] [flags]
+
+Without a command the desktop app starts.
+
+Commands:
+  export    write time entries to CSV or XLSX
+  migrate   run a data migration, e.g. base-role
+  policy    export, diff or import the organization's policy as Casbin CSV, or lint it
+  help      show this help
+
+Commands that sign in take the password from SHOWCASE_PASSWORD or ask for it.
+`
+
+
+// runCommand runs a command line subcommand instead of the windows and returns the exit code
+func runCommand(inArgs []string, inS3db *sql.DB, inStdout io.Writer, inStderr io.Writer) int {
+    switch inArgs[0] {
+    case "export":
+        return runExportCommand(inArgs[1:], inS3db, inStdout, inStderr)
+    case "migrate":
+        return runMigrateCommand(inArgs[1:], inS3db, inStdout, inStderr)
+    case "policy":
+        return runPolicyCommand(inArgs[1:], inS3db, inStdout, inStderr)
+    case "help", "-h", "-help", "--help":
+        fmt.Fprint(inStdout, cliUsage)
+        return 0
+    default:
+        fmt.Fprintf(inStderr, "unknown command %q\n\n%s", inArgs[0], cliUsage)
+        return 2
+    }
+}
+
+
+// cliSignIn checks the user given on the command line, the password comes from SHOWCASE_PASSWORD or a prompt
+func cliSignIn(inUsername string, inS3db *sql.DB, inStderr io.Writer) (int, error) {
+    if inUsername == "" {
+        return 0, fmt.Errorf("please give -user")
+    }
+    password, err := cliPassword(inStderr)
+    if err != nil {
+        return 0, err
+    }
+    if password == "" {
+        return 0, fmt.Errorf("please set SHOWCASE_PASSWORD or type the password when asked")
+    }
+
+    success, userID := checkSignIn(inUsername, password, inS3db)
+    if !success {
+        return 0, fmt.Errorf("sign-in failed for %s", inUsername)
+    }
+
+    return userID, nil
+}
+
+
+// cliPassword takes the password from SHOWCASE_PASSWORD, else asks for it on stdin without echoing it
+func cliPassword(inStderr io.Writer) (string, error) {
+    if password := os.Getenv("SHOWCASE_PASSWORD"); password != "" {
+        return password, nil
+    }
+
+    fmt.Fprint(inStderr, "Password: ")
+    password, err := readHiddenLine(os.Stdin)
+    fmt.Fprintln(inStderr)
+    if err != nil && err != io.EOF {
+        return "", fmt.Errorf("could not read the password: %w", err)
+    }
+
+    return password, nil
+}
+
+
+// readPasswordLine reads up to the end of the line one byte at a time, so nothing after it is buffered away
+func readPasswordLine(inReader io.Reader) (string, error) {
+    var line []byte
+    buffer := make([]byte, 1)
+    for {
+        n, err := inReader.Read(buffer)
+        if n > 0 {
+            if buffer[0] == '\n' {
+                break
+            }
+            line = append(line, buffer[0])
+        }
+        if err != nil {
+            return strings.TrimSuffix(string(line), "\r"), err
+        }
+    }
+
+    return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+
+// cliAdminSignIn signs in and opens the organization's enforcer, the user needs "write" on inObject there
+func cliAdminSignIn(inUsername string, inOrgName string, inObject string, inS3db *sql.DB, inStderr io.Writer) (*OrgEnforcer, Organization, error) {
+    userID, err := cliSignIn(inUsername, inS3db, inStderr)
+    if err != nil {
+        return nil, Organization{}, err
+    }
+    organization, err := userOrganization(inS3db, userID, inOrgName)
+    if err != nil {
+        return nil, Organization{}, err
+    }
+
+    userEnforcer := initCasbinEnforcers(organization.OrganizationID)
+    userEnforcer.SetActor(userID)
+    if err := userEnforcer.checkActorWrite(inS3db, inObject, fmt.Errorf("you may not change the %s settings of %s", inObject, organization.OrganizationName)); err != nil {
+        return nil, Organization{}, err
+    }
+
+    return userEnforcer, organization, nil
+}
+
+
+func runExportCommand(inArgs []string, inS3db *sql.DB, inStdout io.Writer, inStderr io.Writer) int {
+    flags := flag.NewFlagSet("export", flag.ContinueOnError)
+    flags.SetOutput(inStderr)
+
+    username   := flags.String("user", "", "user signing in for the export")
+    orgName    := flags.String("org", "", "organization to export from, default the user's first one")
+    fromText   := flags.String("from", "", "first day to export, YYYY-MM-DD")
+    toText     := flags.String("to", "", "last day to export, YYYY-MM-DD")
+    ofUser     := flags.String("of-user", "", "only export entries of this user")
+    client     := flags.String("client", "", "only export entries of this client")
+    state      := flags.String("state", "", "only export entries of timesheets in this state, e.g. approved")
+    format     := flags.String("format", "csv", "csv or xlsx")
+    columnText := flags.String("columns", "", "comma separated columns, default date,user,client,hours,state")
+    localeName := flags.String("locale", "en", "number and date formats: en, en-US, de or sl")
+    outPath    := flags.String("out", "", "output file, default a new file in "+exportFolder+", - for stdout")
+
+    if err := flags.Parse(inArgs); err != nil {
+        return 2
+    }
+
+    filter, columns, locale, err := parseExportOptions(*fromText, *toText, *ofUser, *client, *state, *columnText, *localeName)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 2
+    }
+
+    userID, err := cliSignIn(*username, inS3db, inStderr)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+    organization, err := userOrganization(inS3db, userID, *orgName)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+    userEnforcer := initCasbinEnforcers(organization.OrganizationID)
+
+    // Writing to stdout skips the file, handy for piping into other tools
+    if *outPath == "-" {
+        exportRows, err := queryExportRows(inS3db, userEnforcer, userID, filter)
+        if err == nil {
+            err = writeExport(inStdout, *format, exportRows, columns, locale)
+        }
+        if err != nil {
+            fmt.Fprintln(inStderr, err)
+            return 1
+        }
+        return 0
+    }
+
+    if *outPath == "" {
+        *outPath = filepath.Join(exportFolder, exportFileName(*format, time.Now()))
+    }
+    count, err := exportTimeEntries(inS3db, userEnforcer, userID, filter, *format, columns, locale, *outPath)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+    fmt.Fprintf(inStdout, "Exported %d entries to %s\n", count, *outPath)
+
+    return 0
+}
+
+
+// runMigrateCommand runs one of the migrations, for now only base-role which factors rules shared by all roles into a base role
+func runMigrateCommand(inArgs []string, inS3db *sql.DB, inStdout io.Writer, inStderr io.Writer) int {
+    if len(inArgs) == 0 || inArgs[0] != "base-role" {
+        fmt.Fprintln(inStderr, "usage: showcase_desktop migrate base-role -user NAME -org NAME -base NAME")
+        return 2
+    }
+
+    flags := flag.NewFlagSet("migrate base-role", flag.ContinueOnError)
+    flags.SetOutput(inStderr)
+
+    username   := flags.String("user", "", "admin signing in for the migration")
+    orgName    := flags.String("org", "", "organization whose roles are factored, default the user's first one")
+    baseName   := flags.String("base", "B_base", "name of the base role, created when missing")
+
+    if err := flags.Parse(inArgs[1:]); err != nil {
+        return 2
+    }
+
+    userEnforcer, organization, err := cliAdminSignIn(*username, *orgName, roleObject, inS3db, inStderr)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+
+    moved, err := factorBaseRole(inS3db, userEnforcer, *baseName)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+    fmt.Fprintf(inStdout, "Moved %d shared rules of %s into %s\n", moved, organization.OrganizationName, *baseName)
+
+    return 0
+}
+
+
+// runPolicyCommand exports the organization's policy, or diffs or imports a CSV file against it
+func runPolicyCommand(inArgs []string, inS3db *sql.DB, inStdout io.Writer, inStderr io.Writer) int {
+    if len(inArgs) > 0 && inArgs[0] == "lint" {
+        return runPolicyLintCommand(inArgs[1:], inS3db, inStdout, inStderr)
+    }
+    if len(inArgs) == 0 || (inArgs[0] != "export" && inArgs[0] != "diff" && inArgs[0] != "import") {
+        fmt.Fprintln(inStderr, "usage: showcase_desktop policy export|diff|import -user NAME -org NAME [-in FILE] [-out FILE]")
+        fmt.Fprintln(inStderr, "       showcase_desktop policy lint [-strict]")
+        return 2
+    }
+    mode := inArgs[0]
+
+    flags := flag.NewFlagSet("policy "+mode, flag.ContinueOnError)
+    flags.SetOutput(inStderr)
+
+    username   := flags.String("user", "", "admin signing in")
+    orgName    := flags.String("org", "", "organization of the policy, default the user's first one")
+    inPath     := flags.String("in", "", "CSV file to diff or import")
+    outPath    := flags.String("out", "-", "file to export to, - for stdout")
+
+    if err := flags.Parse(inArgs[1:]); err != nil {
+        return 2
+    }
+    if mode != "export" && *inPath == "" {
+        fmt.Fprintln(inStderr, "please give the CSV file with -in")
+        return 2
+    }
+
+    userEnforcer, organization, err := cliAdminSignIn(*username, *orgName, policyObject, inS3db, inStderr)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+    names, err := subjectNames(inS3db)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+
+    if mode == "export" {
+        out := inStdout
+        if *outPath != "-" {
+            file, err := os.Create(*outPath)
+            if err != nil {
+                fmt.Fprintln(inStderr, err)
+                return 1
+            }
+            defer file.Close()
+            out = file
+        }
+        count, err := writePolicyCSV(out, userEnforcer, names)
+        if err != nil {
+            fmt.Fprintln(inStderr, err)
+            return 1
+        }
+        if *outPath != "-" {
+            fmt.Fprintf(inStdout, "Exported %d rules of %s to %s\n", count, organization.OrganizationName, *outPath)
+        }
+        return 0
+    }
+
+    file, err := os.Open(*inPath)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+    defer file.Close()
+
+    // diff only shows what import would do
+    diff, err := importPolicyCSV(file, inS3db, userEnforcer, mode == "diff")
+    for _, line := range diff.Lines(names) {
+        fmt.Fprintln(inStdout, line)
+    }
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+    verb := "Imported"
+    if mode == "diff" {
+        verb = "Import would make"
+    }
+    fmt.Fprintf(inStdout, "%s %d additions and %d removals in %s\n", verb, len(diff.Added), len(diff.Removed), organization.OrganizationName)
+
+    return 0
+}
+
+// runPolicyLintCommand checks the policy of every organization. It only reads the database the caller can
+// already open, so it needs no sign-in and can run before a release. Exits 1 on errors, with -strict on warnings too.
+func runPolicyLintCommand(inArgs []string, inS3db *sql.DB, inStdout io.Writer, inStderr io.Writer) int {
+    flags := flag.NewFlagSet("policy lint", flag.ContinueOnError)
+    flags.SetOutput(inStderr)
+
+    strict := flags.Bool("strict", false, "fail on warnings as well")
+
+    if err := flags.Parse(inArgs); err != nil {
+        return 2
+    }
+
+    findings, err := lintPolicy(inS3db)
+    if err != nil {
+        fmt.Fprintln(inStderr, err)
+        return 1
+    }
+    errorCount := writeLintFindings(inStdout, findings)
+    if errorCount > 0 || (*strict && len(findings) > 0) {
+        return 1
+    }
+
+    return 0
+}
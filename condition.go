@@ -0,0 +1,99 @@
+package main
+
+import (
+    "fmt"
+    "github.com/casbin/casbin/v2"
+    "strings"
+    "time"
+)
+
+
+// Casbin object and action of the admin's policy editor
+const policyObject = "policy"
+
+// RequestAttributes is the r.env part of a Casbin request, conditions of rules read it as e.g. r.env.Owner.
+// Checks that are not about one record leave it empty.
+type RequestAttributes struct {
+    Owner       string      // subject of the record's owner, e.g. "u3"
+    EntryDate   string      // YYYY-MM-DD
+    Client      string
+    Today       string      // YYYY-MM-DD, passed in so conditions don't depend on the clock
+}
+
+// entryAttributes describes a time entry to the conditions
+func entryAttributes(inEntry TimeEntry, inNow time.Time) RequestAttributes {
+    return RequestAttributes{
+        Owner:     fmt.Sprintf("u%d", inEntry.UserID),
+        EntryDate: dateKey(inEntry.EntryDate),
+        Client:    inEntry.ClientName,
+        Today:     dateKey(inNow),
+    }
+}
+
+// Example conditions shown in the policy editor
+var conditionExamples = []string{
+    "r.env.Owner == r.sub",
+    "withinDays(r.env.EntryDate, r.env.Today, 7)",
+    "r.env.Client == 'ACME'",
+}
+
+
+// registerConditionFunctions adds the functions conditions may call to the enforcer
+func registerConditionFunctions(inEnforcer casbin.IEnforcer) {
+    // withinDays(date, today, days) is true when date is at most days before today, dates after today count as within
+    inEnforcer.AddFunction("withinDays", func(inArgs ...interface{}) (interface{}, error) {
+        if len(inArgs) != 3 {
+            return nil, newError("withinDays(date, today, days) expects 3 arguments, got %d", len(inArgs))
+        }
+        dateText, ok1 := inArgs[0].(string)
+        todayText, ok2 := inArgs[1].(string)
+        days, ok3     := inArgs[2].(float64)
+        if !ok1 || !ok2 || !ok3 {
+            return nil, newError("withinDays(date, today, days) expects two dates and a number")
+        }
+
+        // Requests without a record have no dates, nothing is within then
+        date, err := time.Parse("2006-01-02", dateText)
+        if err != nil {
+            return false, nil
+        }
+        today, err := time.Parse("2006-01-02", todayText)
+        if err != nil {
+            return false, nil
+        }
+
+        return !date.Before(today.AddDate(0, 0, -int(days))), nil
+    })
+}
+
+// checkCondition tells whether an admin's condition is an expression the enforcer can evaluate to true or false.
+// It runs the condition on a scratch enforcer with the app's model and sample attributes.
+func checkCondition(inCondition string) error {
+    if strings.TrimSpace(inCondition) == "" {
+        return nil
+    }
+
+    enforcer, err := casbin.NewEnforcer("data/steaby_casbin_model.conf")
+    if err != nil {
+        return err
+    }
+    registerConditionFunctions(enforcer)
+    if _, err := enforcer.AddPolicy("u1", "o1", "object", "action", "allow", inCondition); err != nil {
+        return err
+    }
+
+    sample := RequestAttributes{Owner: "u1", EntryDate: "2026-10-19", Client: "ACME", Today: "2026-10-20"}
+    if _, err := enforcer.Enforce("u1", "o1", "object", "action", sample); err != nil {
+        return fmt.Errorf("%w: %w", newError("condition %q does not work", inCondition), err)
+    }
+
+    return nil
+}
+
+// policyCondition is the condition as the model expects it, rules without one always hold
+func policyCondition(inCondition string) string {
+    if strings.TrimSpace(inCondition) == "" {
+        return "true"
+    }
+    return inCondition
+}
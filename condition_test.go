@@ -0,0 +1,95 @@
+package main
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+func Test_checkCondition(t *testing.T) {
+    tests := []struct {
+        name      string
+        condition string
+        wantErr   bool
+    }{
+        {"none",                "",                                                 false},
+        {"owner",               "r.env.Owner == r.sub",                             false},
+        {"seven days",          "withinDays(r.env.EntryDate, r.env.Today, 7)",      false},
+        {"client",              "r.env.Client == 'ACME'",                           false},
+        {"both",                "r.env.Owner == r.sub && r.env.Client != 'ACME'",   false},
+        {"half an expression",  "r.env.Owner ==",                                   true},
+        {"not true or false",   "'ACME'",                                           true},
+        {"unknown function",    "isFriday(r.env.Today)",                            true},
+        {"wrong arguments",     "withinDays(r.env.EntryDate)",                      true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if err := checkCondition(tt.condition); (err != nil) != tt.wantErr {
+                t.Errorf("checkCondition() error = %v, wantErr %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func Test_canEditTimeEntry(t *testing.T) {
+    _, dbPath := openTestDb(t)
+    enforcer  := openTestEnforcer(t, dbPath)
+    now       := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+    // Minions may change their own entries of the last 7 days, Petar none of ACME
+    tests := []struct {
+        name      string
+        actorID   int
+        entry     TimeEntry
+        want      bool
+    }{
+        {"own entry today",         2, TimeEntry{UserID: 2, ClientName: "ACME", EntryDate: now},                    true},
+        {"own entry a week ago",    2, TimeEntry{UserID: 2, ClientName: "ACME", EntryDate: now.AddDate(0, 0, -7)},  true},
+        {"own entry 8 days ago",    2, TimeEntry{UserID: 2, ClientName: "ACME", EntryDate: now.AddDate(0, 0, -8)},  false},
+        {"somebody else's",         2, TimeEntry{UserID: 3, ClientName: "Internal", EntryDate: now},                false},
+        {"Petar on ACME",           3, TimeEntry{UserID: 3, ClientName: "ACME", EntryDate: now},                    false},
+        {"Petar on other clients",  3, TimeEntry{UserID: 3, ClientName: "Internal", EntryDate: now},                true},
+        {"admins have no rule",     1, TimeEntry{UserID: 1, ClientName: "ACME", EntryDate: now},                    false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := canEditTimeEntry(enforcer, tt.actorID, tt.entry, now)
+            if err != nil || got != tt.want {
+                t.Errorf("canEditTimeEntry() = %v, %v, want %v", got, err, tt.want)
+            }
+        })
+    }
+
+    // Checks without a record see empty attributes, so the conditional allow doesn't hold
+    if allowed, err := enforcer.Enforce("u2", timeEntryObject, timeEntryActEdit); err != nil || allowed {
+        t.Errorf("Enforce() without attributes = %v, %v, want false", allowed, err)
+    }
+}
+
+func Test_updateTimeEntry(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    day        := time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)
+
+    entry, err := addTimeEntry(db, 1, 2, "ACME", day, 60)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    updated, err := updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 90, day.AddDate(0, 0, 2))
+    if err != nil || updated.Minutes != 90 {
+        t.Errorf("updateTimeEntry() = %+v, %v", updated, err)
+    }
+    if _, err := updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 30, day.AddDate(0, 0, 10)); !errors.Is(err, errTimeEntryDenied) {
+        t.Errorf("updateTimeEntry() after the window error = %v, want %v", err, errTimeEntryDenied)
+    }
+    if err := deleteTimeEntry(db, enforcer, 3, entry.TimeEntryID, day); !errors.Is(err, errTimeEntryDenied) {
+        t.Errorf("deleteTimeEntry() by Petar error = %v, want %v", err, errTimeEntryDenied)
+    }
+    if err := deleteTimeEntry(db, enforcer, 2, entry.TimeEntryID, day); err != nil {
+        t.Errorf("deleteTimeEntry() error = %v", err)
+    }
+    if _, err := getTimeEntry(db, entry.TimeEntryID); !errors.Is(err, errTimeEntryNotFound) {
+        t.Errorf("getTimeEntry() after delete error = %v, want %v", err, errTimeEntryNotFound)
+    }
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "image/color"
+    "log"
+    "showcase_desktop/widgets"
+    "time"
+)
+
+
+// Pie colors for billable and non-billable time
+var (
+    billableColor    = color.NRGBA{R: 127, G: 152, B: 0,   A: 255}
+    nonBillableColor = color.NRGBA{R: 160, G: 160, B: 160, A: 255}
+)
+
+// runDashboard shows the reports the user may read over the entries they may read, clicking a chart lists its entries
+func runDashboard(inWindow *app.Window, inUserID int, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var fromTextbox         widget.Editor
+    var toTextbox           widget.Editor
+    var userTextbox         widget.Editor
+    var refreshBtn          widget.Clickable
+    var billableBtn         widget.Clickable
+    var nonBillableBtn      widget.Clickable
+    var entryList           widget.List
+    var report              Report
+    var segments            [][]*barSegment
+    var drillTitle          string
+    var drillRows           []ExportRow
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Reports")
+    entryList.Axis           = layout.Vertical
+
+    canReadHours, canReadBillable, err := visibleReports(inEnforcer, inUserID)
+    if err != nil {
+        return err
+    }
+
+    // The last eight weeks unless the filter says otherwise
+    fromTextbox.SetText(dateKey(weekStart(time.Now()).AddDate(0, 0, -7*7)))
+
+    refreshReport := func() {
+        filter, _, _, err := parseExportOptions(fromTextbox.Text(), toTextbox.Text(), userTextbox.Text(), "", "", "", "")
+        if err == nil {
+            report, err = buildReport(inS3db, inEnforcer, inUserID, filter)
+        }
+        if err != nil {
+            log.Print(err)
+            statusMsg = tr().Sprintf("Could not load the report: %s", errorText(err))
+            return
+        }
+
+        segments = make([][]*barSegment, len(report.Weeks))
+        for weekIdx, week := range report.Weeks {
+            for _, client := range report.Clients {
+                segments[weekIdx] = append(segments[weekIdx], &barSegment{week: week, client: client})
+            }
+        }
+        drillTitle, drillRows = "", nil
+        statusMsg             = tr().Plural(len(report.Rows), "%d entry, %s in total", "%d entries, %s in total", len(report.Rows), tr().Duration(report.BillableMinutes+report.NonBillableMinutes))
+    }
+    refreshReport()
+
+    inWindow.Option(app.Title(tr().Text("Reports")), app.Size(unit.Dp(1050), unit.Dp(800)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            if refreshBtn.Clicked(gtx) {
+                refreshReport()
+            }
+
+            // Drill down into a bar segment or a pie slice
+            for _, week := range segments {
+                for _, segment := range week {
+                    if segment.btn.Clicked(gtx) {
+                        drillTitle = tr().Sprintf("%s in the week of %s", segment.client, tr().Date(segment.week))
+                        drillRows  = report.EntriesFor(segment.week, segment.client)
+                    }
+                }
+            }
+            if billableBtn.Clicked(gtx) {
+                drillTitle, drillRows = tr().Text("Billable time"), report.EntriesByBillable(true)
+            }
+            if nonBillableBtn.Clicked(gtx) {
+                drillTitle, drillRows = tr().Text("Non-billable time"), report.EntriesByBillable(false)
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                // Totals or the last error
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                // Filters
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &fromTextbox, tr().Text("From YYYY-MM-DD")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &toTextbox, tr().Text("To YYYY-MM-DD")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &userTextbox, tr().Text("Only this user")).Layout(gtx) },
+                        material.Button(theme.Theme, &refreshBtn, tr().Text("Refresh")).Layout,
+                    )
+                }),
+
+                // Nothing to show without any of the report permissions
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    if canReadHours || canReadBillable {
+                        return layout.Dimensions{}
+                    }
+                    return widgets.ErrorBox(theme, tr().Text("You may not read any of the reports")).Layout(gtx)
+                }),
+
+                // Charts side by side
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+                        // Hours per client per week
+                        layout.Flexed(2, func(gtx layout.Context) layout.Dimensions {
+                            if !canReadHours {
+                                return layout.Dimensions{}
+                            }
+                            return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+                                return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+                                    layout.Rigid(material.H6(theme.Theme, tr().Text("Hours per client per week")).Layout),
+                                    layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                                        return legendElement(gtx, theme, report.Clients)
+                                    }),
+                                    layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                                        return stackedBarChartElement(gtx, theme, report, segments)
+                                    }),
+                                )
+                            })
+                        }),
+
+                        // Billable vs non-billable
+                        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                            if !canReadBillable {
+                                return layout.Dimensions{}
+                            }
+                            return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+                                return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+                                    layout.Rigid(material.H6(theme.Theme, tr().Text("Billable time")).Layout),
+                                    layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                                        return pieChartElement(gtx, []int{report.BillableMinutes, report.NonBillableMinutes}, []color.NRGBA{billableColor, nonBillableColor})
+                                    }),
+                                    layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+                                    layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                                        btn := material.Button(theme.Theme, &billableBtn, tr().Sprintf("Billable %s", tr().Duration(report.BillableMinutes)))
+                                        btn.Background = billableColor
+                                        return btn.Layout(gtx)
+                                    }),
+                                    layout.Rigid(layout.Spacer{Height: unit.Dp(5)}.Layout),
+                                    layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                                        btn := material.Button(theme.Theme, &nonBillableBtn, tr().Sprintf("Non-billable %s", tr().Duration(report.NonBillableMinutes)))
+                                        btn.Background = nonBillableColor
+                                        return btn.Layout(gtx)
+                                    }),
+                                )
+                            })
+                        }),
+                    )
+                }),
+
+                // Drill-down
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    if drillTitle == "" {
+                        return layout.Dimensions{}
+                    }
+                    return material.H6(theme.Theme, drillTitle).Layout(gtx)
+                }),
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &entryList).Layout(gtx, len(drillRows), func(gtx layout.Context, index int) layout.Dimensions {
+                        row     := drillRows[index]
+                        rowText := fmt.Sprintf("%s  %s  %s  %s  %s", tr().Date(row.EntryDate), row.Username, row.ClientName, tr().Duration(row.Minutes), timesheetStateText(row.State))
+                        return layout.UniformInset(unit.Dp(3)).Layout(gtx, material.Body1(theme.Theme, rowText).Layout)
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
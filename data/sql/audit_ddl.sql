@@ -0,0 +1,29 @@
+-- Append-only record of sign-ins, denials, policy changes and time entry changes.
+-- Every row holds the hash of the row before it, so editing or deleting a row breaks the chain from there on.
+-- Actor and organization are 0 when there is none, e.g. a failed sign-in.
+CREATE TABLE IF NOT EXISTS audit_event (
+      audit_event_id        INTEGER         PRIMARY KEY
+    , created_at            VARCHAR(40)     NOT NULL
+    , actor_id              INTEGER         NOT NULL DEFAULT 0
+    , actor_name            VARCHAR(64)     NOT NULL DEFAULT ''
+    , organization_id       INTEGER         NOT NULL DEFAULT 0
+    , event_type            VARCHAR(32)     NOT NULL
+    , object                VARCHAR(64)     NOT NULL DEFAULT ''
+    , before_value          TEXT            NOT NULL DEFAULT ''
+    , after_value           TEXT            NOT NULL DEFAULT ''
+    , prev_hash             CHAR(64)        NOT NULL UNIQUE
+    , hash                  CHAR(64)        NOT NULL UNIQUE
+)
+;
+
+CREATE INDEX IF NOT EXISTS audit_event_organization_idx ON audit_event (organization_id, created_at);
+
+-- Rows are never changed once written
+CREATE TRIGGER IF NOT EXISTS audit_event_no_update BEFORE UPDATE ON audit_event
+BEGIN
+    SELECT RAISE(ABORT, 'audit_event is append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_event_no_delete BEFORE DELETE ON audit_event
+BEGIN
+    SELECT RAISE(ABORT, 'audit_event is append-only');
+END;
@@ -0,0 +1,81 @@
+CREATE TABLE IF NOT EXISTS client_dim (
+      client_id             INTEGER         PRIMARY KEY
+    , client_name           VARCHAR(64)     UNIQUE NOT NULL COLLATE NOCASE
+    , currency              VARCHAR(3)      NOT NULL DEFAULT 'EUR'
+)
+;
+
+-- Empty client, role or user means the rate applies to all of them. The most specific matching rate wins.
+CREATE TABLE IF NOT EXISTS rate_card (
+      rate_card_id          INTEGER         PRIMARY KEY
+    , client_id             INTEGER
+    , role_id               INTEGER
+    , user_id               INTEGER
+    , hourly_rate_cents     INTEGER         NOT NULL
+    , currency              VARCHAR(3)      NOT NULL DEFAULT 'EUR'
+    , valid_from            DATE            NOT NULL
+    , valid_to              DATE
+)
+;
+
+-- Invoices and credit notes share one sequence of numbers
+CREATE TABLE IF NOT EXISTS invoice (
+      invoice_id            INTEGER         PRIMARY KEY
+    , invoice_number        INTEGER         UNIQUE NOT NULL
+    , kind                  VARCHAR(16)     NOT NULL DEFAULT 'invoice'
+    , client_id             INTEGER         NOT NULL
+    , currency              VARCHAR(3)      NOT NULL
+    , issue_date            DATE            NOT NULL
+    , period_from           DATE            NOT NULL
+    , period_to             DATE            NOT NULL
+    , credited_invoice_id   INTEGER         UNIQUE
+    , note                  VARCHAR(256)    NOT NULL DEFAULT ''
+    , total_cents           INTEGER         NOT NULL
+)
+;
+
+CREATE TABLE IF NOT EXISTS invoice_line (
+      invoice_line_id       INTEGER         PRIMARY KEY
+    , invoice_id            INTEGER         NOT NULL
+    , line_no               INTEGER         NOT NULL
+    , user_id               INTEGER         NOT NULL
+    , description           VARCHAR(256)    NOT NULL
+    , minutes_spent         INTEGER         NOT NULL
+    , hourly_rate_cents     INTEGER         NOT NULL
+    , amount_cents          INTEGER         NOT NULL
+)
+;
+
+-- Which time entries were billed on which line, so nothing gets billed twice
+CREATE TABLE IF NOT EXISTS invoice_line_entry (
+      invoice_line_id       INTEGER         NOT NULL
+    , time_entry_id         INTEGER         NOT NULL
+    , PRIMARY KEY (invoice_line_id, time_entry_id)
+)
+;
+
+-- Issued documents never change, corrections go through credit notes
+CREATE TRIGGER IF NOT EXISTS invoice_immutable_update BEFORE UPDATE ON invoice
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable, issue a credit note instead');
+END;
+
+CREATE TRIGGER IF NOT EXISTS invoice_immutable_delete BEFORE DELETE ON invoice
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable, issue a credit note instead');
+END;
+
+CREATE TRIGGER IF NOT EXISTS invoice_line_immutable_update BEFORE UPDATE ON invoice_line
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable, issue a credit note instead');
+END;
+
+CREATE TRIGGER IF NOT EXISTS invoice_line_immutable_delete BEFORE DELETE ON invoice_line
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable, issue a credit note instead');
+END;
+
+CREATE TRIGGER IF NOT EXISTS invoice_line_entry_immutable_delete BEFORE DELETE ON invoice_line_entry
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable, issue a credit note instead');
+END;
@@ -0,0 +1,13 @@
+-- Run once on databases from before budget tracking
+ALTER TABLE project ADD COLUMN block_over_budget BOOLEAN NOT NULL DEFAULT 0;
+
+-- A row per project and crossed threshold (75, 90 or 100 percent), so every alert is raised only once
+CREATE TABLE IF NOT EXISTS budget_alert (
+      budget_alert_id       INTEGER         PRIMARY KEY
+    , project_id            INTEGER         NOT NULL
+    , threshold             INTEGER         NOT NULL
+    , used_percent          INTEGER         NOT NULL
+    , raised_at             DATETIME        NOT NULL
+    , UNIQUE (project_id, threshold)
+)
+;
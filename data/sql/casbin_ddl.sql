@@ -29,31 +29,72 @@
 -- )
 -- ;
 
-CREATE VIEW casbin_rules AS
+-- ptype tells the adapter which kind of Casbin rule a row is:
+-- p for allow/deny rules, g for user to role mappings and role inheritance, g2 for project to project group mappings.
+-- p and g rules hold in one organization, the domain "o<organization_id>", g2 rules hold everywhere.
+-- Only p rules have a condition, empty when the rule always holds.
+DROP VIEW IF EXISTS casbin_rule;
+
+CREATE VIEW casbin_rule AS
     SELECT
-          aup.user_policy_id            AS policy_id
+          'p'                           AS ptype
+        , aup.user_policy_id            AS policy_id
         , 'u' || aup.subject            AS subject
+        , 'o' || aup.organization_id    AS domain
         , aup.object                    AS object
         , aup.action                    AS action
         , aup.effect                    AS effect
+        , aup.condition                 AS condition
     FROM
         auth_user_policy    AS aup
     UNION
     SELECT
-          arp.role_policy_id            AS policy_id
+          'p'                           AS ptype
+        , arp.role_policy_id            AS policy_id
         , 'r' || arp.subject            AS subject
+        , 'o' || arp.organization_id    AS domain
         , arp.object                    AS object
         , arp.action                    AS action
         , arp.effect                    AS effect
+        , arp.condition                 AS condition
     FROM
         auth_role_policy    AS arp
     UNION
     SELECT
-          aurmp.map_policy_id           AS policy_id
+          'g'                           AS ptype
+        , aurmp.map_policy_id           AS policy_id
         , 'u' || aurmp.subject          AS subject
+        , 'o' || aurmp.organization_id  AS domain
         , 'r' || aurmp.object           AS object
         , NULL                          AS action
         , NULL                          AS effect
+        , NULL                          AS condition
     FROM
         auth_user_role_map_policy   AS aurmp
+    UNION
+    SELECT
+          'g'                           AS ptype
+        , ari.role_inheritance_id       AS policy_id
+        , 'r' || ari.role_id            AS subject
+        , 'o' || ari.organization_id    AS domain
+        , 'r' || ari.parent_role_id     AS object
+        , NULL                          AS action
+        , NULL                          AS effect
+        , NULL                          AS condition
+    FROM
+        auth_role_inheritance       AS ari
+    UNION
+    SELECT
+          'g2'                          AS ptype
+        , pr.project_id                 AS policy_id
+        , 'project_' || pr.project_id   AS subject
+        , NULL                          AS domain
+        , pg.group_name                 AS object
+        , NULL                          AS action
+        , NULL                          AS effect
+        , NULL                          AS condition
+    FROM
+        project             AS pr
+        JOIN project_group  AS pg
+            ON pg.project_group_id = pr.project_group_id
 ;
@@ -0,0 +1,5 @@
+-- Conditions on policy rules, a Casbin expression over the request such as
+--     r.env.Owner == r.sub && withinDays(r.env.EntryDate, r.env.Today, 7)
+-- An empty condition always holds. Recreate the casbin_rule view with casbin_ddl.sql afterwards.
+ALTER TABLE auth_user_policy    ADD COLUMN condition VARCHAR(512) NOT NULL DEFAULT '';
+ALTER TABLE auth_role_policy    ADD COLUMN condition VARCHAR(512) NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+-- One row per imported file, the entries it created are listed in import_batch_entry so the import can be undone
+CREATE TABLE IF NOT EXISTS import_batch (
+      import_batch_id       INTEGER         PRIMARY KEY
+    , user_id               INTEGER         NOT NULL
+    , source                VARCHAR(16)     NOT NULL
+    , file_name             VARCHAR(256)    NOT NULL DEFAULT ''
+    , imported_at           DATETIME        NOT NULL
+    , undone                BOOLEAN         NOT NULL DEFAULT 0
+)
+;
+
+CREATE TABLE IF NOT EXISTS import_batch_entry (
+      import_batch_id       INTEGER         NOT NULL
+    , time_entry_id         INTEGER         NOT NULL
+    , PRIMARY KEY (import_batch_id, time_entry_id)
+)
+;
@@ -0,0 +1,47 @@
+-- Business units sharing the database, Casbin sees each one as a domain "o<organization_id>"
+CREATE TABLE IF NOT EXISTS organization (
+      organization_id       INTEGER         PRIMARY KEY
+    , organization_name     VARCHAR(64)     UNIQUE NOT NULL COLLATE NOCASE
+)
+;
+
+-- Run once on databases from before organizations, everything there belongs to the first one
+INSERT OR IGNORE INTO organization (organization_id, organization_name) VALUES (1, 'Steaby');
+
+ALTER TABLE auth_user_policy            ADD COLUMN organization_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE auth_role_policy            ADD COLUMN organization_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE auth_user_role_map_policy   ADD COLUMN organization_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE client_dim                  ADD COLUMN organization_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE rate_card                   ADD COLUMN organization_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE import_batch                ADD COLUMN organization_id INTEGER NOT NULL DEFAULT 1;
+
+-- Client names and weeks are unique within an organization. SQLite can't change the keys of a table, so both are
+-- copied into tables keyed by the organization.
+CREATE TABLE client_dim_by_organization (
+      client_id             INTEGER         PRIMARY KEY
+    , client_name           VARCHAR(64)     NOT NULL COLLATE NOCASE
+    , currency              VARCHAR(3)      NOT NULL DEFAULT 'EUR'
+    , organization_id       INTEGER         NOT NULL DEFAULT 1
+    , UNIQUE (organization_id, client_name)
+)
+;
+INSERT INTO client_dim_by_organization (client_id, client_name, currency, organization_id)
+    SELECT client_id, client_name, currency, organization_id FROM client_dim;
+DROP TABLE client_dim;
+ALTER TABLE client_dim_by_organization RENAME TO client_dim;
+
+CREATE TABLE timesheet_by_organization (
+      timesheet_id          INTEGER         PRIMARY KEY
+    , organization_id       INTEGER         NOT NULL DEFAULT 1
+    , user_id               INTEGER         NOT NULL
+    , week_start            DATE            NOT NULL
+    , state                 VARCHAR(16)     NOT NULL DEFAULT 'draft'
+    , reviewer_id           INTEGER
+    , review_note           VARCHAR(256)    NOT NULL DEFAULT ''
+    , UNIQUE (organization_id, user_id, week_start)
+)
+;
+INSERT INTO timesheet_by_organization (timesheet_id, user_id, week_start, state, reviewer_id, review_note)
+    SELECT timesheet_id, user_id, week_start, state, reviewer_id, review_note FROM timesheet;
+DROP TABLE timesheet;
+ALTER TABLE timesheet_by_organization RENAME TO timesheet;
@@ -0,0 +1,80 @@
+-- Single row counter bumped by every change to the tables behind the casbin_rule view.
+-- Running apps poll it and reload their enforcer when it moves.
+CREATE TABLE IF NOT EXISTS policy_version (
+      policy_version_id     INTEGER         PRIMARY KEY CHECK (policy_version_id = 1)
+    , version               INTEGER         NOT NULL DEFAULT 0
+)
+;
+
+INSERT OR IGNORE INTO policy_version (policy_version_id, version) VALUES (1, 0);
+
+
+-- auth_user_policy
+CREATE TRIGGER IF NOT EXISTS auth_user_policy_insert_policy_version AFTER INSERT ON auth_user_policy
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS auth_user_policy_update_policy_version AFTER UPDATE ON auth_user_policy
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS auth_user_policy_delete_policy_version AFTER DELETE ON auth_user_policy
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+
+-- auth_role_policy
+CREATE TRIGGER IF NOT EXISTS auth_role_policy_insert_policy_version AFTER INSERT ON auth_role_policy
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS auth_role_policy_update_policy_version AFTER UPDATE ON auth_role_policy
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS auth_role_policy_delete_policy_version AFTER DELETE ON auth_role_policy
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+
+-- auth_user_role_map_policy
+CREATE TRIGGER IF NOT EXISTS auth_user_role_map_policy_insert_policy_version AFTER INSERT ON auth_user_role_map_policy
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS auth_user_role_map_policy_update_policy_version AFTER UPDATE ON auth_user_role_map_policy
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS auth_user_role_map_policy_delete_policy_version AFTER DELETE ON auth_user_role_map_policy
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+
+-- project
+CREATE TRIGGER IF NOT EXISTS project_insert_policy_version AFTER INSERT ON project
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS project_update_policy_version AFTER UPDATE ON project
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS project_delete_policy_version AFTER DELETE ON project
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+
+-- project_group
+CREATE TRIGGER IF NOT EXISTS project_group_insert_policy_version AFTER INSERT ON project_group
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS project_group_update_policy_version AFTER UPDATE ON project_group
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS project_group_delete_policy_version AFTER DELETE ON project_group
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
@@ -0,0 +1,36 @@
+-- Projects group the work for a client, Casbin sees each project as the object 'project_<id>'.
+-- project_group puts projects into object groups, loaded as g2 rules, so policies can be written per group.
+CREATE TABLE IF NOT EXISTS project_group (
+      project_group_id      INTEGER         PRIMARY KEY
+    , group_name            VARCHAR(64)     UNIQUE NOT NULL
+)
+;
+
+-- Budget is in minutes for an 'hours' budget and in cents for a 'money' budget, no budget_kind means no budget
+CREATE TABLE IF NOT EXISTS project (
+      project_id            INTEGER         PRIMARY KEY
+    , client_id             INTEGER         NOT NULL
+    , project_name          VARCHAR(64)     NOT NULL COLLATE NOCASE
+    , project_group_id      INTEGER
+    , budget_kind           VARCHAR(8)
+    , budget_amount         INTEGER         NOT NULL DEFAULT 0
+    , start_date            DATE            NOT NULL
+    , end_date              DATE
+    , billable              BOOLEAN         NOT NULL DEFAULT 1
+    , UNIQUE (client_id, project_name)
+)
+;
+
+-- A task is billable only if its project is billable too
+CREATE TABLE IF NOT EXISTS task (
+      task_id               INTEGER         PRIMARY KEY
+    , project_id            INTEGER         NOT NULL
+    , task_name             VARCHAR(64)     NOT NULL COLLATE NOCASE
+    , billable              BOOLEAN         NOT NULL DEFAULT 1
+    , UNIQUE (project_id, task_name)
+)
+;
+
+-- Run once on databases from before projects, entries without a task keep logging against the client only
+ALTER TABLE time_entry ADD COLUMN project_id INTEGER;
+ALTER TABLE time_entry ADD COLUMN task_id INTEGER;
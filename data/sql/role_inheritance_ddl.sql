@@ -0,0 +1,28 @@
+-- Role to role inheritance, a role gets every rule of its parent roles within the organization.
+-- Casbin sees a row as "g, r<role_id>, r<parent_role_id>, o<organization_id>", cycles are refused by the app.
+CREATE TABLE IF NOT EXISTS auth_role_inheritance (
+      role_inheritance_id   INTEGER         PRIMARY KEY
+    , role_id               INTEGER         NOT NULL REFERENCES auth_role_dim (role_dim_id)
+    , parent_role_id        INTEGER         NOT NULL REFERENCES auth_role_dim (role_dim_id)
+    , organization_id       INTEGER         NOT NULL DEFAULT 1 REFERENCES organization (organization_id)
+    , UNIQUE (role_id, parent_role_id, organization_id)
+    , CHECK (role_id <> parent_role_id)
+)
+;
+
+-- Running apps reload their enforcer when the hierarchy changes
+CREATE TRIGGER IF NOT EXISTS auth_role_inheritance_insert_policy_version AFTER INSERT ON auth_role_inheritance
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS auth_role_inheritance_update_policy_version AFTER UPDATE ON auth_role_inheritance
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+CREATE TRIGGER IF NOT EXISTS auth_role_inheritance_delete_policy_version AFTER DELETE ON auth_role_inheritance
+BEGIN
+    UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1;
+END;
+
+-- The rules every role had copied can then be moved into a base role with
+--     showcase_desktop migrate base-role -org <organization> -base <role name>
@@ -11,7 +11,8 @@
 INSERT INTO auth_role_dim (role_dim_id, role_name)
 VALUES
     (1, 'B_admin'),
-    (2, 'B_minion')
+    (2, 'B_minion'),
+    (3, 'B_base')
 ;
 
 INSERT INTO auth_role_policy (role_policy_id, subject, object, action, effect)
@@ -24,25 +25,70 @@ VALUES
         -- we could potentially also transform "allow" = 1 and "deny" = 0 ?
 
     -- B_admin
-    (100, 1, 'report_text',                 'read',     'allow'),
-    (101, 1, 'inputbox_client_name',        'read',     'allow'),
     (102, 1, 'inputbox_client_name',        'write',    'deny'),
-    (103, 1, 'inputbox_time_spent',         'read',     'allow'),
     (104, 1, 'inputbox_time_spent',         'write',    'deny'),
     (105, 1, 'admin_text',                  'read',     'allow'),
+    (106, 1, 'timesheet',                   'submit',   'deny'),
+    (107, 1, 'timesheet',                   'approve',  'allow'),
+    (108, 1, 'timesheet',                   'reject',   'allow'),
+    (109, 1, 'invoice',                     'write',    'allow'),
+    (111, 1, 'team_time_entry',             'read',     'allow'),
+    (112, 1, 'time_entry',                  'write',    'deny'),
+    (114, 1, 'report_billable',             'read',     'allow'),
+    (117, 1, 'budget_override',             'write',    'allow'),
+    (118, 1, 'check_access',                'read',     'allow'),
+    (119, 1, 'role',                        'write',    'allow'),
+    (120, 1, 'policy',                      'write',    'allow'),
+    (121, 1, 'audit_log',                   'read',     'allow'),
     -- B_minion
-    (200, 2, 'report_text',                 'read',     'allow'),
-    (201, 2, 'inputbox_client_name',        'read',     'allow'),
     (202, 2, 'inputbox_client_name',        'write',    'allow'),
-    (203, 2, 'inputbox_time_spent',         'read',     'allow'),
     (204, 2, 'inputbox_time_spent',         'write',    'allow'),
-    (205, 2, 'admin_text',                  'read',     'deny')
+    (205, 2, 'admin_text',                  'read',     'deny'),
+    (206, 2, 'timesheet',                   'submit',   'allow'),
+    (207, 2, 'timesheet',                   'approve',  'deny'),
+    (208, 2, 'timesheet',                   'reject',   'deny'),
+    (209, 2, 'invoice',                     'write',    'deny'),
+    (211, 2, 'team_time_entry',             'read',     'deny'),
+    (212, 2, 'time_entry',                  'write',    'allow'),
+    (214, 2, 'report_billable',             'read',     'deny'),
+    (216, 2, 'client_work',                 'write',    'allow'),
+    (218, 2, 'internal_work',               'write',    'allow'),
+    -- B_base, what every role may do
+    (400, 3, 'client_work',                 'read',     'allow'),
+    (401, 3, 'inputbox_client_name',        'read',     'allow'),
+    (402, 3, 'inputbox_time_spent',         'read',     'allow'),
+    (403, 3, 'internal_work',               'read',     'allow'),
+    (404, 3, 'report_hours_by_client',      'read',     'allow'),
+    (405, 3, 'report_text',                 'read',     'allow'),
+    (406, 3, 'time_entry',                  'read',     'allow')
+;
+
+-- Both roles inherit the rules of B_base
+INSERT INTO auth_role_inheritance (role_inheritance_id, role_id, parent_role_id, organization_id)
+VALUES
+    (1, 1, 3, 1),
+    (2, 2, 3, 1)
 ;
 
 INSERT INTO auth_user_policy (user_policy_id, subject, object, action, effect)
 VALUES
     -- deny report text from Petar
-    (1, 3, 'report_text',                   'read',     'deny')
+    (1, 3, 'report_text',                   'read',     'deny'),
+    -- Petar can see ACME support, but may not log time on it
+    (2, 3, 'project_2',                     'write',    'deny')
+;
+
+-- Rules with conditions on the entry in the request
+INSERT INTO auth_role_policy (role_policy_id, subject, object, action, effect, condition)
+VALUES
+    -- minions change their own entries for a week
+    (219, 2, 'time_entry',                  'edit',     'allow',    'r.env.Owner == r.sub && withinDays(r.env.EntryDate, r.env.Today, 7)')
+;
+
+INSERT INTO auth_user_policy (user_policy_id, subject, object, action, effect, condition)
+VALUES
+    -- ACME signs off Petar's hours as they come in, so they stay as logged
+    (10, 3, 'time_entry',                   'edit',     'deny',     'r.env.Client == ''ACME''')
 ;
 
 INSERT INTO auth_user_role_map_policy (map_policy_id, subject, object)
@@ -53,4 +99,97 @@ VALUES
     (3, 3, 2)
 ;
 
+-- Steaby (1) comes with organization_ddl.sql, the labs are a second business unit with their own policies
+INSERT INTO organization (organization_id, organization_name)
+VALUES
+    (2, 'Steaby Labs')
+;
+
+-- In the labs Tadej runs things and Ray only logs time
+INSERT INTO auth_role_policy (role_policy_id, subject, object, action, effect, organization_id)
+VALUES
+    -- B_admin
+    (300, 1, 'admin_text',                  'read',     'allow',    2),
+    (304, 1, 'timesheet',                   'approve',  'allow',    2),
+    (305, 1, 'timesheet',                   'reject',   'allow',    2),
+    (306, 1, 'team_time_entry',             'read',     'allow',    2),
+    (307, 1, 'check_access',                'read',     'allow',    2),
+    (308, 1, 'role',                        'write',    'allow',    2),
+    (309, 1, 'policy',                      'write',    'allow',    2),
+    (310, 1, 'audit_log',                   'read',     'allow',    2),
+    -- B_minion
+    (312, 2, 'inputbox_client_name',        'write',    'allow',    2),
+    (314, 2, 'inputbox_time_spent',         'write',    'allow',    2),
+    (315, 2, 'admin_text',                  'read',     'deny',     2),
+    (316, 2, 'timesheet',                   'submit',   'allow',    2),
+    (317, 2, 'time_entry',                  'read',     'allow',    2),
+    (318, 2, 'time_entry',                  'write',    'allow',    2),
+    -- B_base
+    (410, 3, 'inputbox_client_name',        'read',     'allow',    2),
+    (411, 3, 'inputbox_time_spent',         'read',     'allow',    2),
+    (412, 3, 'report_text',                 'read',     'allow',    2)
+;
+
+INSERT INTO auth_role_policy (role_policy_id, subject, object, action, effect, organization_id, condition)
+VALUES
+    (319, 2, 'time_entry',                  'edit',     'allow',    2,  'r.env.Owner == r.sub && withinDays(r.env.EntryDate, r.env.Today, 7)')
+;
+
+INSERT INTO auth_role_inheritance (role_inheritance_id, role_id, parent_role_id, organization_id)
+VALUES
+    (3, 1, 3, 2),
+    (4, 2, 3, 2)
+;
+
+INSERT INTO auth_user_role_map_policy (map_policy_id, subject, object, organization_id)
+VALUES
+    (4, 2, 1, 2),
+    (5, 1, 2, 2)
+;
+
+-- Ray approves the timesheets of Tadej and Petar
+INSERT INTO user_manager_map (user_manager_map_id, user_id, manager_id)
+VALUES
+    (1, 2, 1),
+    (2, 3, 1)
+;
+
+INSERT INTO client_dim (client_id, client_name, currency, organization_id)
+VALUES
+    (1, 'ACME',   'EUR', 1),
+    (2, 'Globex', 'USD', 2)
+;
+
+-- Everybody bills 50/h, minions 60/h on ACME, Tadej is worth 80/h anywhere
+INSERT INTO rate_card (rate_card_id, client_id, role_id, user_id, hourly_rate_cents, currency, valid_from, valid_to)
+VALUES
+    (1, NULL, NULL, NULL,   5000, 'EUR', '2025-01-01', NULL),
+    (2, 1,    2,    NULL,   6000, 'EUR', '2025-01-01', NULL),
+    (3, NULL, NULL, 2,      8000, 'EUR', '2025-01-01', NULL)
+;
+
+-- Casbin policies are written on the groups, each project belongs to one
+INSERT INTO project_group (project_group_id, group_name)
+VALUES
+    (1, 'client_work'),
+    (2, 'internal_work')
+;
+
+-- 100h for the website and no logging past it, 5000.00 for support, pre-sales is not billed
+INSERT INTO project (project_id, client_id, project_name, project_group_id, budget_kind, budget_amount, start_date, end_date, billable, block_over_budget)
+VALUES
+    (1, 1, 'Website',   1, 'hours', 6000,   '2026-01-01', NULL,         1, 1),
+    (2, 1, 'Support',   1, 'money', 500000, '2026-01-01', NULL,         1, 0),
+    (3, 1, 'Pre-sales', 2, NULL,    0,      '2026-01-01', '2026-12-31', 0, 0)
+;
+
+INSERT INTO task (task_id, project_id, task_name, billable)
+VALUES
+    (1, 1, 'Design',         1),
+    (2, 1, 'Development',    1),
+    (3, 2, 'Tickets',        1),
+    (4, 2, 'Warranty fixes', 0),
+    (5, 3, 'Meetings',       1)
+;
+
 -- Kristine & Preston users
\ No newline at end of file
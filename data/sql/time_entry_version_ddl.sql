@@ -0,0 +1,44 @@
+-- Every change to a time entry, newest version last. A row holds the entry as it was after the change,
+-- deletes keep the entry as it was before it went away. Undone changes point to the version that undid them.
+CREATE TABLE IF NOT EXISTS time_entry_version (
+      time_entry_version_id INTEGER         PRIMARY KEY
+    , time_entry_id         INTEGER         NOT NULL
+    , version               INTEGER         NOT NULL
+    , change                VARCHAR(16)     NOT NULL CHECK (change IN ('created', 'updated', 'deleted', 'restored', 'undone'))
+    , changed_by            INTEGER         NOT NULL REFERENCES user_dim (user_id)
+    , changed_at            VARCHAR(40)     NOT NULL
+    , timesheet_id          INTEGER         NOT NULL
+    , user_id               INTEGER         NOT NULL
+    , client_name           VARCHAR(64)     NOT NULL
+    , project_id            INTEGER
+    , task_id               INTEGER
+    , entry_date            DATE            NOT NULL
+    , minutes_spent         INTEGER         NOT NULL
+    , undone_by_version_id  INTEGER         REFERENCES time_entry_version (time_entry_version_id)
+    , UNIQUE (time_entry_id, version)
+)
+;
+
+CREATE INDEX IF NOT EXISTS time_entry_version_timesheet_idx ON time_entry_version (timesheet_id);
+CREATE INDEX IF NOT EXISTS time_entry_version_changed_by_idx ON time_entry_version (changed_by, time_entry_version_id);
+
+-- Entries logged before the history existed start with a version made by their owner
+INSERT INTO time_entry_version (time_entry_id, version, change, changed_by, changed_at, timesheet_id, user_id, client_name, project_id, task_id, entry_date, minutes_spent)
+SELECT
+      te.time_entry_id
+    , 1
+    , 'created'
+    , te.user_id
+    , te.entry_date
+    , te.timesheet_id
+    , te.user_id
+    , te.client_name
+    , te.project_id
+    , te.task_id
+    , te.entry_date
+    , te.minutes_spent
+FROM
+    time_entry                  AS te
+WHERE
+    NOT EXISTS (SELECT 1 FROM time_entry_version AS tev WHERE tev.time_entry_id = te.time_entry_id)
+;
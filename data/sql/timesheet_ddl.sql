@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS user_manager_map (
+      user_manager_map_id   INTEGER         PRIMARY KEY
+    , user_id               INTEGER         UNIQUE NOT NULL
+    , manager_id            INTEGER         NOT NULL
+)
+;
+
+-- One row per user per week, state goes draft -> submitted -> approved/rejected -> locked
+CREATE TABLE IF NOT EXISTS timesheet (
+      timesheet_id          INTEGER         PRIMARY KEY
+    , user_id               INTEGER         NOT NULL
+    , week_start            DATE            NOT NULL
+    , state                 VARCHAR(16)     NOT NULL DEFAULT 'draft'
+    , reviewer_id           INTEGER
+    , review_note           VARCHAR(256)    NOT NULL DEFAULT ''
+    , UNIQUE (user_id, week_start)
+)
+;
+
+CREATE TABLE IF NOT EXISTS time_entry (
+      time_entry_id         INTEGER         PRIMARY KEY
+    , timesheet_id          INTEGER         NOT NULL
+    , user_id               INTEGER         NOT NULL
+    , client_name           VARCHAR(64)     NOT NULL
+    , entry_date            DATE            NOT NULL
+    , minutes_spent         INTEGER         NOT NULL
+)
+;
@@ -0,0 +1,8 @@
+-- Preferences a user picks for themselves, e.g. the theme. A missing row means the app's default.
+CREATE TABLE IF NOT EXISTS user_setting (
+      user_id               INTEGER         NOT NULL REFERENCES user_dim (user_id)
+    , setting_key           VARCHAR(32)     NOT NULL
+    , setting_value         VARCHAR(64)     NOT NULL
+    , PRIMARY KEY (user_id, setting_key)
+)
+;
@@ -0,0 +1,184 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+)
+
+
+// Casbin object of the admin's check access tool
+const checkAccessObject = "check_access"
+
+// AccessExplanation tells which rules made Casbin allow or deny a request
+type AccessExplanation struct {
+    Subject         string
+    Domain          string
+    Object          string
+    Action          string
+    Allowed         bool
+    DecidingRule    []string        // the rule EnforceEx reports, empty when no rule matched
+    RoleChain       [][2]string     // subject to role edges, in the order they were followed
+    ObjectGroups    []string        // groups the object is in through g2
+    MatchingRules   [][]string      // every rule that matched, with deny-override one deny beats all allows
+}
+
+// explainAccess asks Casbin for the decision with EnforceEx and collects the roles and rules behind it
+func explainAccess(inEnforcer *OrgEnforcer, inSubject string, inObject string, inAction string) (AccessExplanation, error) {
+    explanation := AccessExplanation{Subject: inSubject, Domain: inEnforcer.Domain(), Object: inObject, Action: inAction}
+
+    allowed, decidingRule, err := inEnforcer.EnforceEx(inSubject, inObject, inAction)
+    if err != nil {
+        return explanation, err
+    }
+    explanation.Allowed      = allowed
+    explanation.DecidingRule = decidingRule
+
+    // Follow the organization's g rules breadth first, the visited set keeps a cycle from looping forever
+    subjects := map[string]bool{inSubject: true}
+    queue    := []string{inSubject}
+    for len(queue) > 0 {
+        name  := queue[0]
+        queue  = queue[1:]
+        roles, err := inEnforcer.GetRolesForUser(name)
+        if err != nil {
+            return explanation, err
+        }
+        for _, role := range roles {
+            explanation.RoleChain = append(explanation.RoleChain, [2]string{name, role})
+            if !subjects[role] {
+                subjects[role] = true
+                queue          = append(queue, role)
+            }
+        }
+    }
+
+    groups, err := inEnforcer.GetNamedImplicitRolesForUser("g2", inObject)
+    if err != nil {
+        return explanation, err
+    }
+    explanation.ObjectGroups = groups
+    objects := map[string]bool{inObject: true}
+    for _, group := range groups {
+        objects[group] = true
+    }
+
+    policy, err := inEnforcer.GetPolicy()
+    if err != nil {
+        return explanation, err
+    }
+    for _, rule := range policy {
+        if len(rule) >= 4 && subjects[rule[0]] && objects[rule[2]] && rule[3] == inAction {
+            explanation.MatchingRules = append(explanation.MatchingRules, rule)
+        }
+    }
+
+    return explanation, nil
+}
+
+// Lines is the explanation as shown to users, subjects are named through inNames where known
+func (a AccessExplanation) Lines(inNames map[string]string) []string {
+    name := func(inSubject string) string {
+        if named, found := inNames[inSubject]; found {
+            return fmt.Sprintf("%s (%s)", named, inSubject)
+        }
+        return inSubject
+    }
+    // Rules come as sub, dom, obj, act, eft, cond
+    rule := func(inRule []string) string {
+        effect := effectText("allow")
+        if len(inRule) > 4 {
+            effect = effectText(inRule[4])
+        }
+        text := tr().Sprintf("%s %s on %s for %s", effect, inRule[3], inRule[2], name(inRule[0]))
+        if len(inRule) > 5 && inRule[5] != policyCondition("") {
+            text = tr().Sprintf("%s when %s", text, inRule[5])
+        }
+        return text
+    }
+
+    verdict := tr().Sprintf("Denied: %s %s %s in %s", name(a.Subject), a.Action, a.Object, name(a.Domain))
+    if a.Allowed {
+        verdict = tr().Sprintf("Allowed: %s %s %s in %s", name(a.Subject), a.Action, a.Object, name(a.Domain))
+    }
+    lines := []string{verdict}
+
+    if len(a.DecidingRule) > 0 {
+        lines = append(lines, tr().Sprintf("Decided by: %s", rule(a.DecidingRule)))
+    } else {
+        lines = append(lines, tr().Text("Decided by: no rule matches, so it is denied by default"))
+    }
+    for _, edge := range a.RoleChain {
+        lines = append(lines, tr().Sprintf("Role: %s is in %s", name(edge[0]), name(edge[1])))
+    }
+    if len(a.ObjectGroups) > 0 {
+        lines = append(lines, tr().Sprintf("Object groups: %s is in %s", a.Object, strings.Join(a.ObjectGroups, ", ")))
+    }
+    for _, matching := range a.MatchingRules {
+        lines = append(lines, tr().Sprintf("Matching rule: %s", rule(matching)))
+    }
+
+    return lines
+}
+
+
+// subjectNames maps Casbin subjects and domains to names, e.g. "u3" to "Petar", "r2" to "B_minion" and "o1" to "Steaby"
+func subjectNames(inDB dbRunner) (map[string]string, error) {
+    names := map[string]string{}
+
+    for prefix, query := range map[string]string{
+        "u": "SELECT user_id, username FROM user_dim",
+        "r": "SELECT role_dim_id, role_name FROM auth_role_dim",
+        "o": "SELECT organization_id, organization_name FROM organization",
+    } {
+        rows, err := inDB.Query(query)
+        if err != nil {
+            return nil, err
+        }
+        for rows.Next() {
+            var id   int
+            var name string
+            if err := rows.Scan(&id, &name); err != nil {
+                rows.Close()
+                return nil, err
+            }
+            names[fmt.Sprintf("%s%d", prefix, id)] = name
+        }
+        rows.Close()
+        if err := rows.Err(); err != nil {
+            return nil, err
+        }
+    }
+
+    return names, nil
+}
+
+// userSubject turns a username into the user's Casbin subject
+func userSubject(inDB dbRunner, inUsername string) (string, error) {
+    var userID int
+
+    err := inDB.QueryRow("SELECT user_id FROM user_dim WHERE username = ?", strings.TrimSpace(inUsername)).Scan(&userID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return "", newError("user %q does not exist", strings.TrimSpace(inUsername))
+    }
+    if err != nil {
+        return "", err
+    }
+
+    return fmt.Sprintf("u%d", userID), nil
+}
+
+// explainAccessText is the "Why?" answer for a denied element, one line per reason
+func explainAccessText(inDB dbRunner, inEnforcer *OrgEnforcer, inSubject string, inObject string, inAction string) string {
+    explanation, err := explainAccess(inEnforcer, inSubject, inObject, inAction)
+    if err != nil {
+        return tr().Sprintf("Could not explain the decision: %s", errorText(err))
+    }
+    names, err := subjectNames(inDB)
+    if err != nil {
+        return tr().Sprintf("Could not explain the decision: %s", errorText(err))
+    }
+
+    return strings.Join(explanation.Lines(names), "\n")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func Test_explainAccess(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+
+    names, err := subjectNames(db)
+    if err != nil || names["u3"] != "Petar" || names["r2"] != "B_minion" {
+        t.Fatalf("subjectNames() = %v, %v", names, err)
+    }
+
+    tests := []struct {
+        name          string
+        subject       string
+        object        string
+        action        string
+        wantAllowed   bool
+        wantDecided   string
+        wantMatching  int
+    }{
+        {"user deny beats the role allow",  "u3", "report_text", "read",  false, "Decided by: deny read on report_text for Petar (u3)",             2},
+        {"role deny",                       "u1", "time_entry",  "write", false, "Decided by: deny write on time_entry for B_admin (r1)",          1},
+        {"allowed through the group",       "u2", "project_1",   "write", true,  "Decided by: allow write on client_work for B_minion (r2)",       1},
+        {"nothing matches",                 "u2", "nothing",     "read",  false, "Decided by: no rule matches, so it is denied by default",        0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            explanation, err := explainAccess(enforcer, tt.subject, tt.object, tt.action)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if explanation.Allowed != tt.wantAllowed || len(explanation.MatchingRules) != tt.wantMatching {
+                t.Errorf("explainAccess() = %+v", explanation)
+            }
+            lines := explanation.Lines(names)
+            if len(lines) < 3 || lines[1] != tt.wantDecided || !strings.HasPrefix(lines[2], "Role: ") {
+                t.Errorf("Lines() = %q", lines)
+            }
+        })
+    }
+
+    if _, err := userSubject(db, "Nobody"); err == nil {
+        t.Error("userSubject() of an unknown user did not fail")
+    }
+    if got := explainAccessText(db, enforcer, "u3", "project_2", "write"); !strings.Contains(got, "Object groups: project_2 is in client_work") {
+        t.Errorf("explainAccessText() = %q", got)
+    }
+}
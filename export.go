@@ -0,0 +1,329 @@
+package main
+
+import (
+    "database/sql"
+    "encoding/csv"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+)
+
+
+// Casbin objects for reading time entries - your own, and everybody else's
+const (
+    timeEntryObject     = "time_entry"
+    teamTimeEntryObject = "team_time_entry"
+)
+
+// Casbin action for changing or deleting a logged entry, its rules usually have conditions on the entry
+const timeEntryActEdit = "edit"
+
+const exportFolder = "data/exports"
+
+// ExportFilter narrows down which time entries get exported, zero values mean "no filter"
+type ExportFilter struct {
+    From        time.Time
+    To          time.Time
+    Username    string
+    ClientName  string
+    State       string
+}
+
+// ExportRow is one time entry with the names resolved, as it appears in a spreadsheet
+type ExportRow struct {
+    TimeEntryID int
+    EntryDate   time.Time
+    WeekStart   time.Time
+    UserID      int
+    Username    string
+    ClientName  string
+    ProjectID   int
+    ProjectName string
+    TaskID      int
+    TaskName    string
+    Minutes     int
+    State       string
+}
+
+// exportLocale decides how dates and numbers look in the exported files
+type exportLocale struct {
+    DateLayout      string
+    XlsxDateFormat  string
+    DecimalSep      string
+    CSVSeparator    rune
+}
+
+// Spreadsheets on comma-decimal locales expect semicolons between CSV fields
+var exportLocales = map[string]exportLocale{
+    "en":    {DateLayout: "2006-01-02", XlsxDateFormat: "yyyy-mm-dd", DecimalSep: ".", CSVSeparator: ','},
+    "en-US": {DateLayout: "01/02/2006", XlsxDateFormat: "mm/dd/yyyy", DecimalSep: ".", CSVSeparator: ','},
+    "de":    {DateLayout: "02.01.2006", XlsxDateFormat: "dd.mm.yyyy", DecimalSep: ",", CSVSeparator: ';'},
+    "sl":    {DateLayout: "2. 1. 2006", XlsxDateFormat: "d. m. yyyy", DecimalSep: ",", CSVSeparator: ';'},
+}
+
+// exportColumn is a column that can be picked for an export
+type exportColumn struct {
+    Header  string
+    // Value returns either a string, an int, a float64 or a time.Time, so each format can write it natively
+    Value   func(inRow ExportRow) any
+}
+
+var exportColumns = map[string]exportColumn{
+    "entry_id": {"Entry ID", func(r ExportRow) any { return r.TimeEntryID }},
+    "date":     {"Date",     func(r ExportRow) any { return r.EntryDate }},
+    "week":     {"Week",     func(r ExportRow) any { return r.WeekStart }},
+    "user":     {"User",     func(r ExportRow) any { return r.Username }},
+    "client":   {"Client",   func(r ExportRow) any { return r.ClientName }},
+    "project":  {"Project",  func(r ExportRow) any { return r.ProjectName }},
+    "task":     {"Task",     func(r ExportRow) any { return r.TaskName }},
+    "hours":    {"Hours",    func(r ExportRow) any { return float64(r.Minutes) / 60 }},
+    "minutes":  {"Minutes",  func(r ExportRow) any { return r.Minutes }},
+    "state":    {"State",    func(r ExportRow) any { return r.State }},
+}
+
+var defaultExportColumns = []string{"date", "user", "client", "hours", "state"}
+
+
+// parseExportColumns turns "date,user,hours" into a column list, empty text gives the default columns
+func parseExportColumns(inText string) ([]string, error) {
+    if strings.TrimSpace(inText) == "" {
+        return defaultExportColumns, nil
+    }
+
+    var columns []string
+    for _, column := range strings.Split(inText, ",") {
+        column = strings.ToLower(strings.TrimSpace(column))
+        if _, ok := exportColumns[column]; !ok {
+            return nil, newError("unknown column %q", column)
+        }
+        columns = append(columns, column)
+    }
+
+    return columns, nil
+}
+
+func getExportLocale(inName string) (exportLocale, error) {
+    if inName == "" {
+        inName = "en"
+    }
+    locale, ok := exportLocales[inName]
+    if !ok {
+        return exportLocale{}, newError("unknown locale %q", inName)
+    }
+
+    return locale, nil
+}
+
+
+// queryExportRows loads the filtered time entries the user is allowed to read.
+// Own entries need "read" on time_entry, entries of other users need "read" on team_time_entry.
+func queryExportRows(inDB *sql.DB, inEnforcer *OrgEnforcer, inUserID int, inFilter ExportFilter) ([]ExportRow, error) {
+    subject := fmt.Sprintf("u%d", inUserID)
+
+    canReadOwn, err := inEnforcer.Enforce(subject, timeEntryObject, "read")
+    if err != nil {
+        return nil, err
+    }
+    canReadTeam, err := inEnforcer.Enforce(subject, teamTimeEntryObject, "read")
+    if err != nil {
+        return nil, err
+    }
+
+    var where []string
+    var args  []any
+
+    if !inFilter.From.IsZero() {
+        where = append(where, "te.entry_date >= ?")
+        args  = append(args, dateKey(inFilter.From))
+    }
+    if !inFilter.To.IsZero() {
+        where = append(where, "te.entry_date <= ?")
+        args  = append(args, dateKey(inFilter.To))
+    }
+    if inFilter.Username != "" {
+        where = append(where, "ud.username = ?")
+        args  = append(args, inFilter.Username)
+    }
+    if inFilter.ClientName != "" {
+        where = append(where, "te.client_name = ? COLLATE NOCASE")
+        args  = append(args, inFilter.ClientName)
+    }
+    if inFilter.State != "" {
+        where = append(where, "ts.state = ?")
+        args  = append(args, inFilter.State)
+    }
+
+    // Only the organization's weeks, the other organizations' entries stay there
+    where = append(where, "ts.organization_id = ?")
+    args  = append(args, inEnforcer.OrganizationID)
+
+    switch {
+    case canReadOwn && canReadTeam:
+    case canReadOwn:
+        where = append(where, "te.user_id = ?")
+        args  = append(args, inUserID)
+    case canReadTeam:
+        where = append(where, "te.user_id <> ?")
+        args  = append(args, inUserID)
+    default:
+        return nil, nil
+    }
+
+    rows, err := inDB.Query(fmt.Sprintf(`
+SELECT
+      te.time_entry_id
+    , te.entry_date
+    , ts.week_start
+    , te.user_id
+    , COALESCE(ud.username, '')
+    , te.client_name
+    , COALESCE(te.project_id, 0)
+    , COALESCE(pr.project_name, '')
+    , COALESCE(te.task_id, 0)
+    , COALESCE(tk.task_name, '')
+    , te.minutes_spent
+    , ts.state
+FROM
+    time_entry                  AS te
+    JOIN timesheet              AS ts
+        ON ts.timesheet_id = te.timesheet_id
+    LEFT JOIN user_dim          AS ud
+        ON ud.user_id = te.user_id
+    LEFT JOIN project           AS pr
+        ON pr.project_id = te.project_id
+    LEFT JOIN task              AS tk
+        ON tk.task_id = te.task_id
+WHERE
+    %s
+ORDER BY
+    te.entry_date, ud.username, te.time_entry_id
+    `, strings.Join(where, "\n    AND ")), args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var exportRows []ExportRow
+    for rows.Next() {
+        var row ExportRow
+        if err := rows.Scan(&row.TimeEntryID, &row.EntryDate, &row.WeekStart, &row.UserID, &row.Username, &row.ClientName,
+            &row.ProjectID, &row.ProjectName, &row.TaskID, &row.TaskName, &row.Minutes, &row.State); err != nil {
+            return nil, err
+        }
+        exportRows = append(exportRows, row)
+    }
+
+    return exportRows, rows.Err()
+}
+
+
+// writeExportCSV writes the rows as CSV with the locale's separators and date layout
+func writeExportCSV(inWriter io.Writer, inRows []ExportRow, inColumns []string, inLocale exportLocale) error {
+    writer      := csv.NewWriter(inWriter)
+    writer.Comma = inLocale.CSVSeparator
+
+    var header []string
+    for _, column := range inColumns {
+        header = append(header, exportColumns[column].Header)
+    }
+    if err := writer.Write(header); err != nil {
+        return err
+    }
+
+    for _, row := range inRows {
+        var record []string
+        for _, column := range inColumns {
+            record = append(record, formatExportValue(exportColumns[column].Value(row), inLocale))
+        }
+        if err := writer.Write(record); err != nil {
+            return err
+        }
+    }
+    writer.Flush()
+
+    return writer.Error()
+}
+
+func formatExportValue(inValue any, inLocale exportLocale) string {
+    switch value := inValue.(type) {
+    case time.Time:
+        return value.Format(inLocale.DateLayout)
+    case float64:
+        return strings.Replace(strconv.FormatFloat(value, 'f', 2, 64), ".", inLocale.DecimalSep, 1)
+    case int:
+        return strconv.Itoa(value)
+    default:
+        return fmt.Sprint(value)
+    }
+}
+
+// exportFileName builds a file name like time_entries_20261019_153000.csv
+func exportFileName(inFormat string, inNow time.Time) string {
+    return fmt.Sprintf("time_entries_%s.%s", inNow.Format("20060102_150405"), inFormat)
+}
+
+// writeExport writes the rows in the given format, "csv" or "xlsx"
+func writeExport(inWriter io.Writer, inFormat string, inRows []ExportRow, inColumns []string, inLocale exportLocale) error {
+    switch inFormat {
+    case "csv":
+        return writeExportCSV(inWriter, inRows, inColumns, inLocale)
+    case "xlsx":
+        return writeExportXLSX(inWriter, inRows, inColumns, inLocale)
+    default:
+        return newError("unknown export format %q, use csv or xlsx", inFormat)
+    }
+}
+
+// exportTimeEntries runs a whole export into a file and returns how many entries were written
+func exportTimeEntries(inDB *sql.DB, inEnforcer *OrgEnforcer, inUserID int, inFilter ExportFilter, inFormat string, inColumns []string, inLocale exportLocale, inPath string) (int, error) {
+    exportRows, err := queryExportRows(inDB, inEnforcer, inUserID, inFilter)
+    if err != nil {
+        return 0, err
+    }
+
+    if err := os.MkdirAll(filepath.Dir(inPath), 0o755); err != nil {
+        return 0, err
+    }
+    file, err := os.Create(inPath)
+    if err != nil {
+        return 0, err
+    }
+    defer file.Close()
+
+    if err := writeExport(file, inFormat, exportRows, inColumns, inLocale); err != nil {
+        return 0, err
+    }
+
+    return len(exportRows), file.Close()
+}
+
+// parseExportOptions turns the texts from the command line or the export window into an export setup
+func parseExportOptions(inFrom string, inTo string, inUsername string, inClient string, inState string, inColumns string, inLocale string) (ExportFilter, []string, exportLocale, error) {
+    var filter ExportFilter
+    var err    error
+
+    if filter.From, err = parseDateOr(inFrom, time.Time{}); err != nil {
+        return filter, nil, exportLocale{}, err
+    }
+    if filter.To, err = parseDateOr(inTo, time.Time{}); err != nil {
+        return filter, nil, exportLocale{}, err
+    }
+    if _, known := timesheetTransitions[inState]; inState != "" && !known && inState != timesheetLocked {
+        return filter, nil, exportLocale{}, newError("unknown timesheet state %q", inState)
+    }
+    filter.Username   = inUsername
+    filter.ClientName = inClient
+    filter.State      = inState
+
+    columns, err := parseExportColumns(inColumns)
+    if err != nil {
+        return filter, nil, exportLocale{}, err
+    }
+    locale, err := getExportLocale(inLocale)
+
+    return filter, columns, locale, err
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+    "archive/zip"
+    "bytes"
+    "io"
+    "os"
+    "strings"
+    "testing"
+    "time"
+)
+
+func Test_queryExportRows(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer    := openTestEnforcer(t, dbPath)
+    day         := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    approvedWeek(t, db, dbPath, 2, 90, day)
+    if _, err := addTimeEntry(db, 1, 3, "Initech", day, 30); err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name      string
+        userID    int
+        filter    ExportFilter
+        wantUsers []string
+    }{
+        {"admin reads everybody",         1, ExportFilter{},                           []string{"Petar", "Tadej"}},
+        {"minion reads only own",         3, ExportFilter{},                           []string{"Petar"}},
+        {"filter on state",               1, ExportFilter{State: timesheetApproved},   []string{"Tadej"}},
+        {"filter on client",              1, ExportFilter{ClientName: "initech"},      []string{"Petar"}},
+        {"filter on user",                1, ExportFilter{Username: "Tadej"},          []string{"Tadej"}},
+        {"filter on date range",          1, ExportFilter{From: day.AddDate(0, 0, 1)}, nil},
+        {"minion can't filter on others", 2, ExportFilter{Username: "Petar"},          nil},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rows, err := queryExportRows(db, enforcer, tt.userID, tt.filter)
+            if err != nil {
+                t.Fatal(err)
+            }
+            var users []string
+            for _, row := range rows {
+                users = append(users, row.Username)
+            }
+            if strings.Join(users, ",") != strings.Join(tt.wantUsers, ",") {
+                t.Errorf("queryExportRows() users = %v, want %v", users, tt.wantUsers)
+            }
+        })
+    }
+}
+
+func Test_writeExport(t *testing.T) {
+    rows := []ExportRow{{TimeEntryID: 1, EntryDate: time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC), Username: "Tadej", ClientName: "ACME; Inc", Minutes: 90, State: "approved"}}
+    columns, _ := parseExportColumns("date, user, client, hours")
+
+    tests := []struct {
+        name   string
+        locale string
+        want   string
+    }{
+        {"english", "en",    "Date,User,Client,Hours\n2026-10-21,Tadej,ACME; Inc,1.50\n"},
+        {"us",      "en-US", "Date,User,Client,Hours\n10/21/2026,Tadej,ACME; Inc,1.50\n"},
+        {"german",  "de",    "Date;User;Client;Hours\n21.10.2026;Tadej;\"ACME; Inc\";1,50\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            locale, _ := getExportLocale(tt.locale)
+            var out bytes.Buffer
+            if err := writeExport(&out, "csv", rows, columns, locale); err != nil {
+                t.Fatal(err)
+            }
+            if out.String() != tt.want {
+                t.Errorf("writeExport() = %q, want %q", out.String(), tt.want)
+            }
+        })
+    }
+
+    // XLSX keeps dates and hours as numbers, formatted by the locale's date style
+    locale, _ := getExportLocale("de")
+    var out bytes.Buffer
+    if err := writeExport(&out, "xlsx", rows, columns, locale); err != nil {
+        t.Fatal(err)
+    }
+    archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    parts := map[string]string{}
+    for _, file := range archive.File {
+        reader, _ := file.Open()
+        content, _ := io.ReadAll(reader)
+        parts[file.Name] = string(content)
+    }
+    for part, want := range map[string]string{
+        "xl/worksheets/sheet1.xml": `<c r="A2" s="2"><v>46316</v></c>`,
+        "xl/styles.xml":            `formatCode="dd.mm.yyyy"`,
+        "[Content_Types].xml":      "spreadsheetml.sheet.main+xml",
+    } {
+        if !strings.Contains(parts[part], want) {
+            t.Errorf("%s misses %q: %s", part, want, parts[part])
+        }
+    }
+    if !strings.Contains(parts["xl/worksheets/sheet1.xml"], `<c r="D2" s="3"><v>1.5</v></c>`) {
+        t.Errorf("hours are not a number: %s", parts["xl/worksheets/sheet1.xml"])
+    }
+
+    if _, err := parseExportColumns("date,salary"); err == nil {
+        t.Error("parseExportColumns() accepted an unknown column")
+    }
+}
+
+func Test_runCommand(t *testing.T) {
+    db, _ := openTestDb(t)
+
+    tests := []struct {
+        name     string
+        args     []string
+        password string
+        wantCode int
+        wantOut  string
+    }{
+        {"help",              []string{"help"},                                          "",            0, "Commands:"},
+        {"unknown command",   []string{"frobnicate"},                                    "",            2, ""},
+        {"bad locale",        []string{"export", "-user", "Ray", "-locale", "xx"},       "bestpass",    2, ""},
+        {"wrong password",    []string{"export", "-user", "Ray", "-out", "-"},           "nope",        1, ""},
+        {"password flag",     []string{"export", "-user", "Ray", "-password", "bestpass"}, "bestpass",  2, ""},
+        {"quote in password", []string{"export", "-user", "Ray", "-out", "-"},           "' OR '1'='1", 1, ""},
+        {"quote in username", []string{"export", "-user", "x' OR 1=1 --", "-out", "-"},  "x",           1, ""},
+        {"quote for policy",  []string{"policy", "export", "-user", "Ray"},              "' OR '1'='1", 1, ""},
+        {"export stdout",     []string{"export", "-user", "Ray", "-out", "-"},           "bestpass",    0, "Date,User,Client,Hours,State\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            t.Setenv("SHOWCASE_PASSWORD", tt.password)
+            var stdout, stderr bytes.Buffer
+            if code := runCommand(tt.args, db, &stdout, &stderr); code != tt.wantCode {
+                t.Fatalf("runCommand() = %v, want %v, stderr: %s", code, tt.wantCode, stderr.String())
+            }
+            if !strings.Contains(stdout.String(), tt.wantOut) {
+                t.Errorf("runCommand() stdout = %q, want %q", stdout.String(), tt.wantOut)
+            }
+        })
+    }
+}
+
+func Test_readPasswordLine(t *testing.T) {
+    password, err := readPasswordLine(strings.NewReader("bestpass\r\nrest"))
+    if err != nil || password != "bestpass" {
+        t.Errorf("readPasswordLine() = %q, %v, want %q", password, err, "bestpass")
+    }
+}
+
+// Test_exportStdout pipes an export, so nothing else may reach stdout, not even from the process' own os.Stdout
+func Test_exportStdout(t *testing.T) {
+    db, _ := openTestDb(t)
+
+    reader, writer, err := os.Pipe()
+    if err != nil {
+        t.Fatal(err)
+    }
+    processStdout := os.Stdout
+    os.Stdout      = writer
+    defer func() { os.Stdout = processStdout }()
+
+    t.Setenv("SHOWCASE_PASSWORD", "bestpass")
+    var stdout, stderr bytes.Buffer
+    code := runCommand([]string{"export", "-user", "Ray", "-out", "-"}, db, &stdout, &stderr)
+    os.Stdout = processStdout
+    writer.Close()
+    stray, _ := io.ReadAll(reader)
+
+    if code != 0 {
+        t.Fatalf("runCommand() = %v, stderr: %s", code, stderr.String())
+    }
+    if !strings.HasPrefix(stdout.String(), "Date,User,Client,Hours,State\n") {
+        t.Errorf("export does not start with the header row: %q", stdout.String())
+    }
+    if len(stray) > 0 {
+        t.Errorf("export wrote %q to the process' stdout", stray)
+    }
+}
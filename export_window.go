@@ -0,0 +1,130 @@
+package main
+
+import (
+    "database/sql"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "path/filepath"
+    "showcase_desktop/widgets"
+    "time"
+)
+
+
+// runExport lets the user pick filters, columns and a locale and writes the time entries they may read to a file
+func runExport(inWindow *app.Window, inUserID int, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var fromTextbox         widget.Editor
+    var toTextbox           widget.Editor
+    var userTextbox         widget.Editor
+    var clientTextbox       widget.Editor
+    var stateTextbox        widget.Editor
+    var columnsTextbox      widget.Editor
+    var localeTextbox       widget.Editor
+    var csvBtn              widget.Clickable
+    var xlsxBtn             widget.Clickable
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Export time entries")
+
+    // Amounts and dates in the file follow the user's language unless they pick another locale
+    localeTextbox.SetText(tr().Tag())
+
+    inWindow.Option(app.Title(tr().Text("Export")), app.Size(unit.Dp(1050), unit.Dp(500)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            format := ""
+            if csvBtn.Clicked(gtx) {
+                format = "csv"
+            }
+            if xlsxBtn.Clicked(gtx) {
+                format = "xlsx"
+            }
+
+            if format != "" {
+                filter, columns, locale, err := parseExportOptions(fromTextbox.Text(), toTextbox.Text(), userTextbox.Text(),
+                    clientTextbox.Text(), stateTextbox.Text(), columnsTextbox.Text(), localeTextbox.Text())
+
+                if err != nil {
+                    statusMsg = errorText(err)
+                } else {
+                    path       := filepath.Join(exportFolder, exportFileName(format, time.Now()))
+                    count, err := exportTimeEntries(inS3db, inEnforcer, inUserID, filter, format, columns, locale, path)
+                    if err != nil {
+                        statusMsg = tr().Sprintf("Could not export: %s", errorText(err))
+                    } else {
+                        statusMsg = tr().Plural(count, "Exported %d entry to %s", "Exported %d entries to %s", count, path)
+                    }
+                }
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                // Result of the last export
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                // Filters
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &fromTextbox, tr().Text("From YYYY-MM-DD")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &toTextbox, tr().Text("To YYYY-MM-DD")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &stateTextbox, tr().Text("State, e.g. approved")).Layout(gtx) },
+                    )
+                }),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &userTextbox, tr().Text("Only this user")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &clientTextbox, tr().Text("Only this client")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &localeTextbox, tr().Text("Locale: en, en-US, de, sl")).Layout(gtx) },
+                    )
+                }),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.InputBox(theme, &columnsTextbox, tr().Text("Columns, e.g. date,user,client,project,task,hours")).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Button(theme, &csvBtn, tr().Text("Export CSV")).Layout(gtx)
+                }),
+                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Button(theme, &xlsxBtn, tr().Text("Export XLSX")).Layout(gtx)
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
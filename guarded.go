@@ -0,0 +1,106 @@
+package main
+
+import (
+    "gioui.org/gesture"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/op/clip"
+    "gioui.org/op/paint"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "image"
+    "image/color"
+    "showcase_desktop/widgets"
+)
+
+
+// Permission-aware versions of the elements below, so screens don't hand-code Casbin checks around them.
+// Each one takes the window's permission snapshot and a guard, and draws the element, a disabled variant or nothing.
+
+// guardedEditor is an editor that remembers the hover of its tooltip
+type guardedEditor struct {
+    widget.Editor
+    hover           gesture.Hover
+}
+
+// guardedElement draws inWidget in the given state - hidden draws nothing, disabled draws it without taking input
+func guardedElement(inGTX layout.Context, inState GuardState, inWidget layout.Widget) layout.Dimensions {
+    switch inState {
+    case GuardHidden:
+        return layout.Dimensions{}
+    case GuardDisabled:
+        return inWidget(inGTX.Disabled())
+    default:
+        return inWidget(inGTX)
+    }
+}
+
+// guardedLabelElement shows the report text only to users who may view it
+func guardedLabelElement(inGTX layout.Context, inTheme *widgets.Theme, inPerms PermissionSnapshot, inGuard Guard, inTxt string, inColor color.NRGBA) layout.Dimensions {
+    return guardedElement(inGTX, inPerms.State(inGuard), func(gtx layout.Context) layout.Dimensions {
+        return widgets.ReportBox(inTheme, inTxt, inColor).Layout(gtx)
+    })
+}
+
+// guardedBtnElement shows the button if any of the guards lets the user see it, and greys it out if none lets them use it
+func guardedBtnElement(inGTX layout.Context, inTheme *widgets.Theme, inPerms PermissionSnapshot, inBtn *widget.Clickable, inBtnText string, inGuards ...Guard) layout.Dimensions {
+    return guardedElement(inGTX, inPerms.State(inGuards...), func(gtx layout.Context) layout.Dimensions {
+        return widgets.Button(inTheme, inBtn, inBtnText).Layout(gtx)
+    })
+}
+
+// guardedInputBoxElement makes the editor read-only with inReadOnlyTip on hover when the user may read but not write it,
+// and hides it without read
+func guardedInputBoxElement(inGTX layout.Context, inTheme *widgets.Theme, inPerms PermissionSnapshot, inGuard Guard, inInputTextbox *guardedEditor, inHintText string, inReadOnlyTip string) layout.Dimensions {
+    state                   := inPerms.State(inGuard)
+    inInputTextbox.ReadOnly  = state != GuardEnabled
+
+    switch state {
+    case GuardHidden:
+        return layout.Dimensions{}
+    case GuardDisabled:
+        return tooltipElement(inGTX, inTheme, &inInputTextbox.hover, inReadOnlyTip, func(gtx layout.Context) layout.Dimensions {
+            return widgets.InputBox(inTheme, &inInputTextbox.Editor, inHintText).Layout(gtx)
+        })
+    default:
+        return widgets.InputBox(inTheme, &inInputTextbox.Editor, inHintText).Layout(inGTX)
+    }
+}
+
+
+// tooltipElement shows inTip under the widget while the pointer is over it
+func tooltipElement(inGTX layout.Context, inTheme *widgets.Theme, inHover *gesture.Hover, inTip string, inWidget layout.Widget) layout.Dimensions {
+    hovered := inHover.Update(inGTX.Source)
+    dims    := inWidget(inGTX)
+
+    // The hover area covers the whole widget
+    area := clip.Rect{Max: dims.Size}.Push(inGTX.Ops)
+    inHover.Add(inGTX.Ops)
+    area.Pop()
+
+    if !hovered || len(inTip) == 0 {
+        return dims
+    }
+
+    // Deferred so the tip is drawn over whatever comes below the widget
+    macro := op.Record(inGTX.Ops)
+    op.Offset(image.Pt(0, dims.Size.Y)).Add(inGTX.Ops)
+    tipGTX                := inGTX
+    tipGTX.Constraints.Min = image.Point{}
+    layout.Background{}.Layout(tipGTX,
+        func(gtx layout.Context) layout.Dimensions {
+            defer clip.UniformRRect(image.Rectangle{Max: gtx.Constraints.Min}, gtx.Dp(3)).Push(gtx.Ops).Pop()
+            paint.Fill(gtx.Ops, inTheme.Tooltip)
+            return layout.Dimensions{Size: gtx.Constraints.Min}
+        },
+        func(gtx layout.Context) layout.Dimensions {
+            tip      := material.Body2(inTheme.Theme, inTip)
+            tip.Color = inTheme.TooltipText
+            return layout.UniformInset(unit.Dp(6)).Layout(gtx, tip.Layout)
+        },
+    )
+    op.Defer(inGTX.Ops, macro.Stop())
+
+    return dims
+}
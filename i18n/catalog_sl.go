@@ -0,0 +1,455 @@
+package i18n
+
+
+// slovenianMessages are the Slovenian translations. Plurals have the forms for one, two, three or four, and other.
+var slovenianMessages = map[string][]string{
+    // Sign in
+    "Very Simple-teab app":                     {"Zelo preprosta aplikacija"},
+    "Sign In":                                  {"Prijava"},
+    "Enter username":                           {"Vnesite uporabniško ime"},
+    "Enter password":                           {"Vnesite geslo"},
+    "Organization":                             {"Organizacija"},
+    "Continue":                                 {"Nadaljuj"},
+    "Please enter a username and a password":   {"Vnesite uporabniško ime in geslo"},
+    "Wrong username or password":               {"Napačno uporabniško ime ali geslo"},
+    "Could not load your organizations: %s":    {"Vaših organizacij ni bilo mogoče naložiti: %s"},
+    "You are not in any organization":          {"Niste član nobene organizacije"},
+    "you are not in any organization":      {"niste član nobene organizacije"},
+    "you are not in the organization %q":   {"niste član organizacije %q"},
+
+    // Main screen
+    "Very Simple showcase app with unnecessarily long title":    {"Zelo preprosta predstavitvena aplikacija z nepotrebno dolgim naslovom"},
+    "Welcome back to %s, %s! We did not miss you!":              {"Dobrodošli nazaj v %s, %s! Niste nam manjkali!"},
+    "Your user ID is %d, probably":                              {"Vaš ID uporabnika je %d, verjetno"},
+    "Only Admin users can view their ID, you are just a minion": {"Svoj ID vidijo le skrbniki, vi ste samo podrejeni"},
+    "Confirm":                                                   {"Potrdi"},
+    "Could not load this week's timesheet":                      {"Časovnice tega tedna ni bilo mogoče naložiti"},
+    "Week of %s: %s, %s logged":                                 {"Teden %s: %s, vneseno %s"},
+    " (rejected: %s)":                                           {" (zavrnjeno: %s)"},
+    "Could not load the project budgets":                        {"Proračunov projektov ni bilo mogoče naložiti"},
+    "You shall not pass!.. the reports":                         {"Ne boste šli mimo!.. poročil"},
+    "Could not log the time: %s":                                {"Časa ni bilo mogoče vnesti: %s"},
+    "Logged %[2]s for %[3]s, confirmed %[1]d times": {
+        "Vneseno %[2]s za %[3]s, potrjeno %[1]d-krat",
+        "Vneseno %[2]s za %[3]s, potrjeno %[1]d-krat",
+        "Vneseno %[2]s za %[3]s, potrjeno %[1]d-krat",
+        "Vneseno %[2]s za %[3]s, potrjeno %[1]d-krat",
+    },
+    "Could not submit the week: %s":                             {"Tedna ni bilo mogoče oddati: %s"},
+    "Why?":                                                      {"Zakaj?"},
+    "Input for T&B client name":                                 {"Vnos imena stranke za T&B"},
+    "Read-only: you may not change the client name":             {"Samo za branje: imena stranke ne smete spreminjati"},
+    "Input for T&B time spent":                                  {"Vnos porabljenega časa za T&B"},
+    "Read-only: you may not change the time spent":              {"Samo za branje: porabljenega časa ne smete spreminjati"},
+    "My week":                                                   {"Moj teden"},
+    "Submit week":                                               {"Oddaj teden"},
+    "Approvals":                                                 {"Odobritve"},
+    "Billing":                                                   {"Obračun"},
+    "Export":                                                    {"Izvoz"},
+    "Import":                                                    {"Uvoz"},
+    "Reports":                                                   {"Poročila"},
+    "Check access":                                              {"Preveri dostop"},
+    "Roles":                                                     {"Vloge"},
+    "Policies":                                                  {"Pravila"},
+    "Audit log":                                                 {"Revizijska sled"},
+    "Notifications":                                             {"Obvestila"},
+    "Settings":                                                  {"Nastavitve"},
+    "Timer started at %s, %s stops it":                          {"Štoparica teče od %s, %s jo ustavi"},
+    "Timer stopped after %s, press Enter to log it":             {"Štoparica ustavljena po %s, pritisnite Enter za vnos"},
+    "Press %s for the keyboard shortcuts":                       {"Pritisnite %s za bližnjice na tipkovnici"},
+
+    // Keyboard shortcuts
+    "Keyboard shortcuts":                       {"Bližnjice na tipkovnici"},
+    "Next input or button":                     {"Naslednje polje ali gumb"},
+    "Previous input or button":                 {"Prejšnje polje ali gumb"},
+    "Log the time, in the time input":          {"Vnesi čas, v polju za čas"},
+    "New entry":                                {"Nov vnos"},
+    "Start or stop the timer":                  {"Zaženi ali ustavi štoparico"},
+    "Sign out":                                 {"Odjava"},
+    "Show or hide the shortcuts":               {"Pokaži ali skrij bližnjice"},
+
+    // Project picker
+    "Client":                                   {"Stranka"},
+    "Project":                                  {"Projekt"},
+    "Task":                                     {"Naloga"},
+
+    // Timesheet states
+    "draft":                                    {"osnutek"},
+    "submitted":                                {"oddano"},
+    "approved":                                 {"odobreno"},
+    "rejected":                                 {"zavrnjeno"},
+    "locked":                                   {"zaklenjeno"},
+
+    // My week
+    "Could not load the week":                  {"Tedna ni bilo mogoče naložiti"},
+    "Week of %[2]s is %[3]s, %[1]d entries": {
+        "Teden %[2]s je %[3]s, %[1]d vnos",
+        "Teden %[2]s je %[3]s, %[1]d vnosa",
+        "Teden %[2]s je %[3]s, %[1]d vnosi",
+        "Teden %[2]s je %[3]s, %[1]d vnosov",
+    },
+    "Changed the entry of %s":                  {"Vnos za %s je spremenjen"},
+    "Deleted the entry of %s":                  {"Vnos za %s je izbrisan"},
+    "Undid %d changes, then: %s": {
+        "Razveljavljena %d sprememba, nato: %s",
+        "Razveljavljeni %d spremembi, nato: %s",
+        "Razveljavljene %d spremembe, nato: %s",
+        "Razveljavljenih %d sprememb, nato: %s",
+    },
+    "Undid your last %d changes": {
+        "Razveljavljena je vaša zadnja %d sprememba",
+        "Razveljavljeni sta vaši zadnji %d spremembi",
+        "Razveljavljene so vaše zadnje %d spremembe",
+        "Razveljavljenih je vaših zadnjih %d sprememb",
+    },
+    "Restored v%d of the entry of %s":          {"Obnovljena različica v%d vnosa za %s"},
+    "Previous week":                            {"Prejšnji teden"},
+    "Next week":                                {"Naslednji teden"},
+    "History":                                  {"Zgodovina"},
+    "Changes to undo, default %d":              {"Število sprememb za razveljavitev, privzeto %d"},
+    "Undo my last changes":                     {"Razveljavi moje zadnje spremembe"},
+    "Hours":                                    {"Ure"},
+    "Save":                                     {"Shrani"},
+    "Delete":                                   {"Izbriši"},
+    "Restore":                                  {"Obnovi"},
+    "%s (undone)":                          {"%s (razveljavljeno)"},
+    "v%d %s by %s at %s: %s %s %s":         {"v%d %s (%s, %s): %s %s %s"},
+    "created":                              {"ustvarjeno"},
+    "updated":                              {"posodobljeno"},
+    "deleted":                              {"izbrisano"},
+    "restored":                             {"obnovljeno"},
+    "undone":                               {"razveljavljeno"},
+    "entry back":                           {"vnos vrnjen"},
+    "time %s -> %s":                        {"čas %s -> %s"},
+    "date %s -> %s":                        {"datum %s -> %s"},
+    "client %s -> %s":                      {"stranka %s -> %s"},
+    "task %d -> %d":                        {"naloga %d -> %d"},
+    "undo needs a number of changes, not %q": {"za razveljavitev vnesite število sprememb, ne %q"},
+    "could not undo v%d of the %s entry":   {"v%d vnosa za %s ni bilo mogoče razveljaviti"},
+    "v%d is the delete, restore the version before it": {"v%d je izbris, obnovite različico pred njim"},
+    "invalid time spent %q":                {"neveljaven porabljeni čas %q"},
+    "time spent %q must be between 1 minute and 24 hours": {"porabljeni čas %q mora biti med 1 minuto in 24 urami"},
+    "time entry not found":                 {"vnosa ni mogoče najti"},
+    "you may not change this time entry":   {"tega vnosa ne smete spreminjati"},
+    "time entry version not found":         {"različice vnosa ni mogoče najti"},
+    "you have no changes left to undo":     {"nimate več sprememb za razveljavitev"},
+
+    // Approvals
+    "Timesheets waiting for approval":          {"Časovnice, ki čakajo na odobritev"},
+    "Could not load pending timesheets":        {"Čakajočih časovnic ni bilo mogoče naložiti"},
+    "Nothing to approve, go get a coffee":      {"Ničesar ni za odobriti, pojdite na kavo"},
+    "Could not approve %s's week: %s":          {"Tedna uporabnika %s ni bilo mogoče odobriti: %s"},
+    "Could not reject %s's week: %s":           {"Tedna uporabnika %s ni bilo mogoče zavrniti: %s"},
+    "%s's week of %s is now %s":                {"Teden %[2]s uporabnika %[1]s je zdaj %[3]s"},
+    "Note for the submitter":                   {"Opomba za oddajatelja"},
+    "%s - week of %s - %s":                     {"%s - teden %s - %s"},
+    "Approve":                                  {"Odobri"},
+    "Reject":                                   {"Zavrni"},
+    "the week is %s":                       {"teden je v stanju %s"},
+    "it was changed in the meantime":       {"medtem ga je spremenil nekdo drug"},
+    "timesheet cannot make this transition": {"časovnica ne more preiti v to stanje"},
+    "timesheet is read-only in its current state": {"časovnice v tem stanju ni mogoče spreminjati"},
+    "you shall not pass!.. the timesheet":  {"ne boste šli mimo!.. časovnice"},
+    "timesheet not found":                  {"časovnice ni mogoče najti"},
+
+    // Billing
+    "Could not load invoices":                  {"Računov ni bilo mogoče naložiti"},
+    "Exported %s":                              {"Izvoženo: %s"},
+    "Could not export %s: %s":                  {"%s ni bilo mogoče izvoziti: %s"},
+    "Could not credit %s: %s":                  {"Za %s ni bilo mogoče izdati dobropisa: %s"},
+    "Issued %s for %s":                         {"Izdan %s za %s"},
+    "Client (empty = any)":                     {"Stranka (prazno = katera koli)"},
+    "Role (empty = any)":                       {"Vloga (prazno = katera koli)"},
+    "User (empty = any)":                       {"Uporabnik (prazno = kateri koli)"},
+    "Hourly rate, e.g. 60.00":                  {"Urna postavka, npr. 60,00"},
+    "Valid from (empty = today)":               {"Velja od (prazno = danes)"},
+    "Valid to (empty = open)":                  {"Velja do (prazno = odprto)"},
+    "Add rate":                                 {"Dodaj postavko"},
+    "Client to invoice":                        {"Stranka za račun"},
+    "Period from YYYY-MM-DD":                   {"Obdobje od LLLL-MM-DD"},
+    "Period to YYYY-MM-DD":                     {"Obdobje do LLLL-MM-DD"},
+    "Generate invoice":                         {"Ustvari račun"},
+    "Reason for the next credit note":          {"Razlog za naslednji dobropis"},
+    "Could not add the rate: %s":               {"Postavke ni bilo mogoče dodati: %s"},
+    "Added a rate of %s/h from %s":             {"Dodana postavka %s/h od %s"},
+    "Please enter the client to invoice":       {"Vnesite stranko za račun"},
+    "Please enter the start of the period as YYYY-MM-DD": {"Vnesite začetek obdobja kot LLLL-MM-DD"},
+    "Could not generate the invoice: %s":       {"Računa ni bilo mogoče ustvariti: %s"},
+    "Issued %s over %s":                        {"Izdan %s na znesek %s"},
+    "  (credited)":                             {"  (dobropis izdan)"},
+    "Credit":                                   {"Dobropis"},
+    "invalid amount %q, at most two decimals": {"neveljaven znesek %q, največ dve decimalki"},
+    "invalid amount %q":                    {"neveljaven znesek %q"},
+    "user %d, client %d, %s":               {"uporabnik %d, stranka %d, %s"},
+    "rate for %s is in %s, but %s is billed in %s": {"postavka za %s je v %s, %s pa se obračunava v %s"},
+    "%s is not an invoice":                 {"%s ni račun"},
+    "invoice %d not found":                 {"računa %d ni mogoče najti"},
+    "invalid date %q, expected YYYY-MM-DD": {"neveljaven datum %q, pričakovan LLLL-MM-DD"},
+    "%q does not exist":                    {"%q ne obstaja"},
+    "no rate card matches":                 {"nobena postavka se ne ujema"},
+    "no approved, unbilled time for this client and period": {"za to stranko in obdobje ni odobrenega, neobračunanega časa"},
+    "invoice has already been credited":    {"za račun je dobropis že izdan"},
+
+    // Export
+    "Export time entries":                      {"Izvoz vnosov časa"},
+    "Could not export: %s":                     {"Izvoz ni uspel: %s"},
+    "Exported %d entries to %s": {
+        "%d vnos izvožen v %s",
+        "%d vnosa izvožena v %s",
+        "%d vnosi izvoženi v %s",
+        "%d vnosov izvoženih v %s",
+    },
+    "From YYYY-MM-DD":                          {"Od LLLL-MM-DD"},
+    "To YYYY-MM-DD":                            {"Do LLLL-MM-DD"},
+    "State, e.g. approved":                     {"Stanje, npr. approved"},
+    "Only this user":                           {"Samo ta uporabnik"},
+    "Only this client":                         {"Samo ta stranka"},
+    "Locale: en, en-US, de, sl":                {"Jezik zapisa: en, en-US, de, sl"},
+    "Columns, e.g. date,user,client,project,task,hours": {"Stolpci, npr. date,user,client,project,task,hours"},
+    "Export CSV":                               {"Izvozi CSV"},
+    "Export XLSX":                              {"Izvozi XLSX"},
+    "unknown column %q":                    {"neznan stolpec %q"},
+    "unknown locale %q":                    {"neznan jezik zapisa %q"},
+    "unknown export format %q, use csv or xlsx": {"neznana oblika izvoza %q, uporabite csv ali xlsx"},
+    "unknown timesheet state %q":           {"neznano stanje časovnice %q"},
+
+    // Import
+    "Import time entries":                      {"Uvoz vnosov časa"},
+    "Could not load earlier imports":           {"Prejšnjih uvozov ni bilo mogoče naložiti"},
+    "Could not read the file: %s":              {"Datoteke ni bilo mogoče prebrati: %s"},
+    "%d new, %d duplicates, %d errors - click Import to add the new ones": {"Novi: %d, podvojeni: %d, napake: %d - kliknite Uvoz, da dodate nove"},
+    "Imported %d entries as import #%d": {
+        "%d vnos uvožen kot uvoz #%d",
+        "%d vnosa uvožena kot uvoz #%d",
+        "%d vnosi uvoženi kot uvoz #%d",
+        "%d vnosov uvoženih kot uvoz #%d",
+    },
+    "Could not import: %s":                     {"Uvoz ni uspel: %s"},
+    "Could not undo import #%d: %s":            {"Uvoza #%d ni bilo mogoče razveljaviti: %s"},
+    "Removed the %d entries of import #%d": {
+        "Odstranjen %d vnos uvoza #%d",
+        "Odstranjena %d vnosa uvoza #%d",
+        "Odstranjeni %d vnosi uvoza #%d",
+        "Odstranjenih %d vnosov uvoza #%d",
+    },
+    "Path to the CSV file":                     {"Pot do datoteke CSV"},
+    "csv, toggl, clockify or harvest":          {"csv, toggl, clockify ali harvest"},
+    "For csv: date=Day, client=Customer, project=Project, hours=Time, layout=2006-01-02 - for a preset only the changes, e.g. layout=02/01/2006": {
+        "Za csv: date=Dan, client=Stranka, project=Projekt, hours=Čas, layout=2006-01-02 - za predlogo le spremembe, npr. layout=02/01/2006",
+    },
+    "Preview":                                  {"Predogled"},
+    "Line %d  %s":                              {"Vrstica %d  %s"},
+    "new":                                      {"nov"},
+    "duplicate":                                {"podvojen"},
+    "error":                                    {"napaka"},
+    "#%d  %s  %s  %s  %d entries": {
+        "#%d  %s  %s  %s  %d vnos",
+        "#%d  %s  %s  %s  %d vnosa",
+        "#%d  %s  %s  %s  %d vnosi",
+        "#%d  %s  %s  %s  %d vnosov",
+    },
+    "  (undone)":                               {"  (razveljavljeno)"},
+    "Undo":                                     {"Razveljavi"},
+    "invalid mapping %q, expected key=column": {"neveljavna preslikava %q, pričakovano ključ=stolpec"},
+    "unknown mapping key %q":               {"neznan ključ preslikave %q"},
+    "mapping needs date, client and one of hours, clock or minutes": {"preslikava potrebuje date, client in enega od hours, clock ali minutes"},
+    "unknown import preset %q, use csv, toggl, clockify or harvest": {"neznana predloga uvoza %q, uporabite csv, toggl, clockify ali harvest"},
+    "invalid hours %q":                     {"neveljavne ure %q"},
+    "invalid minutes %q":                   {"neveljavne minute %q"},
+    "invalid duration %q, expected h:mm or h:mm:ss": {"neveljavno trajanje %q, pričakovano h:mm ali h:mm:ss"},
+    "invalid duration %q":                  {"neveljavno trajanje %q"},
+    "unknown duration kind %q":             {"neznana vrsta trajanja %q"},
+    "invalid date %q, expected %s":         {"neveljaven datum %q, pričakovano %s"},
+    "the file is empty":                    {"datoteka je prazna"},
+    "the file has no %q column":            {"datoteka nima stolpca %q"},
+    "line %d":                              {"vrstica %d"},
+    "import %d not found":                  {"uvoza %d ni mogoče najti"},
+    "import can't be undone anymore":       {"uvoza ni več mogoče razveljaviti"},
+    "it was already undone":                {"že je bil razveljavljen"},
+    "some of its weeks were already submitted": {"nekateri njegovi tedni so že oddani"},
+    "no client":                            {"ni stranke"},
+    "new client":                           {"nova stranka"},
+    "%s has no project %q":                 {"%s nima projekta %q"},
+    "you shall not pass!.. the import":     {"ne boste šli mimo!.. uvoza"},
+    "you shall not pass!.. the invoices":   {"ne boste šli mimo!.. računov"},
+    "you shall not pass!.. the policy":     {"ne boste šli mimo!.. pravil"},
+    "you shall not pass!.. the roles":      {"ne boste šli mimo!.. vlog"},
+    "already logged":                       {"že vneseno"},
+
+    // Reports
+    "Could not load the report: %s":            {"Poročila ni bilo mogoče naložiti: %s"},
+    "%d entries, %s in total": {
+        "%d vnos, skupaj %s",
+        "%d vnosa, skupaj %s",
+        "%d vnosi, skupaj %s",
+        "%d vnosov, skupaj %s",
+    },
+    "%s in the week of %s":                     {"%s v tednu %s"},
+    "Billable time":                            {"Obračunljiv čas"},
+    "Non-billable time":                        {"Neobračunljiv čas"},
+    "Refresh":                                  {"Osveži"},
+    "You may not read any of the reports":      {"Nobenega poročila ne smete brati"},
+    "Hours per client per week":                {"Ure po strankah na teden"},
+    "Billable %s":                              {"Obračunljivo %s"},
+    "Non-billable %s":                          {"Neobračunljivo %s"},
+    "No time logged in this period":            {"V tem obdobju ni vnesenega časa"},
+
+    // Check access
+    "Please enter an object and an action":     {"Vnesite objekt in dejanje"},
+    "Could not check the access: %s":           {"Dostopa ni bilo mogoče preveriti: %s"},
+    "Username, e.g. Petar":                     {"Uporabniško ime, npr. Petar"},
+    "Object, e.g. report_text":                 {"Objekt, npr. report_text"},
+    "Action, read or write":                    {"Dejanje, read ali write"},
+    "Check":                                    {"Preveri"},
+    "%s %s on %s for %s":                   {"%s %s na %s za %s"},
+    "%s when %s":                           {"%s, ko velja %s"},
+    "Allowed: %s %s %s in %s":              {"Dovoljeno: %s %s %s v %s"},
+    "Denied: %s %s %s in %s":               {"Zavrnjeno: %s %s %s v %s"},
+    "Decided by: %s":                       {"Odločilo je: %s"},
+    "Decided by: no rule matches, so it is denied by default": {"Odločilo je: nobeno pravilo se ne ujema, zato je privzeto zavrnjeno"},
+    "Role: %s is in %s":                    {"Vloga: %s je v %s"},
+    "Object groups: %s is in %s":           {"Skupine objektov: %s je v %s"},
+    "Matching rule: %s":                    {"Ujemajoče pravilo: %s"},
+    "Could not explain the decision: %s":   {"Odločitve ni bilo mogoče pojasniti: %s"},
+    "user %q does not exist":               {"uporabnik %q ne obstaja"},
+    "allow":                                {"dovoli"},
+    "deny":                                 {"zavrni"},
+    "condition %q does not work":           {"pogoj %q ne deluje"},
+    "withinDays(date, today, days) expects 3 arguments, got %d": {"withinDays(date, today, days) pričakuje 3 argumente, dobil jih je %d"},
+    "withinDays(date, today, days) expects two dates and a number": {"withinDays(date, today, days) pričakuje dva datuma in število"},
+
+    // Roles
+    "Could not load the roles":                 {"Vlog ni bilo mogoče naložiti"},
+    "Role, e.g. B_minion":                      {"Vloga, npr. B_minion"},
+    "Inherits from, e.g. B_base":               {"Deduje od, npr. B_base"},
+    "Add":                                      {"Dodaj"},
+    "Remove":                                   {"Odstrani"},
+    "%s now inherits from %s":                  {"%s zdaj deduje od %s"},
+    "%s no longer inherits from %s":            {"%s ne deduje več od %s"},
+    "%s inherits from %s":                  {"%s deduje od %s"},
+    "role %q does not exist":               {"vloga %q ne obstaja"},
+    "%s already inherits from %s":          {"%s že deduje od %s"},
+    "the role does not inherit from that parent": {"vloga ne deduje od te nadrejene vloge"},
+    "please name the base role":            {"poimenujte osnovno vlogo"},
+    "there is nothing to share, it takes at least two roles with rules": {"ni česa deliti, za to sta potrebni vsaj dve vlogi s pravili"},
+    "a role can not inherit from itself, directly or through other roles": {"vloga ne more dedovati sama od sebe, ne neposredno ne prek drugih vlog"},
+
+    // Policies
+    "Condition, e.g. %s":                       {"Pogoj, npr. %s"},
+    " or ":                                     {" ali "},
+    "Could not load the policies":              {"Pravil ni bilo mogoče naložiti"},
+    "Added an allow rule for %s":               {"Dodano pravilo, ki dovoljuje %s"},
+    "Added a deny rule for %s":                 {"Dodano pravilo, ki prepoveduje %s"},
+    "Could not export the policy: %s":          {"Pravil ni bilo mogoče izvoziti: %s"},
+    "Exported %d rules to %s": {
+        "%d pravilo izvoženo v %s",
+        "%d pravili izvoženi v %s",
+        "%d pravila izvožena v %s",
+        "%d pravil izvoženih v %s",
+    },
+    "Could not read the policy: %s":            {"Pravil ni bilo mogoče prebrati: %s"},
+    "Importing would add %s and remove %s":     {"Uvoz bi dodal %s in odstranil %s"},
+    "Added %s and removed %s":                  {"Uvoz je dodal %s in odstranil %s"},
+    "%d rules": {
+        "%d pravilo",
+        "%d pravili",
+        "%d pravila",
+        "%d pravil",
+    },
+    "Removed %s":                               {"Odstranjeno: %s"},
+    "Role or user, e.g. B_minion":              {"Vloga ali uporabnik, npr. B_minion"},
+    "Object, e.g. time_entry":                  {"Objekt, npr. time_entry"},
+    "Action, e.g. edit":                        {"Dejanje, npr. edit"},
+    "Effect":                                   {"Učinek"},
+    "Allow":                                    {"Dovoli"},
+    "Deny":                                     {"Prepovej"},
+    "Add rule":                                 {"Dodaj pravilo"},
+    "CSV file, empty exports to %s":            {"Datoteka CSV, prazno izvozi v %s"},
+    "Diff":                                     {"Razlike"},
+    "%s %s: %s %s on %s":                   {"%s %s: %s %s na %s"},
+    "role":                                 {"vloga"},
+    "user":                                 {"uporabnik"},
+    "there is no role or user called %q":   {"vloge ali uporabnika z imenom %q ni"},
+    "there is no role called %q":           {"vloge z imenom %q ni"},
+    "please enter an object and an action": {"vnesite objekt in dejanje"},
+    "effect %q must be allow or deny":      {"učinek %q mora biti allow ali deny"},
+    "the rule does not exist":              {"pravilo ne obstaja"},
+    "the rule already exists":              {"pravilo že obstaja"},
+    "the rule is for %q, not for %s":       {"pravilo je za %q, ne za %s"},
+    "p rules have a subject, organization, object, action, effect and an optional condition": {"pravila p imajo subjekt, organizacijo, objekt, dejanje, učinek in neobvezen pogoj"},
+    "g rules have a subject, role and organization": {"pravila g imajo subjekt, vlogo in organizacijo"},
+    "unknown rule type %q":                 {"neznana vrsta pravila %q"},
+    "the enforcer does not store its policy in the auth tables": {"izvrševalnik ne hrani pravil v tabelah pooblastil"},
+    "%q is not a %s<id> value":             {"%q ni vrednost oblike %s<id>"},
+    "p rule %v needs a subject, domain, object, action and effect": {"pravilo p %v potrebuje subjekt, domeno, objekt, dejanje in učinek"},
+    "g rule %v needs a subject, role and domain": {"pravilo g %v potrebuje subjekt, vlogo in domeno"},
+    "project groups (g2) are set on the projects, not in the policy": {"skupine projektov (g2) se nastavijo na projektih, ne v pravilih"},
+
+    // Audit log
+    "Event, e.g. %s":                           {"Dogodek, npr. %s"},
+    "signed in":                                {"prijava"},
+    "sign-in failed":                           {"neuspešna prijava"},
+    "signed out":                               {"odjava"},
+    "denied":                                   {"zavrnjeno"},
+    "rule added":                               {"pravilo dodano"},
+    "rule removed":                             {"pravilo odstranjeno"},
+    "policy saved":                             {"pravila shranjena"},
+    "entry changed":                            {"vnos spremenjen"},
+    "entry deleted":                            {"vnos izbrisan"},
+    "entry restored":                           {"vnos obnovljen"},
+    "timesheet changed":                        {"časovnica spremenjena"},
+    "%d events, the chain could not be checked": {
+        "%d dogodek, verige ni bilo mogoče preveriti",
+        "%d dogodka, verige ni bilo mogoče preveriti",
+        "%d dogodki, verige ni bilo mogoče preveriti",
+        "%d dogodkov, verige ni bilo mogoče preveriti",
+    },
+    "%d events - the log was changed at event %d or later!": {
+        "%d dogodek - sled je bila spremenjena pri dogodku %d ali pozneje!",
+        "%d dogodka - sled je bila spremenjena pri dogodku %d ali pozneje!",
+        "%d dogodki - sled je bila spremenjena pri dogodku %d ali pozneje!",
+        "%d dogodkov - sled je bila spremenjena pri dogodku %d ali pozneje!",
+    },
+    "%d events, the chain is intact": {
+        "%d dogodek, veriga je nedotaknjena",
+        "%d dogodka, veriga je nedotaknjena",
+        "%d dogodki, veriga je nedotaknjena",
+        "%d dogodkov, veriga je nedotaknjena",
+    },
+    "User":                                     {"Uporabnik"},
+    "Filter":                                   {"Filtriraj"},
+    "date %q is not YYYY-MM-DD":            {"datum %q ni v obliki LLLL-MM-DD"},
+
+    // Notifications and budgets
+    "Could not load the notifications":         {"Obvestil ni bilo mogoče naložiti"},
+    "%d budget alerts": {
+        "%d opozorilo o proračunu",
+        "%d opozorili o proračunu",
+        "%d opozorila o proračunu",
+        "%d opozoril o proračunu",
+    },
+    "%s  %s / %s crossed %d%% of its budget (%d%% used)": {"%s  %s / %s je presegel %d %% proračuna (porabljeno %d %%)"},
+    "%s / %s: %s of %s used (%d%%), %s pending": {"%s / %s: porabljeno %s od %s (%d %%), %s v čakanju"},
+    "%s at %d%%":                               {"%s pri %d %%"},
+    "Budget alert: %s":                         {"Opozorilo o proračunu: %s"},
+    "no budget":                            {"brez proračuna"},
+    "project is over its budget":           {"projekt je presegel proračun"},
+    "project is not running on that date":  {"projekt na ta dan ne teče"},
+    "you shall not pass!.. the project":    {"ne boste šli mimo!.. projekta"},
+    "task not found":                       {"naloge ni mogoče najti"},
+    "project %d not found":                 {"projekta %d ni mogoče najti"},
+    "unknown budget kind %q, use %s or %s": {"neznana vrsta proračuna %q, uporabite %s ali %s"},
+    "the project ends before it starts":    {"projekt se konča, preden se začne"},
+    "the project needs a name":             {"projekt potrebuje ime"},
+    "project group %q":                     {"skupina projektov %q"},
+    "the task needs a name":                {"naloga potrebuje ime"},
+
+    // Settings
+    "Could not save the setting, it only holds until you sign out: %s": {"Nastavitve ni bilo mogoče shraniti, velja le do odjave: %s"},
+    "Saved":                                    {"Shranjeno"},
+    "Light":                                    {"Svetla"},
+    "Dark":                                     {"Temna"},
+    "High contrast":                            {"Visok kontrast"},
+    "Theme":                                    {"Tema"},
+    "Language":                                 {"Jezik"},
+}
@@ -0,0 +1,229 @@
+// Package i18n translates the app's messages and writes dates, durations and amounts the way a language does.
+//
+// Messages are looked up by their English text, so a call site reads like the message it shows. Plurals are
+// looked up by their English plural and pick the form by the language's rules:
+//
+//     tr := i18n.NewPrinter(i18n.Slovenian)
+//     tr.Sprintf("Logged %s for %s", tr.Duration(90), "ACME")    // "Vneseno 1,50 h za ACME"
+//     tr.Plural(3, "%d budget alert", "%d budget alerts")        // "3 opozorila o proračunu"
+//
+// A message without a translation is shown in English.
+package i18n
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+
+// Tags of the languages the app speaks, as they are stored in the user settings
+const (
+    English     = "en"
+    Slovenian   = "sl"
+)
+
+// Languages lists the tags in the order a settings screen offers them
+var Languages = []string{English, Slovenian}
+
+// language is how one language writes messages and values
+type language struct {
+    name            string                  // in the language itself
+    messages        map[string][]string     // English text -> translation, one entry per plural form for plurals
+    pluralForms     int
+    plural          func(int) int           // index of the plural form for a count
+    dateLayout      string
+    shortDateLayout string                  // day and month, for chart axes
+    dateTimeLayout  string
+    decimalSep      string
+    groupSep        string
+    hourUnit        string                  // appended to durations in hours
+}
+
+var languages = map[string]*language{
+    English: {
+        name:            "English",
+        pluralForms:     2,
+        plural:          englishPlural,
+        dateLayout:      "2006-01-02",
+        shortDateLayout: "02 Jan",
+        dateTimeLayout:  "2006-01-02 15:04",
+        decimalSep:      ".",
+        groupSep:        ",",
+        hourUnit:        "h",
+    },
+    Slovenian: {
+        name:            "Slovenščina",
+        messages:        slovenianMessages,
+        pluralForms:     4,
+        plural:          slovenianPlural,
+        dateLayout:      "2. 1. 2006",
+        shortDateLayout: "2. 1.",
+        dateTimeLayout:  "2. 1. 2006 15:04",
+        decimalSep:      ",",
+        groupSep:        ".",
+        hourUnit:        " h",
+    },
+}
+
+
+// englishPlural has one form for 1 and another for everything else
+func englishPlural(inCount int) int {
+    if inCount == 1 || inCount == -1 {
+        return 0
+    }
+    return 1
+}
+
+// slovenianPlural has forms for counts ending in 01, 02, 03 and 04, and the rest, e.g. 1 ura, 2 uri, 3 ure, 5 ur
+func slovenianPlural(inCount int) int {
+    if inCount < 0 {
+        inCount = -inCount
+    }
+    switch inCount % 100 {
+    case 1:
+        return 0
+    case 2:
+        return 1
+    case 3, 4:
+        return 2
+    default:
+        return 3
+    }
+}
+
+
+// Printer translates into one language. It holds no state of its own, windows on different go routines may share one.
+type Printer struct {
+    tag             string
+    lang            *language
+}
+
+// NewPrinter returns the printer of the language, English for a tag it doesn't know
+func NewPrinter(inTag string) *Printer {
+    lang, ok := languages[inTag]
+    if !ok {
+        inTag, lang = English, languages[English]
+    }
+    return &Printer{tag: inTag, lang: lang}
+}
+
+// Tag is the language the printer writes, e.g. "sl"
+func (p *Printer) Tag() string {
+    return p.tag
+}
+
+// Text translates a message
+func (p *Printer) Text(inMessage string) string {
+    if forms, ok := p.lang.messages[inMessage]; ok && len(forms) > 0 {
+        return forms[0]
+    }
+    return inMessage
+}
+
+// Sprintf translates the format, then fills it in like fmt.Sprintf
+func (p *Printer) Sprintf(inFormat string, inArgs ...any) string {
+    return fmt.Sprintf(p.Text(inFormat), inArgs...)
+}
+
+// Plural picks the form for inCount and fills it in. The count is the only argument when no others are given.
+func (p *Printer) Plural(inCount int, inOne string, inOther string, inArgs ...any) string {
+    if len(inArgs) == 0 {
+        inArgs = []any{inCount}
+    }
+
+    format := inOther
+    if forms, ok := p.lang.messages[inOther]; ok && len(forms) == p.lang.pluralForms {
+        format = forms[p.lang.plural(inCount)]
+    } else if englishPlural(inCount) == 0 {
+        format = inOne
+    }
+    return fmt.Sprintf(format, inArgs...)
+}
+
+// Date writes the day, e.g. "2030-01-08" or "8. 1. 2030"
+func (p *Printer) Date(inDate time.Time) string {
+    return inDate.Format(p.lang.dateLayout)
+}
+
+// ShortDate writes the day without the year, e.g. "08 Jan" or "8. 1."
+func (p *Printer) ShortDate(inDate time.Time) string {
+    return inDate.Format(p.lang.shortDateLayout)
+}
+
+// DateTime writes the day and the time to the minute
+func (p *Printer) DateTime(inTime time.Time) string {
+    return inTime.Format(p.lang.dateTimeLayout)
+}
+
+// Duration writes minutes as hours with two decimals, e.g. "1.50h" or "1,50 h"
+func (p *Printer) Duration(inMinutes int) string {
+    return p.Number(float64(inMinutes)/60, 2) + p.lang.hourUnit
+}
+
+// Money writes an amount in cents with its currency, e.g. "1,234.50 EUR" or "1.234,50 EUR"
+func (p *Printer) Money(inCents int64, inCurrency string) string {
+    sign := ""
+    if inCents < 0 {
+        sign, inCents = "-", -inCents
+    }
+    amount := sign + p.group(inCents/100) + p.lang.decimalSep + fmt.Sprintf("%02d", inCents%100)
+    if len(inCurrency) == 0 {
+        return amount
+    }
+    return amount + " " + inCurrency
+}
+
+// Number writes the number with the language's decimal and thousands separators
+func (p *Printer) Number(inNumber float64, inDecimals int) string {
+    text          := strconv.FormatFloat(inNumber, 'f', inDecimals, 64)
+    sign          := ""
+    if strings.HasPrefix(text, "-") {
+        sign, text = "-", text[1:]
+    }
+    whole, fraction, _ := strings.Cut(text, ".")
+    number, _          := strconv.ParseInt(whole, 10, 64)
+
+    if len(fraction) == 0 {
+        return sign + p.group(number)
+    }
+    return sign + p.group(number) + p.lang.decimalSep + fraction
+}
+
+// group puts the thousands separator into a whole number that is not negative
+func (p *Printer) group(inNumber int64) string {
+    digits := strconv.FormatInt(inNumber, 10)
+    for i := len(digits) - 3; i > 0; i -= 3 {
+        digits = digits[:i] + p.lang.groupSep + digits[i:]
+    }
+    return digits
+}
+
+
+// Name is the language's name in the language itself, e.g. "Slovenščina", or the tag for one it doesn't know
+func Name(inTag string) string {
+    if lang, ok := languages[inTag]; ok {
+        return lang.name
+    }
+    return inTag
+}
+
+// Lookup returns the translation of a message or a plural, one entry per plural form. English has none, it is
+// what the messages are written in.
+func Lookup(inTag string, inMessage string) ([]string, bool) {
+    lang, ok := languages[inTag]
+    if !ok {
+        return nil, false
+    }
+    forms, ok := lang.messages[inMessage]
+    return forms, ok
+}
+
+// PluralForms is how many forms a plural has in the language
+func PluralForms(inTag string) int {
+    if lang, ok := languages[inTag]; ok {
+        return lang.pluralForms
+    }
+    return 0
+}
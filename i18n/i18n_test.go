@@ -0,0 +1,80 @@
+package i18n
+
+import (
+    "testing"
+    "time"
+)
+
+func Test_Plural(t *testing.T) {
+    tests := []struct {
+        name    string
+        tag     string
+        count   int
+        want    string
+    }{
+        {"english one",         English,   1,   "1 budget alert"},
+        {"english zero",        English,   0,   "0 budget alerts"},
+        {"english many",        English,   5,   "5 budget alerts"},
+        {"slovenian one",       Slovenian, 1,   "1 opozorilo o proračunu"},
+        {"slovenian two",       Slovenian, 2,   "2 opozorili o proračunu"},
+        {"slovenian four",      Slovenian, 4,   "4 opozorila o proračunu"},
+        {"slovenian five",      Slovenian, 5,   "5 opozoril o proračunu"},
+        {"slovenian 101",       Slovenian, 101, "101 opozorilo o proračunu"},
+        {"slovenian 111",       Slovenian, 111, "111 opozoril o proračunu"},
+        {"unknown language",    "xx",      1,   "1 budget alert"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := NewPrinter(tt.tag).Plural(tt.count, "%d budget alert", "%d budget alerts"); got != tt.want {
+                t.Errorf("Plural(%d) = %q, want %q", tt.count, got, tt.want)
+            }
+        })
+    }
+}
+
+func Test_Text(t *testing.T) {
+    sl := NewPrinter(Slovenian)
+
+    if got := sl.Sprintf("Budget alert: %s", "Website"); got != "Opozorilo o proračunu: Website" {
+        t.Errorf("Sprintf() = %q", got)
+    }
+    // Messages without a translation fall back to English
+    if got := sl.Text("Not in any catalog"); got != "Not in any catalog" {
+        t.Errorf("Text() = %q", got)
+    }
+    if got := NewPrinter("xx").Tag(); got != English {
+        t.Errorf("Tag() of an unknown language = %q, want %q", got, English)
+    }
+}
+
+func Test_formatting(t *testing.T) {
+    day := time.Date(2030, 1, 8, 9, 5, 0, 0, time.UTC)
+    en  := NewPrinter(English)
+    sl  := NewPrinter(Slovenian)
+
+    tests := []struct {
+        name    string
+        got     string
+        want    string
+    }{
+        {"english date",            en.Date(day),                       "2030-01-08"},
+        {"slovenian date",          sl.Date(day),                       "8. 1. 2030"},
+        {"english short date",      en.ShortDate(day),                  "08 Jan"},
+        {"slovenian short date",    sl.ShortDate(day),                  "8. 1."},
+        {"slovenian date time",     sl.DateTime(day),                   "8. 1. 2030 09:05"},
+        {"english duration",        en.Duration(90),                    "1.50h"},
+        {"slovenian duration",      sl.Duration(90),                    "1,50 h"},
+        {"english money",           en.Money(123450, "EUR"),            "1,234.50 EUR"},
+        {"slovenian money",         sl.Money(123450, "EUR"),            "1.234,50 EUR"},
+        {"negative money",          sl.Money(-5, ""),                   "-0,05"},
+        {"millions",                en.Number(1234567.891, 1),          "1,234,567.9"},
+        {"whole number",            sl.Number(1000, 0),                 "1.000"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if tt.got != tt.want {
+                t.Errorf("got %q, want %q", tt.got, tt.want)
+            }
+        })
+    }
+}
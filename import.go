@@ -0,0 +1,661 @@
+package main
+
+import (
+    "bufio"
+    "database/sql"
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+)
+
+
+// Import row statuses, only "new" rows are written
+const (
+    importNew       = "new"
+    importDuplicate = "duplicate"
+    importError     = "error"
+)
+
+// Import duration kinds
+const (
+    durationHours   = "hours"       // decimal hours, 1.5
+    durationClock   = "clock"       // h:mm or h:mm:ss, 1:30:00
+    durationMinutes = "minutes"     // whole minutes, 90
+)
+
+// importMapping tells which CSV columns hold the date, client, project and duration of an entry, and how its
+// dates are written. The project column is optional - a file without it, or a row with an empty one, logs on the
+// client only.
+type importMapping struct {
+    DateColumn      string
+    ClientColumn    string
+    ProjectColumn   string
+    DurationColumn  string
+    DurationKind    string
+    DateLayout      string
+}
+
+// Presets for the CSV exports of other trackers. Clockify writes dates the way the workspace is set up, the preset
+// takes its default month/day and a day/month workspace sets layout=02/01/2006 on top.
+var importPresets = map[string]importMapping{
+    "toggl": {
+        DateColumn:     "Start date",
+        ClientColumn:   "Client",
+        ProjectColumn:  "Project",
+        DurationColumn: "Duration",
+        DurationKind:   durationClock,
+        DateLayout:     "2006-01-02",
+    },
+    "clockify": {
+        DateColumn:     "Start Date",
+        ClientColumn:   "Client",
+        ProjectColumn:  "Project",
+        DurationColumn: "Duration (decimal)",
+        DurationKind:   durationHours,
+        DateLayout:     "01/02/2006",
+    },
+    "harvest": {
+        DateColumn:     "Date",
+        ClientColumn:   "Client",
+        ProjectColumn:  "Project",
+        DurationColumn: "Hours",
+        DurationKind:   durationHours,
+        DateLayout:     "2006-01-02",
+    },
+}
+
+var (
+    errImportDenied      = errors.New("you shall not pass!.. the import")
+    errImportNotUndoable = errors.New("import can't be undone anymore")
+)
+
+// ImportRow is one line of the file as it would be imported
+type ImportRow struct {
+    Line        int
+    EntryDate   time.Time
+    ClientRaw   string
+    ClientName  string
+    NewClient   bool
+    ProjectID   int         // zero for time on the client only
+    ProjectName string
+    Minutes     int
+    Status      string
+    Note        error       // why the row is skipped, or what importing it adds
+}
+
+// ImportBatch is one committed import, kept so it can be undone
+type ImportBatch struct {
+    ImportBatchID   int
+    Source          string
+    FileName        string
+    ImportedAt      time.Time
+    EntryCount      int
+    Undone          bool
+}
+
+
+// parseImportMapping reads a mapping like "date=Day, client=Customer, hours=Time, layout=02.01.2006" on top of
+// inBase. The duration key names the kind: hours, clock or minutes.
+func parseImportMapping(inBase importMapping, inText string) (importMapping, error) {
+    mapping := inBase
+
+    for _, pair := range strings.Split(inText, ",") {
+        key, value, found := strings.Cut(pair, "=")
+        key, value         = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+        if !found || value == "" {
+            return mapping, newError("invalid mapping %q, expected key=column", strings.TrimSpace(pair))
+        }
+
+        switch key {
+        case "date":
+            mapping.DateColumn = value
+        case "client":
+            mapping.ClientColumn = value
+        case "project":
+            mapping.ProjectColumn = value
+        case durationHours, durationClock, durationMinutes:
+            mapping.DurationColumn = value
+            mapping.DurationKind   = key
+        case "layout":
+            mapping.DateLayout = value
+        default:
+            return mapping, newError("unknown mapping key %q", key)
+        }
+    }
+
+    if mapping.DateColumn == "" || mapping.ClientColumn == "" || mapping.DurationColumn == "" {
+        return mapping, newError("mapping needs date, client and one of hours, clock or minutes")
+    }
+
+    return mapping, nil
+}
+
+// importSource is the preset name as stored with an import, an empty preset is the generic "csv"
+func importSource(inPreset string) string {
+    preset := strings.ToLower(strings.TrimSpace(inPreset))
+    if preset == "" {
+        return "csv"
+    }
+
+    return preset
+}
+
+// getImportMapping parses the generic mapping when the preset is "csv". A preset takes the mapping text as changes
+// to its columns or date layout, e.g. "layout=02/01/2006".
+func getImportMapping(inPreset string, inMappingText string) (importMapping, error) {
+    preset := importSource(inPreset)
+    if preset == "csv" {
+        return parseImportMapping(importMapping{DateLayout: "2006-01-02"}, inMappingText)
+    }
+
+    mapping, ok := importPresets[preset]
+    if !ok {
+        return mapping, newError("unknown import preset %q, use csv, toggl, clockify or harvest", inPreset)
+    }
+    if strings.TrimSpace(inMappingText) == "" {
+        return mapping, nil
+    }
+
+    return parseImportMapping(mapping, inMappingText)
+}
+
+// parseImportDuration reads a duration cell into minutes
+func parseImportDuration(inText string, inKind string) (int, error) {
+    inText = strings.TrimSpace(inText)
+
+    switch inKind {
+    case durationHours:
+        hours, err := strconv.ParseFloat(strings.Replace(inText, ",", ".", 1), 64)
+        if err != nil {
+            return 0, newError("invalid hours %q", inText)
+        }
+        return int(hours*60 + 0.5), nil
+    case durationMinutes:
+        minutes, err := strconv.Atoi(inText)
+        if err != nil {
+            return 0, newError("invalid minutes %q", inText)
+        }
+        return minutes, nil
+    case durationClock:
+        parts := strings.Split(inText, ":")
+        if len(parts) < 2 || len(parts) > 3 {
+            return 0, newError("invalid duration %q, expected h:mm or h:mm:ss", inText)
+        }
+        var values [3]int
+        for i, part := range parts {
+            value, err := strconv.Atoi(part)
+            if err != nil || value < 0 {
+                return 0, newError("invalid duration %q", inText)
+            }
+            values[i] = value
+        }
+        // Seconds are rounded to the nearest minute
+        return values[0]*60 + values[1] + (values[2]+30)/60, nil
+    default:
+        return 0, newError("unknown duration kind %q", inKind)
+    }
+}
+
+// parseImportDate reads a date cell in the mapping's one layout, so 03/04 is never a guess between March and April
+func parseImportDate(inText string, inLayout string) (time.Time, error) {
+    date, err := time.Parse(inLayout, strings.TrimSpace(inText))
+    if err != nil {
+        return time.Time{}, newError("invalid date %q, expected %s", inText, inLayout)
+    }
+
+    return date, nil
+}
+
+
+// readImportCSV reads the file into header-keyed records. The delimiter is sniffed from the header line.
+func readImportCSV(inReader io.Reader) ([]map[string]string, error) {
+    buffered := bufio.NewReader(inReader)
+
+    headerLine, err := buffered.Peek(4096)
+    if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+        return nil, err
+    }
+    firstLine, _, _ := strings.Cut(string(headerLine), "\n")
+
+    reader                := csv.NewReader(buffered)
+    reader.Comma           = sniffDelimiter(firstLine)
+    reader.FieldsPerRecord = -1
+
+    records, err := reader.ReadAll()
+    if err != nil {
+        return nil, err
+    }
+    if len(records) == 0 {
+        return nil, newError("the file is empty")
+    }
+
+    header := records[0]
+    // Excel likes to start UTF-8 files with a byte order mark
+    header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+    var rows []map[string]string
+    for _, record := range records[1:] {
+        row := map[string]string{}
+        for i, value := range record {
+            if i < len(header) {
+                row[strings.ToLower(strings.TrimSpace(header[i]))] = value
+            }
+        }
+        rows = append(rows, row)
+    }
+
+    return rows, nil
+}
+
+func sniffDelimiter(inLine string) rune {
+    best, bestCount := ',', 0
+
+    for _, delimiter := range []rune{',', ';', '\t'} {
+        if count := strings.Count(inLine, string(delimiter)); count > bestCount {
+            best, bestCount = delimiter, count
+        }
+    }
+
+    return best
+}
+
+
+// normalizeClientName drops case, punctuation and company suffixes, so "ACME, Inc." matches "Acme"
+func normalizeClientName(inName string) string {
+    var sb strings.Builder
+
+    for _, r := range strings.ToLower(inName) {
+        if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127 || r == ' ' {
+            sb.WriteRune(r)
+        } else {
+            sb.WriteRune(' ')
+        }
+    }
+
+    words := strings.Fields(sb.String())
+    for len(words) > 1 {
+        switch words[len(words)-1] {
+        case "inc", "ltd", "llc", "gmbh", "ag", "sa", "bv", "doo", "d", "o", "co", "corp", "company":
+            words = words[:len(words)-1]
+            continue
+        }
+        break
+    }
+
+    return strings.Join(words, " ")
+}
+
+// clientMatcher matches imported client names against the client registry
+type clientMatcher struct {
+    byNormalized map[string]string
+    added        map[string]bool
+}
+
+// newClientMatcher knows the clients of the organization, a client of another one is new here
+func newClientMatcher(inDB dbRunner, inOrganizationID int) (*clientMatcher, error) {
+    rows, err := inDB.Query("SELECT client_name FROM client_dim WHERE organization_id = ?", inOrganizationID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    matcher := &clientMatcher{byNormalized: map[string]string{}, added: map[string]bool{}}
+    for rows.Next() {
+        var name string
+        if err := rows.Scan(&name); err != nil {
+            return nil, err
+        }
+        matcher.byNormalized[normalizeClientName(name)] = name
+    }
+
+    return matcher, rows.Err()
+}
+
+// match returns the registry name of the client, or the trimmed raw name and false for a client we don't know yet.
+// A new client is remembered, so "Foo" and "Foo Ltd" further down the file end up as one client.
+func (m *clientMatcher) match(inRaw string) (string, bool) {
+    normalized := normalizeClientName(inRaw)
+    if name, ok := m.byNormalized[normalized]; ok {
+        return name, !m.added[normalized]
+    }
+
+    m.byNormalized[normalized] = strings.TrimSpace(inRaw)
+    m.added[normalized]        = true
+
+    return m.byNormalized[normalized], false
+}
+
+
+// checkImportAllowed tells if the user may log time in the enforcer's organization at all, an import is one big
+// "write" on time_entry
+func checkImportAllowed(inEnforcer *OrgEnforcer, inUserID int) error {
+    canWrite, err := inEnforcer.Enforce(fmt.Sprintf("u%d", inUserID), timeEntryObject, "write")
+    if err != nil {
+        return err
+    }
+    if !canWrite {
+        return errImportDenied
+    }
+
+    return nil
+}
+
+// findImportProject looks the project up by name among the client's projects in the organization
+func findImportProject(inDB dbRunner, inOrganizationID int, inClientName string, inProjectName string) (Project, error) {
+    projects, err := queryProjects(inDB, "cd.organization_id = ? AND cd.client_name = ? AND pr.project_name = ? COLLATE NOCASE",
+        inOrganizationID, inClientName, inProjectName)
+    if err != nil {
+        return Project{}, err
+    }
+    if len(projects) == 0 {
+        return Project{}, newError("%s has no project %q", inClientName, inProjectName)
+    }
+
+    return projects[0], nil
+}
+
+// previewImport is the dry run - it parses the file, matches clients and projects, flags what is already logged and
+// runs the project checks of a logged entry, without writing anything. The rows go into the enforcer's organization.
+func previewImport(inDB dbRunner, inEnforcer *OrgEnforcer, inUserID int, inReader io.Reader, inMapping importMapping) ([]ImportRow, error) {
+    if err := checkImportAllowed(inEnforcer, inUserID); err != nil {
+        return nil, err
+    }
+
+    records, err := readImportCSV(inReader)
+    if err != nil {
+        return nil, err
+    }
+    for _, column := range []string{inMapping.DateColumn, inMapping.ClientColumn, inMapping.DurationColumn} {
+        if len(records) > 0 {
+            if _, ok := records[0][strings.ToLower(column)]; !ok {
+                return nil, newError("the file has no %q column", column)
+            }
+        }
+    }
+
+    matcher, err := newClientMatcher(inDB, inEnforcer.OrganizationID)
+    if err != nil {
+        return nil, err
+    }
+
+    // Minutes the new rows above put on each project, they count against its budget too
+    planned := map[int]int{}
+    var rows []ImportRow
+
+    for i, record := range records {
+        // Line 1 is the header
+        row := ImportRow{Line: i + 2, ClientRaw: record[strings.ToLower(inMapping.ClientColumn)], Status: importNew}
+        rows = append(rows, row)
+        last := &rows[len(rows)-1]
+
+        last.EntryDate, err = parseImportDate(record[strings.ToLower(inMapping.DateColumn)], inMapping.DateLayout)
+        if err != nil {
+            last.Status, last.Note = importError, err
+            continue
+        }
+        last.Minutes, err = parseImportDuration(record[strings.ToLower(inMapping.DurationColumn)], inMapping.DurationKind)
+        if err == nil {
+            err = validMinutes(last.Minutes, record[strings.ToLower(inMapping.DurationColumn)])
+        }
+        if err != nil {
+            last.Status, last.Note = importError, err
+            continue
+        }
+        if strings.TrimSpace(last.ClientRaw) == "" {
+            last.Status, last.Note = importError, newError("no client")
+            continue
+        }
+
+        var known bool
+        last.ClientName, known = matcher.match(last.ClientRaw)
+        last.NewClient         = !known
+        if last.NewClient {
+            last.Note = newError("new client")
+        }
+
+        // Only what is already logged is a duplicate, the same line twice in a file is time logged twice
+        var existing int
+        err = inDB.QueryRow(`
+SELECT
+    COUNT(*)
+FROM
+    time_entry                  AS te
+    JOIN timesheet              AS ts
+        ON ts.timesheet_id = te.timesheet_id
+WHERE
+        ts.organization_id = ?
+    AND te.user_id = ?
+    AND te.entry_date = ?
+    AND te.client_name = ? COLLATE NOCASE
+    AND te.minutes_spent = ?
+        `, inEnforcer.OrganizationID, inUserID, dateKey(last.EntryDate), last.ClientName, last.Minutes).Scan(&existing)
+        if err != nil {
+            return nil, err
+        }
+        if existing > 0 {
+            last.Status, last.Note = importDuplicate, newError("already logged")
+            continue
+        }
+
+        // Only weeks that are still editable can take new entries
+        var state string
+        err = inDB.QueryRow("SELECT state FROM timesheet WHERE organization_id = ? AND user_id = ? AND week_start = ?",
+            inEnforcer.OrganizationID, inUserID, dateKey(weekStart(last.EntryDate))).Scan(&state)
+        if err != nil && !errors.Is(err, sql.ErrNoRows) {
+            return nil, err
+        }
+        if state != "" && !(Timesheet{State: state}).Editable() {
+            last.Status, last.Note = importError, newError("the week is %s", catalogText(state))
+            continue
+        }
+
+        // Time on a project goes through the same checks as time logged on one of its tasks
+        projectName := strings.TrimSpace(record[strings.ToLower(inMapping.ProjectColumn)])
+        if inMapping.ProjectColumn == "" || projectName == "" {
+            continue
+        }
+        project, err := findImportProject(inDB, inEnforcer.OrganizationID, last.ClientName, projectName)
+        if err == nil {
+            err = checkProjectTimeEntry(inDB, inEnforcer, inUserID, project, last.EntryDate, planned[project.ProjectID]+last.Minutes)
+        }
+        if err != nil {
+            last.Status, last.Note = importError, err
+            continue
+        }
+        last.ProjectID, last.ProjectName = project.ProjectID, project.ProjectName
+        planned[project.ProjectID]      += last.Minutes
+    }
+
+    return rows, nil
+}
+
+// commitImport writes all "new" rows of a preview into the enforcer's organization in one transaction and records
+// the batch for undo. Rows on a project are checked again, with the rows before them already in.
+func commitImport(inDB *sql.DB, inEnforcer *OrgEnforcer, inUserID int, inSource string, inFileName string, inRows []ImportRow) (ImportBatch, error) {
+    if err := checkImportAllowed(inEnforcer, inUserID); err != nil {
+        return ImportBatch{}, err
+    }
+
+    tx, err := inDB.Begin()
+    if err != nil {
+        return ImportBatch{}, err
+    }
+    defer tx.Rollback()
+
+    importedAt  := time.Now().UTC().Truncate(time.Second)
+    result, err := tx.Exec("INSERT INTO import_batch (organization_id, user_id, source, file_name, imported_at) VALUES (?, ?, ?, ?, ?)",
+        inEnforcer.OrganizationID, inUserID, inSource, inFileName, importedAt.Format("2006-01-02 15:04:05"))
+    if err != nil {
+        return ImportBatch{}, err
+    }
+    batchID, _ := result.LastInsertId()
+    batch      := ImportBatch{ImportBatchID: int(batchID), Source: inSource, FileName: inFileName, ImportedAt: importedAt}
+
+    for _, row := range inRows {
+        if row.Status != importNew {
+            continue
+        }
+        if row.NewClient {
+            if _, err := ensureClient(tx, inEnforcer.OrganizationID, row.ClientName); err != nil {
+                return ImportBatch{}, err
+            }
+        }
+
+        var project Project
+        if row.ProjectID != 0 {
+            project, err = getProject(tx, row.ProjectID)
+            if err == nil {
+                err = checkProjectTimeEntry(tx, inEnforcer, inUserID, project, row.EntryDate, row.Minutes)
+            }
+            if err != nil {
+                return ImportBatch{}, fmt.Errorf("%w: %w", newError("line %d", row.Line), err)
+            }
+        }
+
+        entry, err := insertTimeEntry(tx, inEnforcer.OrganizationID, TimeEntry{UserID: inUserID, ClientName: row.ClientName,
+            ProjectID: row.ProjectID, EntryDate: row.EntryDate, Minutes: row.Minutes})
+        if err != nil {
+            return ImportBatch{}, fmt.Errorf("%w: %w", newError("line %d", row.Line), err)
+        }
+        if row.ProjectID != 0 {
+            updateBudgetAlerts(tx, project)
+        }
+        if _, err := tx.Exec("INSERT INTO import_batch_entry (import_batch_id, time_entry_id) VALUES (?, ?)", batchID, entry.TimeEntryID); err != nil {
+            return ImportBatch{}, err
+        }
+        batch.EntryCount++
+    }
+
+    return batch, tx.Commit()
+}
+
+// previewImportFile is previewImport on a file, with the preset or generic mapping picked by name
+func previewImportFile(inDB *sql.DB, inEnforcer *OrgEnforcer, inUserID int, inPath string, inPreset string, inMappingText string) ([]ImportRow, error) {
+    mapping, err := getImportMapping(inPreset, inMappingText)
+    if err != nil {
+        return nil, err
+    }
+
+    file, err := os.Open(inPath)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    return previewImport(inDB, inEnforcer, inUserID, file, mapping)
+}
+
+// countImportRows counts the rows of a preview per status
+func countImportRows(inRows []ImportRow) map[string]int {
+    counts := map[string]int{}
+    for _, row := range inRows {
+        counts[row.Status]++
+    }
+
+    return counts
+}
+
+// undoImport removes every entry of the user's import into the organization, as long as none of its weeks moved on
+// from draft or rejected
+func undoImport(inDB *sql.DB, inOrganizationID int, inUserID int, inBatchID int) error {
+    tx, err := inDB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var undone bool
+    err = tx.QueryRow("SELECT undone FROM import_batch WHERE import_batch_id = ? AND organization_id = ? AND user_id = ?", inBatchID, inOrganizationID, inUserID).Scan(&undone)
+    if errors.Is(err, sql.ErrNoRows) {
+        return newError("import %d not found", inBatchID)
+    }
+    if err != nil {
+        return err
+    }
+    if undone {
+        return fmt.Errorf("%w: %w", errImportNotUndoable, newError("it was already undone"))
+    }
+
+    var lockedWeeks int
+    err = tx.QueryRow(`
+SELECT
+    COUNT(*)
+FROM
+    import_batch_entry          AS ibe
+    JOIN time_entry             AS te
+        ON te.time_entry_id = ibe.time_entry_id
+    JOIN timesheet              AS ts
+        ON ts.timesheet_id = te.timesheet_id
+WHERE
+        ibe.import_batch_id = ?
+    AND ts.state NOT IN (?, ?)
+    `, inBatchID, timesheetDraft, timesheetRejected).Scan(&lockedWeeks)
+    if err != nil {
+        return err
+    }
+    if lockedWeeks > 0 {
+        return fmt.Errorf("%w: %w", errImportNotUndoable, newError("some of its weeks were already submitted"))
+    }
+
+    // The history of each entry ends with its delete, like any other delete
+    entries, err := queryTimeEntries(tx, "time_entry_id IN (SELECT time_entry_id FROM import_batch_entry WHERE import_batch_id = ?)", inBatchID)
+    if err != nil {
+        return err
+    }
+    for _, entry := range entries {
+        if _, err := recordTimeEntryVersion(tx, inUserID, entryDeleted, entry); err != nil {
+            return err
+        }
+    }
+    if _, err := tx.Exec("DELETE FROM time_entry WHERE time_entry_id IN (SELECT time_entry_id FROM import_batch_entry WHERE import_batch_id = ?)", inBatchID); err != nil {
+        return err
+    }
+    if _, err := tx.Exec("UPDATE import_batch SET undone = 1 WHERE import_batch_id = ?", inBatchID); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+func listImportBatches(inDB *sql.DB, inOrganizationID int, inUserID int) ([]ImportBatch, error) {
+    rows, err := inDB.Query(`
+SELECT
+      ib.import_batch_id
+    , ib.source
+    , ib.file_name
+    , ib.imported_at
+    , COUNT(ibe.time_entry_id)
+    , ib.undone
+FROM
+    import_batch                    AS ib
+    LEFT JOIN import_batch_entry    AS ibe
+        ON ibe.import_batch_id = ib.import_batch_id
+WHERE
+        ib.organization_id = ?
+    AND ib.user_id = ?
+GROUP BY
+    ib.import_batch_id
+ORDER BY
+    ib.import_batch_id DESC
+    `, inOrganizationID, inUserID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var batches []ImportBatch
+    for rows.Next() {
+        var batch ImportBatch
+        if err := rows.Scan(&batch.ImportBatchID, &batch.Source, &batch.FileName, &batch.ImportedAt, &batch.EntryCount, &batch.Undone); err != nil {
+            return nil, err
+        }
+        batches = append(batches, batch)
+    }
+
+    return batches, rows.Err()
+}
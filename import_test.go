@@ -0,0 +1,207 @@
+package main
+
+import (
+    "errors"
+    "strings"
+    "testing"
+    "time"
+)
+
+func Test_parseImportDuration(t *testing.T) {
+    tests := []struct {
+        text    string
+        kind    string
+        want    int
+        wantErr bool
+    }{
+        {"1.5",      durationHours,   90,  false},
+        {"0,25",     durationHours,   15,  false},
+        {"1:30",     durationClock,   90,  false},
+        {"01:29:45", durationClock,   90,  false},
+        {"1:xx",     durationClock,   0,   true},
+        {"45",       durationMinutes, 45,  false},
+        {"45",       "weeks",         0,   true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.text+" "+tt.kind, func(t *testing.T) {
+            got, err := parseImportDuration(tt.text, tt.kind)
+            if (err != nil) != tt.wantErr || got != tt.want {
+                t.Errorf("parseImportDuration() = %v, %v, want %v, wantErr %v", got, err, tt.want, tt.wantErr)
+            }
+        })
+    }
+}
+
+func Test_normalizeClientName(t *testing.T) {
+    tests := []struct {
+        name string
+        want string
+    }{
+        {"ACME",           "acme"},
+        {"Acme, Inc.",     "acme"},
+        {"acme d.o.o.",    "acme"},
+        {"Big Co",         "big"},
+        {"Co",             "co"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := normalizeClientName(tt.name); got != tt.want {
+                t.Errorf("normalizeClientName() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func Test_previewImport(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+
+    // Tadej already logged 1.5h on ACME and got the week of the 12th approved
+    if _, err := addTimeEntry(db, 1, 2, "ACME", time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC), 90); err != nil {
+        t.Fatal(err)
+    }
+    approvedWeek(t, db, dbPath, 2, 60, time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC))
+
+    tests := []struct {
+        name       string
+        preset     string
+        mapping    string
+        file       string
+        wantStatus []string
+        wantClient []string
+    }{
+        {
+            name:       "toggl",
+            preset:     "toggl",
+            file:       "\ufeffUser,Client,Start date,Duration\nTadej,\"Acme, Inc.\",2026-10-21,01:30:00\nTadej,Acme,2026-10-22,00:45:00\nTadej,Acme,2026-10-22,00:45:00\nTadej,Globex,2026-10-23,2:00\nTadej,Globex GmbH,2026-10-23,1:00\n",
+            wantStatus: []string{importDuplicate, importNew, importNew, importNew, importNew},
+            wantClient: []string{"ACME", "ACME", "ACME", "Globex", "Globex"},
+        },
+        {
+            name:       "harvest with a locked week and bad cells",
+            preset:     "harvest",
+            file:       "Date;Client;Hours\n2026-10-14;ACME;2\n2026-10-99;ACME;2\n2026-10-22;ACME;-1\n2026-10-22;;1\n",
+            wantStatus: []string{importError, importError, importError, importError},
+            wantClient: []string{"ACME", "", "", ""},
+        },
+        {
+            name:       "clockify on projects in a day/month workspace",
+            preset:     "clockify",
+            mapping:    "layout=02/01/2006",
+            file:       "Start Date,Client,Project,Duration (decimal)\n22/10/2026,ACME,Website,1\n22/10/2026,ACME,Intranet,1\n" +
+                strings.Repeat("23/10/2026,ACME,website,24\n", 5) + "10/22/2026,ACME,,1\n",
+            // The 5th day on the website goes past its 100h, counting the rows above it
+            wantStatus: []string{importNew, importError, importNew, importNew, importNew, importNew, importError, importError},
+            wantClient: []string{"ACME", "ACME", "ACME", "ACME", "ACME", "ACME", "ACME", ""},
+        },
+        {
+            name:       "generic mapping",
+            mapping:    "date=Day, client=Customer, minutes=Time, layout=02.01.2006",
+            file:       "Day\tCustomer\tTime\n22.10.2026\tacme\t30\n",
+            wantStatus: []string{importNew},
+            wantClient: []string{"ACME"},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            mapping, err := getImportMapping(tt.preset, tt.mapping)
+            if err != nil {
+                t.Fatal(err)
+            }
+            rows, err := previewImport(db, enforcer, 2, strings.NewReader(tt.file), mapping)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if len(rows) != len(tt.wantStatus) {
+                t.Fatalf("previewImport() = %+v, want %d rows", rows, len(tt.wantStatus))
+            }
+            for i, row := range rows {
+                if row.Status != tt.wantStatus[i] || row.ClientName != tt.wantClient[i] {
+                    t.Errorf("row %d = %+v, want %v %v", i, row, tt.wantStatus[i], tt.wantClient[i])
+                }
+            }
+        })
+    }
+
+    if _, err := getImportMapping("", "date=Day, hours=Time"); err == nil {
+        t.Error("getImportMapping() accepted a mapping without a client")
+    }
+    // Ray may not log time at Steaby, so he may not import any either
+    if _, err := previewImport(db, enforcer, 1, strings.NewReader("Date,Client,Hours\n2026-10-22,ACME,1\n"), importPresets["harvest"]); !errors.Is(err, errImportDenied) {
+        t.Errorf("previewImport() by Ray error = %v, want %v", err, errImportDenied)
+    }
+}
+
+func Test_importAndUndo(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+
+    file      := "Date,Client,Project,Hours\n2026-10-21,ACME,Website,1.5\n2026-10-22,Initech Ltd,,2\n2026-10-22,Initech Ltd,,2\n"
+    rows, err := previewImport(db, enforcer, 3, strings.NewReader(file), importPresets["harvest"])
+    if err != nil {
+        t.Fatal(err)
+    }
+    batch, err := commitImport(db, enforcer, 3, "harvest", "export.csv", rows)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if batch.EntryCount != 3 {
+        t.Fatalf("commitImport() = %+v, want 3 entries", batch)
+    }
+    var websiteEntries int
+    db.QueryRow("SELECT COUNT(*) FROM time_entry WHERE user_id = 3 AND project_id = 1").Scan(&websiteEntries)
+    if websiteEntries != 1 {
+        t.Errorf("time_entry has %d Website entries of Petar, want 1", websiteEntries)
+    }
+
+    // The new client got registered and the same file imports nothing the second time
+    var clients int
+    db.QueryRow("SELECT COUNT(*) FROM client_dim WHERE client_name = 'Initech Ltd'").Scan(&clients)
+    if clients != 1 {
+        t.Errorf("client_dim has %d Initech rows, want 1", clients)
+    }
+    again, _ := previewImport(db, enforcer, 3, strings.NewReader(file), importPresets["harvest"])
+    if counts := countImportRows(again); counts[importNew] != 0 {
+        t.Errorf("second preview = %+v, want no new rows", again)
+    }
+
+    if err := undoImport(db, 1, 3, batch.ImportBatchID); err != nil {
+        t.Fatal(err)
+    }
+    ts, _ := getOrCreateTimesheet(db, 1, 3, time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC))
+    if ts.TotalMinutes != 0 {
+        t.Errorf("after undo the week has %d minutes, want 0", ts.TotalMinutes)
+    }
+    // The removed entries keep their history, down to the delete
+    history, err := timesheetHistory(db, ts.TimesheetID)
+    var deletes int
+    for _, version := range history {
+        if version.Change == entryDeleted {
+            deletes++
+        }
+    }
+    if err != nil || deletes != 3 {
+        t.Errorf("timesheetHistory() after undo has %d deletes, %v, want 3", deletes, err)
+    }
+    if err := undoImport(db, 1, 3, batch.ImportBatchID); !errors.Is(err, errImportNotUndoable) {
+        t.Errorf("second undoImport() error = %v, want %v", err, errImportNotUndoable)
+    }
+
+    // Once the week is submitted the import stays
+    rows, _  = previewImport(db, enforcer, 3, strings.NewReader(file), importPresets["harvest"])
+    batch, _ = commitImport(db, enforcer, 3, "harvest", "export.csv", rows)
+    if _, err := transitionTimesheet(db, enforcer, 3, ts.TimesheetID, timesheetActSubmit, ""); err != nil {
+        t.Fatal(err)
+    }
+    if err := undoImport(db, 1, 3, batch.ImportBatchID); !errors.Is(err, errImportNotUndoable) {
+        t.Errorf("undoImport() of a submitted week error = %v, want %v", err, errImportNotUndoable)
+    }
+    if err := undoImport(db, 1, 2, batch.ImportBatchID); err == nil {
+        t.Error("undoImport() of another user's import succeeded")
+    }
+
+    batches, err := listImportBatches(db, 1, 3)
+    if err != nil || len(batches) != 2 || !batches[1].Undone || batches[0].FileName != "export.csv" {
+        t.Errorf("listImportBatches() = %+v, %v", batches, err)
+    }
+}
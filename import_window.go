@@ -0,0 +1,234 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "path/filepath"
+    "showcase_desktop/widgets"
+)
+
+
+// importBatchRow keeps the undo button of one earlier import between frames
+type importBatchRow struct {
+    batch       ImportBatch
+    undoBtn     widget.Clickable
+}
+
+
+// runImport previews a CSV file as a dry run and imports the new rows into the organization in one go, earlier
+// imports can be undone
+func runImport(inWindow *app.Window, inUserID int, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var pathTextbox         widget.Editor
+    var presetTextbox       widget.Editor
+    var mappingTextbox      widget.Editor
+    var previewBtn          widget.Clickable
+    var importBtn           widget.Clickable
+    var previewList         widget.List
+    var batchList           widget.List
+    var previewRows         []ImportRow
+    var batchRows           []*importBatchRow
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Import time entries")
+    previewList.Axis         = layout.Vertical
+    batchList.Axis           = layout.Vertical
+
+    refreshBatches := func() {
+        batches, err := listImportBatches(inS3db, inEnforcer.OrganizationID, inUserID)
+        if err != nil {
+            log.Print(err)
+            statusMsg = tr().Text("Could not load earlier imports")
+            return
+        }
+
+        batchRows = batchRows[:0]
+        for _, batch := range batches {
+            batchRows = append(batchRows, &importBatchRow{batch: batch})
+        }
+    }
+    refreshBatches()
+
+    inWindow.Option(app.Title(tr().Text("Import")), app.Size(unit.Dp(1050), unit.Dp(800)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            // Dry run - nothing is written until Import is clicked
+            if previewBtn.Clicked(gtx) {
+                rows, err := previewImportFile(inS3db, inEnforcer, inUserID, pathTextbox.Text(), presetTextbox.Text(), mappingTextbox.Text())
+                if err != nil {
+                    previewRows = nil
+                    statusMsg   = tr().Sprintf("Could not read the file: %s", errorText(err))
+                } else {
+                    previewRows = rows
+                    counts     := countImportRows(rows)
+                    statusMsg   = tr().Sprintf("%d new, %d duplicates, %d errors - click Import to add the new ones", counts[importNew], counts[importDuplicate], counts[importError])
+                }
+            }
+
+            // The file is read again, so the import matches what is on disk now
+            if importBtn.Clicked(gtx) {
+                rows, err := previewImportFile(inS3db, inEnforcer, inUserID, pathTextbox.Text(), presetTextbox.Text(), mappingTextbox.Text())
+                if err == nil {
+                    var batch ImportBatch
+                    batch, err = commitImport(inS3db, inEnforcer, inUserID, importSource(presetTextbox.Text()), filepath.Base(pathTextbox.Text()), rows)
+                    statusMsg  = tr().Plural(batch.EntryCount, "Imported %d entry as import #%d", "Imported %d entries as import #%d", batch.EntryCount, batch.ImportBatchID)
+                }
+                if err != nil {
+                    statusMsg = tr().Sprintf("Could not import: %s", errorText(err))
+                }
+                previewRows = nil
+                refreshBatches()
+            }
+
+            // Undo an earlier import
+            for _, row := range batchRows {
+                if row.undoBtn.Clicked(gtx) {
+                    if err := undoImport(inS3db, inEnforcer.OrganizationID, inUserID, row.batch.ImportBatchID); err != nil {
+                        statusMsg = tr().Sprintf("Could not undo import #%d: %s", row.batch.ImportBatchID, errorText(err))
+                    } else {
+                        statusMsg = tr().Plural(row.batch.EntryCount, "Removed the %d entry of import #%d", "Removed the %d entries of import #%d",
+                            row.batch.EntryCount, row.batch.ImportBatchID)
+                    }
+                    refreshBatches()
+                    break
+                }
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                // Result of the last action
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                // File and format
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &pathTextbox, tr().Text("Path to the CSV file")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &presetTextbox, tr().Text("csv, toggl, clockify or harvest")).Layout(gtx) },
+                    )
+                }),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.InputBox(theme, &mappingTextbox, tr().Text("For csv: date=Day, client=Customer, project=Project, hours=Time, layout=2006-01-02 - for a preset only the changes, e.g. layout=02/01/2006")).Layout(gtx)
+                }),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        material.Button(theme.Theme, &previewBtn, tr().Text("Preview")).Layout,
+                        material.Button(theme.Theme, &importBtn, tr().Text("Import")).Layout,
+                    )
+                }),
+
+                // Preview of the rows
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &previewList).Layout(gtx, len(previewRows), func(gtx layout.Context, index int) layout.Dimensions {
+                        return importPreviewElement(gtx, theme, previewRows[index])
+                    })
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+                // Earlier imports
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &batchList).Layout(gtx, len(batchRows), func(gtx layout.Context, index int) layout.Dimensions {
+                        return importBatchElement(gtx, theme, batchRows[index])
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
+
+
+func importPreviewElement(inGTX layout.Context, inTheme *widgets.Theme, inRow ImportRow) layout.Dimensions {
+    rowText := tr().Sprintf("Line %d  %s", inRow.Line, importStatusText(inRow.Status))
+    if inRow.Status != importError {
+        client := inRow.ClientName
+        if inRow.ProjectName != "" {
+            client += " / " + inRow.ProjectName
+        }
+        rowText += fmt.Sprintf("  %s  %s  %s", tr().Date(inRow.EntryDate), client, tr().Duration(inRow.Minutes))
+    }
+    if inRow.Note != nil {
+        rowText += "  (" + errorText(inRow.Note) + ")"
+    }
+
+    label := material.Body1(inTheme.Theme, rowText)
+    // Rows that won't be imported are greyed out
+    if inRow.Status != importNew {
+        label.Color = inTheme.Muted
+    }
+
+    return layout.UniformInset(unit.Dp(3)).Layout(inGTX, label.Layout)
+}
+
+// importStatusText is what the import would do with a row, in the user's language
+func importStatusText(inStatus string) string {
+    switch inStatus {
+    case importNew:
+        return tr().Text("new")
+    case importDuplicate:
+        return tr().Text("duplicate")
+    case importError:
+        return tr().Text("error")
+    }
+    return inStatus
+}
+
+func importBatchElement(inGTX layout.Context, inTheme *widgets.Theme, inRow *importBatchRow) layout.Dimensions {
+    batch   := inRow.batch
+    rowText := tr().Plural(batch.EntryCount, "#%d  %s  %s  %s  %d entry", "#%d  %s  %s  %s  %d entries",
+        batch.ImportBatchID, tr().DateTime(batch.ImportedAt.Local()), batch.Source, batch.FileName, batch.EntryCount)
+    if batch.Undone {
+        rowText += tr().Text("  (undone)")
+    }
+
+    return layout.UniformInset(unit.Dp(5)).Layout(inGTX, func(gtx layout.Context) layout.Dimensions {
+        return layout.Flex{
+            Axis:      layout.Horizontal,
+            Alignment: layout.Middle,
+        }.Layout(gtx,
+            layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                return material.Body1(inTheme.Theme, rowText).Layout(gtx)
+            }),
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                if batch.Undone {
+                    return layout.Dimensions{}
+                }
+                return material.Button(inTheme.Theme, &inRow.undoBtn, tr().Text("Undo")).Layout(gtx)
+            }),
+        )
+    })
+}
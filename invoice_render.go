@@ -0,0 +1,328 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "encoding/xml"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+
+// Who is billing - printed on every document
+const (
+    invoiceSellerName = "Simple-teab"
+    invoiceFolder     = "data/invoices"
+)
+
+
+// exportInvoice writes the PDF, JSON and UBL renderings of a document into the invoice folder and returns their paths
+func exportInvoice(inInvoice Invoice, inFolder string) ([]string, error) {
+    if err := os.MkdirAll(inFolder, 0o755); err != nil {
+        return nil, err
+    }
+
+    jsonDoc, err := renderInvoiceJSON(inInvoice)
+    if err != nil {
+        return nil, err
+    }
+    ublDoc, err := renderInvoiceUBL(inInvoice)
+    if err != nil {
+        return nil, err
+    }
+
+    renders := []struct {
+        ext string
+        doc []byte
+    }{
+        {".pdf",  renderInvoicePDF(inInvoice)},
+        {".json", jsonDoc},
+        {".xml",  ublDoc},
+    }
+
+    var paths []string
+    for _, render := range renders {
+        path := filepath.Join(inFolder, inInvoice.DocumentNumber()+render.ext)
+        if err := os.WriteFile(path, render.doc, 0o644); err != nil {
+            return paths, err
+        }
+        paths = append(paths, path)
+    }
+
+    return paths, nil
+}
+
+
+// JSON rendering
+type invoiceJSON struct {
+    Number          string              `json:"number"`
+    Kind            string              `json:"kind"`
+    Seller          string              `json:"seller"`
+    Client          string              `json:"client"`
+    Currency        string              `json:"currency"`
+    IssueDate       string              `json:"issue_date"`
+    PeriodFrom      string              `json:"period_from"`
+    PeriodTo        string              `json:"period_to"`
+    Credits         string              `json:"credits,omitempty"`
+    Note            string              `json:"note,omitempty"`
+    Total           string              `json:"total"`
+    Lines           []invoiceLineJSON   `json:"lines"`
+}
+
+type invoiceLineJSON struct {
+    LineNo          int                 `json:"line_no"`
+    Description     string              `json:"description"`
+    Hours           string              `json:"hours"`
+    HourlyRate      string              `json:"hourly_rate"`
+    Amount          string              `json:"amount"`
+    TimeEntryIDs    []int               `json:"time_entry_ids,omitempty"`
+}
+
+func renderInvoiceJSON(inInvoice Invoice) ([]byte, error) {
+    doc := invoiceJSON{
+        Number:     inInvoice.DocumentNumber(),
+        Kind:       inInvoice.Kind,
+        Seller:     invoiceSellerName,
+        Client:     inInvoice.ClientName,
+        Currency:   inInvoice.Currency,
+        IssueDate:  dateKey(inInvoice.IssueDate),
+        PeriodFrom: dateKey(inInvoice.PeriodFrom),
+        PeriodTo:   dateKey(inInvoice.PeriodTo),
+        Note:       inInvoice.Note,
+        Total:      formatCents(inInvoice.TotalCents),
+    }
+    if inInvoice.CreditedInvoiceID != 0 {
+        doc.Credits = inInvoice.CreditedDocumentNumber()
+    }
+    for _, line := range inInvoice.Lines {
+        doc.Lines = append(doc.Lines, invoiceLineJSON{
+            LineNo:       line.LineNo,
+            Description:  line.Description,
+            Hours:        strings.TrimSuffix(formatMinutes(line.Minutes), "h"),
+            HourlyRate:   formatCents(line.HourlyRateCents),
+            Amount:       formatCents(line.AmountCents),
+            TimeEntryIDs: line.TimeEntryIDs,
+        })
+    }
+
+    return json.MarshalIndent(doc, "", "  ")
+}
+
+
+// UBL 2.1 rendering - only the parts a time and billing invoice needs
+type ublAmount struct {
+    Currency    string  `xml:"currencyID,attr"`
+    Value       string  `xml:",chardata"`
+}
+
+type ublQuantity struct {
+    UnitCode    string  `xml:"unitCode,attr"`
+    Value       string  `xml:",chardata"`
+}
+
+type ublParty struct {
+    Name        string  `xml:"cac:Party>cac:PartyName>cbc:Name"`
+}
+
+type ublLine struct {
+    ID              int          `xml:"cbc:ID"`
+    Quantity        *ublQuantity `xml:"cbc:InvoicedQuantity,omitempty"`
+    CreditQuantity  *ublQuantity `xml:"cbc:CreditedQuantity,omitempty"`
+    LineAmount      ublAmount    `xml:"cbc:LineExtensionAmount"`
+    ItemName        string       `xml:"cac:Item>cbc:Name"`
+    Price           ublAmount    `xml:"cac:Price>cbc:PriceAmount"`
+}
+
+type ublDocument struct {
+    XMLName         xml.Name
+    XMLNS           string      `xml:"xmlns,attr"`
+    XMLNSCac        string      `xml:"xmlns:cac,attr"`
+    XMLNSCbc        string      `xml:"xmlns:cbc,attr"`
+    UBLVersion      string      `xml:"cbc:UBLVersionID"`
+    ID              string      `xml:"cbc:ID"`
+    IssueDate       string      `xml:"cbc:IssueDate"`
+    TypeCode        string      `xml:"cbc:InvoiceTypeCode,omitempty"`
+    CreditTypeCode  string      `xml:"cbc:CreditNoteTypeCode,omitempty"`
+    Note            string      `xml:"cbc:Note,omitempty"`
+    Currency        string      `xml:"cbc:DocumentCurrencyCode"`
+    PeriodStart     string      `xml:"cac:InvoicePeriod>cbc:StartDate"`
+    PeriodEnd       string      `xml:"cac:InvoicePeriod>cbc:EndDate"`
+    BillingRef      string      `xml:"cac:BillingReference>cac:InvoiceDocumentReference>cbc:ID,omitempty"`
+    Supplier        ublParty    `xml:"cac:AccountingSupplierParty"`
+    Customer        ublParty    `xml:"cac:AccountingCustomerParty"`
+    LineTotal       ublAmount   `xml:"cac:LegalMonetaryTotal>cbc:LineExtensionAmount"`
+    PayableAmount   ublAmount   `xml:"cac:LegalMonetaryTotal>cbc:PayableAmount"`
+    InvoiceLines    []ublLine   `xml:"cac:InvoiceLine,omitempty"`
+    CreditLines     []ublLine   `xml:"cac:CreditNoteLine,omitempty"`
+}
+
+func renderInvoiceUBL(inInvoice Invoice) ([]byte, error) {
+    isCredit := inInvoice.Kind == invoiceKindCreditNote
+    // Credit notes carry positive amounts in UBL, the document type says which way the money goes
+    sign     := int64(1)
+    if isCredit {
+        sign = -1
+    }
+
+    doc := ublDocument{
+        XMLName:        xml.Name{Local: "Invoice"},
+        XMLNS:          "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",
+        XMLNSCac:       "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",
+        XMLNSCbc:       "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2",
+        UBLVersion:     "2.1",
+        ID:             inInvoice.DocumentNumber(),
+        IssueDate:      dateKey(inInvoice.IssueDate),
+        TypeCode:       "380",
+        Note:           inInvoice.Note,
+        Currency:       inInvoice.Currency,
+        PeriodStart:    dateKey(inInvoice.PeriodFrom),
+        PeriodEnd:      dateKey(inInvoice.PeriodTo),
+        Supplier:       ublParty{Name: invoiceSellerName},
+        Customer:       ublParty{Name: inInvoice.ClientName},
+        LineTotal:      ublAmount{Currency: inInvoice.Currency, Value: formatCents(sign * inInvoice.TotalCents)},
+        PayableAmount:  ublAmount{Currency: inInvoice.Currency, Value: formatCents(sign * inInvoice.TotalCents)},
+    }
+    if isCredit {
+        doc.XMLName        = xml.Name{Local: "CreditNote"}
+        doc.XMLNS          = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+        doc.TypeCode       = ""
+        doc.CreditTypeCode = "381"
+        doc.BillingRef     = inInvoice.CreditedDocumentNumber()
+    }
+
+    for _, line := range inInvoice.Lines {
+        quantity := ublQuantity{UnitCode: "HUR", Value: strings.TrimSuffix(formatMinutes(int(sign)*line.Minutes), "h")}
+        ubl      := ublLine{
+            ID:         line.LineNo,
+            LineAmount: ublAmount{Currency: inInvoice.Currency, Value: formatCents(sign * line.AmountCents)},
+            ItemName:   line.Description,
+            Price:      ublAmount{Currency: inInvoice.Currency, Value: formatCents(line.HourlyRateCents)},
+        }
+        if isCredit {
+            ubl.CreditQuantity = &quantity
+            doc.CreditLines    = append(doc.CreditLines, ubl)
+        } else {
+            ubl.Quantity     = &quantity
+            doc.InvoiceLines = append(doc.InvoiceLines, ubl)
+        }
+    }
+
+    out, err := xml.MarshalIndent(doc, "", "  ")
+    if err != nil {
+        return nil, err
+    }
+
+    return append([]byte(xml.Header), out...), nil
+}
+
+
+// PDF rendering - a plain text A4 document with the built-in Courier font, so columns line up and no PDF library is needed
+const (
+    pdfLinesPerPage = 50
+    pdfFontSize     = 11
+)
+
+func renderInvoicePDF(inInvoice Invoice) []byte {
+    title := "INVOICE"
+    if inInvoice.Kind == invoiceKindCreditNote {
+        title = "CREDIT NOTE"
+    }
+
+    textLines := []string{
+        fmt.Sprintf("%s %s", title, inInvoice.DocumentNumber()),
+        "",
+        fmt.Sprintf("From:        %s", invoiceSellerName),
+        fmt.Sprintf("To:          %s", inInvoice.ClientName),
+        fmt.Sprintf("Issued:      %s", dateKey(inInvoice.IssueDate)),
+        fmt.Sprintf("Period:      %s - %s", dateKey(inInvoice.PeriodFrom), dateKey(inInvoice.PeriodTo)),
+    }
+    if inInvoice.CreditedInvoiceID != 0 {
+        textLines = append(textLines, fmt.Sprintf("Credits:     %s", inInvoice.CreditedDocumentNumber()))
+    }
+    if inInvoice.Note != "" {
+        textLines = append(textLines, fmt.Sprintf("Note:        %s", inInvoice.Note))
+    }
+    textLines = append(textLines, "", fmt.Sprintf("%-4s %-60s %10s %12s", "#", "Description", "Hours", "Amount"))
+
+    for _, line := range inInvoice.Lines {
+        textLines = append(textLines, fmt.Sprintf("%-4d %-60s %10s %12s", line.LineNo, line.Description,
+            strings.TrimSuffix(formatMinutes(line.Minutes), "h"), formatCents(line.AmountCents)))
+    }
+    textLines = append(textLines, "", fmt.Sprintf("Total: %s %s", formatCents(inInvoice.TotalCents), inInvoice.Currency))
+
+    var pages [][]string
+    for len(textLines) > pdfLinesPerPage {
+        pages     = append(pages, textLines[:pdfLinesPerPage])
+        textLines = textLines[pdfLinesPerPage:]
+    }
+    pages = append(pages, textLines)
+
+    return buildPDF(pages)
+}
+
+// buildPDF lays out pages of text lines and writes the objects, xref table and trailer
+func buildPDF(inPages [][]string) []byte {
+    var objects []string
+
+    // 1: catalog, 2: page tree, 3: font, then a page and a content stream per page
+    objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>", "", "<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>")
+
+    var kids []string
+    for _, page := range inPages {
+        var content bytes.Buffer
+
+        fmt.Fprintf(&content, "BT /F1 %d Tf 14 TL 50 800 Td\n", pdfFontSize)
+        for _, line := range page {
+            fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscape(line))
+        }
+        content.WriteString("ET")
+
+        pageID    := len(objects) + 1
+        contentID := pageID + 1
+        kids       = append(kids, fmt.Sprintf("%d 0 R", pageID))
+        objects    = append(objects,
+            fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentID),
+            fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+        )
+    }
+    objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(inPages))
+
+    var out bytes.Buffer
+    var offsets []int
+
+    out.WriteString("%PDF-1.4\n")
+    for i, object := range objects {
+        offsets = append(offsets, out.Len())
+        fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+    }
+
+    xrefStart := out.Len()
+    fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+    for _, offset := range offsets {
+        fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+    }
+    fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefStart)
+
+    return out.Bytes()
+}
+
+// pdfEscape escapes a string for a PDF literal and drops what the standard fonts can't show
+func pdfEscape(inText string) string {
+    var sb strings.Builder
+
+    for _, r := range inText {
+        switch {
+        case r == '(' || r == ')' || r == '\\':
+            sb.WriteRune('\\')
+            sb.WriteRune(r)
+        case r < 32 || r > 126:
+            sb.WriteRune('?')
+        default:
+            sb.WriteRune(r)
+        }
+    }
+
+    return sb.String()
+}
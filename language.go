@@ -0,0 +1,182 @@
+package main
+
+import (
+    "showcase_desktop/i18n"
+    "strings"
+    "sync/atomic"
+    "time"
+)
+
+
+// appPrinter translates into the signed in user's language. The settings window switches it, open windows are
+// told to redraw.
+var appPrinter atomic.Pointer[i18n.Printer]
+
+
+// tr returns the printer of the language windows are drawn in, every user-visible text goes through it
+func tr() *i18n.Printer {
+    if printer := appPrinter.Load(); printer != nil {
+        return printer
+    }
+    appPrinter.CompareAndSwap(nil, i18n.NewPrinter(i18n.English))
+    return appPrinter.Load()
+}
+
+// setLanguage switches every window to the language, tags it doesn't know switch to English
+func setLanguage(inTag string) {
+    appPrinter.Store(i18n.NewPrinter(inTag))
+    notifySettingChange()
+}
+
+// catalogText is an argument of newError that is a message itself, e.g. a timesheet state, and gets translated too
+type catalogText string
+
+// appError is one of the app's errors with its details. It reads in English, errorText writes it in the user's
+// language with the dates the way the language writes them.
+type appError struct {
+    format  string
+    args    []any
+}
+
+// newError makes an error errorText can translate, the format is a catalog message like the ones passed to tr()
+func newError(inFormat string, inArgs ...any) error {
+    return &appError{format: inFormat, args: inArgs}
+}
+
+func (e *appError) Error() string {
+    return e.text(i18n.NewPrinter(i18n.English))
+}
+
+// text fills the error in the printer's language
+func (e *appError) text(inPrinter *i18n.Printer) string {
+    args := make([]any, len(e.args))
+    for i, arg := range e.args {
+        switch arg := arg.(type) {
+        case catalogText:
+            args[i] = inPrinter.Text(string(arg))
+        case time.Time:
+            args[i] = inPrinter.Date(arg)
+        default:
+            args[i] = arg
+        }
+    }
+    return inPrinter.Sprintf(e.format, args...)
+}
+
+
+// timesheetStateText is a timesheet's state in the user's language
+func timesheetStateText(inState string) string {
+    switch inState {
+    case timesheetDraft:
+        return tr().Text("draft")
+    case timesheetSubmitted:
+        return tr().Text("submitted")
+    case timesheetApproved:
+        return tr().Text("approved")
+    case timesheetRejected:
+        return tr().Text("rejected")
+    case timesheetLocked:
+        return tr().Text("locked")
+    }
+    return inState
+}
+
+// effectText is a rule's effect in the user's language
+func effectText(inEffect string) string {
+    switch inEffect {
+    case "allow":
+        return tr().Text("allow")
+    case "deny":
+        return tr().Text("deny")
+    }
+    return inEffect
+}
+
+// changeText is the change a time entry version made, in the user's language
+func changeText(inChange string) string {
+    switch inChange {
+    case entryCreated:
+        return tr().Text("created")
+    case entryUpdated:
+        return tr().Text("updated")
+    case entryDeleted:
+        return tr().Text("deleted")
+    case entryRestored:
+        return tr().Text("restored")
+    case entryUndone:
+        return tr().Text("undone")
+    }
+    return inChange
+}
+
+// localDateTime writes a time stored as RFC 3339 in the user's time zone and language, as stored when it doesn't parse
+func localDateTime(inStored string) string {
+    stored, err := time.Parse(time.RFC3339, inStored)
+    if err != nil {
+        return inStored
+    }
+    return tr().DateTime(stored.Local())
+}
+
+// errorText is the error in the user's language. The app's sentinels and the errors made with newError are translated
+// wherever they are wrapped, errors from elsewhere, e.g. the database, are shown as they come.
+func errorText(inErr error) string {
+    known := []struct {
+        err     error
+        text    string
+    }{
+        {errAlreadyCredited, tr().Text("invoice has already been credited")},
+        {errImportDenied, tr().Text("you shall not pass!.. the import")},
+        {errInvoiceDenied, tr().Text("you shall not pass!.. the invoices")},
+        {errImportNotUndoable, tr().Text("import can't be undone anymore")},
+        {errNoOrganization, tr().Text("you are not in any organization")},
+        {errNoRateCard, tr().Text("no rate card matches")},
+        {errNothingToInvoice, tr().Text("no approved, unbilled time for this client and period")},
+        {errNothingToUndo, tr().Text("you have no changes left to undo")},
+        {errOverBudget, tr().Text("project is over its budget")},
+        {errPolicyDenied, tr().Text("you shall not pass!.. the policy")},
+        {errProjectDenied, tr().Text("you shall not pass!.. the project")},
+        {errProjectGroupRule, tr().Text("project groups (g2) are set on the projects, not in the policy")},
+        {errProjectInactive, tr().Text("project is not running on that date")},
+        {errRoleCycle, tr().Text("a role can not inherit from itself, directly or through other roles")},
+        {errRoleDenied, tr().Text("you shall not pass!.. the roles")},
+        {errTaskNotFound, tr().Text("task not found")},
+        {errTimeEntryDenied, tr().Text("you may not change this time entry")},
+        {errTimeEntryNotFound, tr().Text("time entry not found")},
+        {errTimesheetDenied, tr().Text("you shall not pass!.. the timesheet")},
+        {errTimesheetNotFound, tr().Text("timesheet not found")},
+        {errTimesheetReadOnly, tr().Text("timesheet is read-only in its current state")},
+        {errTimesheetTransition, tr().Text("timesheet cannot make this transition")},
+        {errVersionNotFound, tr().Text("time entry version not found")},
+    }
+
+    var translate func(error) string
+    translate = func(inErr error) string {
+        if app, ok := inErr.(*appError); ok {
+            return app.text(tr())
+        }
+        for _, k := range known {
+            if inErr == k.err {
+                return k.text
+            }
+        }
+
+        // A wrapping error keeps its own words and gets the wrapped ones translated
+        var wrapped []error
+        switch err := inErr.(type) {
+        case interface{ Unwrap() error }:
+            wrapped = []error{err.Unwrap()}
+        case interface{ Unwrap() []error }:
+            wrapped = err.Unwrap()
+        }
+        text := inErr.Error()
+        for _, inner := range wrapped {
+            if inner != nil {
+                text = strings.Replace(text, inner.Error(), translate(inner), 1)
+            }
+        }
+        return text
+    }
+
+    return translate(inErr)
+}
@@ -5,31 +5,36 @@ import (
     "errors"
     "fmt"
     "gioui.org/app"
-    "gioui.org/font"
     "gioui.org/io/system"
-    "gioui.org/layout"
     "gioui.org/op"
-    "gioui.org/text"
-    "gioui.org/unit"
-    "gioui.org/widget"
-    "gioui.org/widget/material"
     "github.com/casbin/casbin/v2"
     "github.com/casbin/casbin/v2/model"
     "github.com/casbin/casbin/v2/persist"
-    "gorm.io/driver/sqlite"
-    "gorm.io/gorm"
-    "image/color"
     "log"
     "os"
+    "showcase_desktop/i18n"
+    "showcase_desktop/widgets"
+    "sync"
 
     _ "github.com/mattn/go-sqlite3"
 )
 
 
+// errSignedOut ends the main window when the user signs out, rather than closing it
+var errSignedOut = errors.New("signed out")
+
+
 func main() {
     // Create sqlite3 object to fetch data from DB
     s3db := openDb()
 
+    // Subcommands like "export" run without opening any window
+    if len(os.Args) > 1 {
+        exitCode := runCommand(os.Args[1:], s3db, os.Stdout, os.Stderr)
+        s3db.Close()
+        os.Exit(exitCode)
+    }
+
     // The app runs in a go routine
     go func() {
         // Define a window instance - we could create multiple windows if needed
@@ -53,15 +58,34 @@ func main() {
 // Functions for handling windows
 func runSignIn(inWindow *app.Window, inS3db *sql.DB) error {
     var ops                 op.Ops 			  // List of operations gio library uses to know what needs to be shown in a window
-    var signInBtn           widget.Clickable
-    var usernameTextbox     widget.Editor
-    var passwordTextbox     widget.Editor
-    var errorMsg            string
 
-    var theme  = material.NewTheme()
+    var ownTheme            windowTheme
+
+    screen := newSignInScreen(inS3db)
+    defer onSettingChange(inWindow.Invalidate)()
+
+    // Open main window in the picked organization and close sign in
+    openApp := func(inSignIn signInResult) {
+        go func() {
+            mainWindow := new(app.Window)
+            inWindow.Perform(system.ActionMinimize)
+            err        := runApp(mainWindow, inSignIn.UserID, inSignIn.Username, inSignIn.Organization, inS3db)
+
+            // Back to the sign in window for the next user
+            if errors.Is(err, errSignedOut) {
+                screen.signedOut.Store(true)
+                inWindow.Option(app.Windowed.Option())
+                inWindow.Perform(system.ActionRaise)
+                inWindow.Invalidate()
+                return
+            }
+            if err != nil {
+                log.Fatal(err)
+            }
 
-    titleText := "Very Simple-teab app"
-    btnText   := "Sign In"
+            defer inWindow.Perform(system.ActionClose)
+        }()
+    }
 
     for {
         event := inWindow.Event()
@@ -75,89 +99,10 @@ func runSignIn(inWindow *app.Window, inS3db *sql.DB) error {
             // This layout context is used for managing the rendering state of the window
             gtx      := app.NewContext(&ops, eventType)
 
-            // Set an action for button click
-            if signInBtn.Clicked(gtx) {
-                var username string
-                var password string
-
-                username = usernameTextbox.Text()
-                password = passwordTextbox.Text()
-
-                if len(username) > 0 && len(password) > 0 {
-                    // Check sign in credentials
-                    success, userID := checkSignIn(username, password, inS3db)
-
-                    if !success {
-                        fmt.Printf("Sign-in failed: %s // %s\n", username, password)
-                        break
-                    }
-
-                    // Open main window and close sign in
-                    go func() {
-                        mainWindow := new(app.Window)
-                        inWindow.Perform(system.ActionMinimize)
-                        err        := runApp(mainWindow, userID, username, inS3db)
-
-                        if err != nil {
-                            log.Fatal(err)
-                        }
-
-                        defer inWindow.Perform(system.ActionClose)
-                    }()
-                } else {
-                    errorMsg   = "Please enter a username and a password"
-                }
+            if signIn, signedIn := screen.update(gtx); signedIn {
+                openApp(signIn)
             }
-
-            layout.Flex{
-                // Vertical alignment, from top to bottom
-                Axis: layout.Vertical,
-                // Empty space is left at the start, i.e. at the top
-                Spacing: layout.SpaceStart,
-            }.Layout(gtx,
-                // Title on top - in Flex Layout Flexed objects start filling from the top
-                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-                    maroon := color.NRGBA{R: 127, G: 0, B: 0, A: 255}
-                    return titleElement(gtx, theme, titleText, 1, maroon)
-                }),
-
-                // Empty spacer
-                layout.Rigid(layout.Spacer{Height: unit.Dp(30)}.Layout),
-
-                // Error box, if there is an error to show
-                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-                    return errorBoxElement(gtx, theme, errorMsg)
-                }),
-
-                // Empty spacer
-                layout.Rigid(layout.Spacer{Height: unit.Dp(50)}.Layout),
-
-                // Textbox for username
-                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-                    return inputBoxElement(gtx, theme, &usernameTextbox, "Enter username")
-                }),
-
-                // Empty spacer
-                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
-
-                // Textbox for password
-                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-                    // Hide user's input with a mask
-                    passwordTextbox.Mask = '•'
-                    return inputBoxElement(gtx, theme, &passwordTextbox, "Enter password")
-                }),
-
-                // Empty spacer
-                layout.Rigid(layout.Spacer{Height: unit.Dp(25)}.Layout),
-
-                // Button for submitting username and password
-                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-                    return btnElement(gtx, theme, &signInBtn, btnText)
-                }),
-
-                // Empty spacer
-                layout.Rigid(layout.Spacer{Height: unit.Dp(25)}.Layout),
-            )
+            screen.layout(gtx, ownTheme.current())
 
             // Pass the drawing operations to the GPU
             eventType.Frame(gtx.Ops)
@@ -166,136 +111,71 @@ func runSignIn(inWindow *app.Window, inS3db *sql.DB) error {
 }
 
 
-func runApp(inWindow *app.Window, inUserID int, inUsername string, inS3db *sql.DB) error {
+func runApp(inWindow *app.Window, inUserID int, inUsername string, inOrganization Organization, inS3db *sql.DB) error {
     var ops                 op.Ops 			  // List of operations gio library uses to know what needs to be shown in a window
-    var inputConfirmBtn     widget.Clickable
-    var clientTextbox       widget.Editor
-    var timeTextbox         widget.Editor
-    var clickCntText        string
+    var ownTheme            windowTheme
+    var signedOut           bool
+    var linkWindows         sessionWindows    // windows opened from this one, they work with this session's rights
 
-    var theme               = material.NewTheme()
-
-    titleText               := "Very Simple showcase app with unnecessarily long title"
-    subTitleText            := fmt.Sprintf("Welcome back %s! We did not miss you!", inUsername)
-    adminTextAllowed        := fmt.Sprintf("Your user ID is %d, probably", inUserID)
-    adminTextDenied         := fmt.Sprintf("Only Admin users can view their ID, you are just a minion")
-    btnText                 := "Confirm"
-    clicksCnt               := 0
+    // Draw every window in the theme the user picked last time
+    if themeName, themeErr := getUserSetting(inS3db, inUserID, settingTheme, widgets.ThemeLight); themeErr != nil {
+        log.Printf("Failed to load the theme setting: %v", themeErr)
+    } else {
+        setTheme(themeName)
+    }
+    // ... and in their language
+    if languageTag, languageErr := getUserSetting(inS3db, inUserID, settingLanguage, i18n.English); languageErr != nil {
+        log.Printf("Failed to load the language setting: %v", languageErr)
+    } else {
+        setLanguage(languageTag)
+    }
+    defer onSettingChange(inWindow.Invalidate)()
 
     // Init Casbin
-    userEnforcer := initCasbinEnforcers()
+    userEnforcer := initCasbinEnforcers(inOrganization.OrganizationID)
+    userEnforcer.SetActor(inUserID)
+    screen       := newMainScreen(inS3db, userEnforcer, inUserID, inUsername, inOrganization)
+
+    // Reload the policy when an admin changes it, the next frame picks up the new decisions
+    policyWatcher, watchErr := watchPolicy(screen.permissions, "data/database/showcase_db", func() {
+        screen.policyChanged.Store(true)
+        inWindow.Invalidate()
+    })
+    if watchErr != nil {
+        log.Printf("Policy changes will only show after a restart: %v", watchErr)
+    } else {
+        defer policyWatcher.Close()
+    }
 
     for {
         event := inWindow.Event()
 
         switch eventType := event.(type) {
-        // This one triggers when the window is closed
+        // This one triggers when the window is closed, which signs the user out and closes the windows opened from it
         case app.DestroyEvent:
+            linkWindows.closeAll()
+            logAuditEvent(inS3db, userEnforcer.auditEvent(auditSignOut, "", "", ""))
+            if signedOut {
+                return errSignedOut
+            }
             return eventType.Err
         // FrameEvent runs before the window is presented on screen
         case app.FrameEvent:
             // This layout context is used for managing the rendering state of the window
             gtx      := app.NewContext(&ops, eventType)
 
-            // Set an action for button click
-            if inputConfirmBtn.Clicked(gtx) && len(clientTextbox.Text()) > 0 && len(timeTextbox.Text()) > 0 {
-                canReportClientName := enforceCasbin(userEnforcer, fmt.Sprintf("u%d", inUserID), "inputbox_client_name", "write")
-                canReportTimeSpent  := enforceCasbin(userEnforcer, fmt.Sprintf("u%d", inUserID), "inputbox_time_spent",  "write")
-
-                if canReportClientName && canReportTimeSpent {
-                    // Increase on click
-                    clicksCnt += 1
-                    clientTextbox.SetText("")
-                    timeTextbox.SetText("")
-                    clickCntText = fmt.Sprintf("Confirmed the report text: %d times", clicksCnt)
-                } else {
-                    clientTextbox.SetText("")
-                    timeTextbox.SetText("")
-                    clickCntText = "You shall not pass!.. the reports"
+            for _, link := range screen.update(gtx) {
+                // Ctrl+Q closes the window, which signs the user out
+                if link == linkSignOut {
+                    signedOut = true
+                    inWindow.Perform(system.ActionClose)
+                    continue
+                }
+                if !signedOut {
+                    openMainWindowLink(&linkWindows, link, inUserID, inS3db, userEnforcer)
                 }
             }
-
-            layout.Flex{
-                // Vertical alignment, from top to bottom
-                Axis: layout.Vertical,
-                // Empty space is left at the start, i.e. at the top
-                Spacing: layout.SpaceStart,
-            }.Layout(gtx,
-                // Title on top - in Flex Layout Flexed objects start filling from the top
-                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-                    maroon := color.NRGBA{R: 127, G: 0, B: 0, A: 255}
-                    return titleElement(gtx, theme, titleText, 1, maroon)
-                }),
-
-                // Empty spacer
-                layout.Flexed(1,layout.Spacer{Height: unit.Dp(10)}.Layout),
-
-                // Subtitle
-                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-                    subColor := color.NRGBA{R: 12, G: 13, B: 114, A: 240}
-                    return titleElement(gtx, theme, subTitleText, 2, subColor)
-                }),
-
-                // Empty spacer
-                layout.Flexed(1,layout.Spacer{Height: unit.Dp(20)}.Layout),
-
-                // Admin text
-                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-                    var adminText        string
-                    var canViewAdminText bool
-                    //  Casbin check
-                    canViewAdminText = enforceCasbin(userEnforcer, fmt.Sprintf("u%d", inUserID), "admin_text", "read")
-
-                    if canViewAdminText {
-                        adminText = adminTextAllowed
-                    } else {
-                        adminText = adminTextDenied
-                    }
-                    newColor := color.NRGBA{R: 127, G: 152, B: 42, A: 250}
-
-                    return reportBoxElement(gtx, theme, adminText, newColor)
-                }),
-
-                // Ticks and clicks count textbox
-                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-                    // Casbin check report text
-                    canViewReportText := enforceCasbin(userEnforcer, fmt.Sprintf("u%d", inUserID), "report_text", "read")
-                    someColor         := color.NRGBA{R: 127, G: 152, B: 0, A: 160}
-
-                    if canViewReportText {
-                        return reportBoxElement(gtx, theme, clickCntText, someColor)
-                    } else {
-                        return layout.Dimensions{}
-                    }
-                }),
-
-                // Empty spacer
-                layout.Rigid(layout.Spacer{Height: unit.Dp(30)}.Layout),
-
-                // Input box
-                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-                    return inputBoxElement(gtx, theme, &clientTextbox, "Input for T&B client name")
-                }),
-
-                // Empty spacer
-                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
-
-                // Input box
-                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-                    return inputBoxElement(gtx, theme, &timeTextbox, "Input for T&B time spent")
-                }),
-
-                // Empty spacer
-                layout.Rigid(layout.Spacer{Height: unit.Dp(50)}.Layout),
-
-                // Button for counting clicks
-                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-                    return btnElement(gtx, theme, &inputConfirmBtn, btnText)
-                }),
-
-                // Empty spacer
-                layout.Rigid(layout.Spacer{Height: unit.Dp(25)}.Layout),
-            )
+            screen.layout(gtx, ownTheme.current())
 
             // Pass the drawing operations to the GPU
             eventType.Frame(gtx.Ops)
@@ -304,118 +184,102 @@ func runApp(inWindow *app.Window, inUserID int, inUsername string, inS3db *sql.D
 }
 
 
-// Functions for specific elements shown in windows
-func errorBoxElement(inGTX layout.Context, inTheme *material.Theme, inErrTxt string) layout.Dimensions {
-    // Define a large label with a text
-    title          := material.H4(inTheme, inErrTxt)
-    // Change the color of the label
-    red            := color.NRGBA{R: 200, G: 0, B: 0, A: 192}
-    title.Color     = red
-    // Change the alignment position of the label
-    title.Alignment = text.Middle
-    // Draw the label to the layout context
-    return title.Layout(inGTX)
+// sessionWindows keeps track of the windows a main window opened, so they don't outlive the user's session
+type sessionWindows struct {
+    mu          sync.Mutex
+    windows     map[*app.Window]bool
+    closed      bool
+    running     sync.WaitGroup
 }
 
+// open runs inRun in a new window in its own go routine, unless the session already ended
+func (s *sessionWindows) open(inRun func(inWindow *app.Window) error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return
+    }
+    if s.windows == nil {
+        s.windows = map[*app.Window]bool{}
+    }
 
-func titleElement(inGTX layout.Context, inTheme *material.Theme, inTxt string, inSize int, inColor color.NRGBA) layout.Dimensions {
-    // Define a large label with a text
-    var title material.LabelStyle
+    linkWindow := new(app.Window)
+    s.windows[linkWindow] = true
+    s.running.Add(1)
 
-    if inSize == 1 {
-        title          = material.H3(inTheme, inTxt)
-    } else {
-        title          = material.H4(inTheme, inTxt)
-    }
-    // Change the color of the label
-    title.Color     = inColor
-    // Change the alignment position of the label
-    title.Alignment = text.Middle
-    // Draw the label to the layout context
-    return title.Layout(inGTX)
-}
+    go func() {
+        defer s.running.Done()
+        err := inRun(linkWindow)
 
+        s.mu.Lock()
+        delete(s.windows, linkWindow)
+        s.mu.Unlock()
 
-func reportBoxElement(inGTX layout.Context, inTheme *material.Theme, inClickCntText string, inColor color.NRGBA) layout.Dimensions {
-    // Define a large label with a text
-    changingTxt          := material.Label(inTheme, unit.Sp(inGTX.Sp(12)), inClickCntText)
-    // Change the color of the label
-    changingTxt.Color     = inColor
-    // Change the alignment position of the label
-    changingTxt.Alignment = text.Middle
-    // Draw the label to the layout context
-    return changingTxt.Layout(inGTX)
+        if err != nil {
+            log.Print(err)
+        }
+    }()
 }
 
+// closeAll closes the open windows and waits until they are gone, no new ones open afterwards
+func (s *sessionWindows) closeAll() {
+    s.mu.Lock()
+    s.closed = true
+    for linkWindow := range s.windows {
+        linkWindow.Perform(system.ActionClose)
+    }
+    s.mu.Unlock()
 
-func inputBoxElement(inGTX layout.Context, inTheme *material.Theme, inInputTextbox *widget.Editor, inHintText string) layout.Dimensions {
-    // This part keeps it in the center without stretching the widget to the window's edge
-    return layout.Flex{
-        Axis:    layout.Horizontal,
-        Spacing: layout.SpaceAround,
-    }.Layout(inGTX,
-        // Empty flexible space to push content to center
-        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-            return layout.Dimensions{}
-        }),
-        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-            // Set fixed width
-            gtx.Constraints.Min.X = gtx.Dp(300)
-            gtx.Constraints.Max.X = gtx.Dp(300)
-            // Define an editable input box
-            inputBox             := material.Editor(inTheme, inInputTextbox, inHintText)
-            // Change the font of a box's text
-            inputBox.Font         = font.Font{Typeface: "Light"}
-            // Put the box in the middle of the window
-            inInputTextbox.Alignment = text.Middle
-            // Add a border so the box actually has a form
-            border := widget.Border{
-                Color:        color.NRGBA{R: 204, G: 204, B: 204, A: 255},
-                CornerRadius: unit.Dp(3),
-                Width:        unit.Dp(2),
-            }
-            // Draw the input box to the layout context, but put it in the center of the window
-            return border.Layout(gtx, inputBox.Layout)
-        }),
-        // Empty flexible space to balance layout
-        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-            return layout.Dimensions{}
-        }),
-    )
+    s.running.Wait()
 }
 
 
-func btnElement(inGTX layout.Context, inTheme *material.Theme, inInputConfirmBtn *widget.Clickable, inBtnText string) layout.Dimensions {
-    return layout.Flex{
-        Axis:    layout.Horizontal,
-        Spacing: layout.SpaceAround,
-    }.Layout(inGTX,
-        // Empty flexible space to push content to center
-        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-            return layout.Dimensions{}
-        }),
-        // Actual button with fixed width
-        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-            // Set fixed width
-            gtx.Constraints.Min.X   = gtx.Dp(150)
-            gtx.Constraints.Max.X   = gtx.Dp(150)
-            // Define a button with a text
-            btn                    := material.Button(inTheme, inInputConfirmBtn, inBtnText)
-            // Change the font of a button's text
-            btn.Font                = font.Font{Typeface: "ExtraLight"}
-            btn.Inset               = layout.UniformInset(10)
-            // Draw the button to the layout context in the center of the window
-            return btn.Layout(gtx)
-        }),
-        // Empty flexible space to balance layout
-        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-            return layout.Dimensions{}
-        }),
-    )
+// openMainWindowLink opens the window a main window button asked for, in its own go routine
+func openMainWindowLink(inWindows *sessionWindows, inLink mainWindowLink, inUserID int, inS3db *sql.DB, inEnforcer *OrgEnforcer) {
+    var run func(inWindow *app.Window) error
+
+    switch inLink {
+    case linkApprovals:
+        run = func(inWindow *app.Window) error { return runTimesheetApproval(inWindow, inUserID, inS3db, inEnforcer) }
+    case linkBilling:
+        run = func(inWindow *app.Window) error { return runBilling(inWindow, inS3db, inEnforcer) }
+    case linkExport:
+        run = func(inWindow *app.Window) error { return runExport(inWindow, inUserID, inS3db, inEnforcer) }
+    case linkImport:
+        run = func(inWindow *app.Window) error { return runImport(inWindow, inUserID, inS3db, inEnforcer) }
+    case linkReports:
+        run = func(inWindow *app.Window) error { return runDashboard(inWindow, inUserID, inS3db, inEnforcer) }
+    case linkCheckAccess:
+        run = func(inWindow *app.Window) error { return runCheckAccess(inWindow, inS3db, inEnforcer) }
+    case linkRoles:
+        run = func(inWindow *app.Window) error { return runRoles(inWindow, inS3db, inEnforcer) }
+    case linkPolicies:
+        run = func(inWindow *app.Window) error { return runPolicies(inWindow, inS3db, inEnforcer) }
+    case linkAuditLog:
+        run = func(inWindow *app.Window) error { return runAuditLog(inWindow, inS3db, inEnforcer) }
+    case linkMyWeek:
+        run = func(inWindow *app.Window) error { return runMyWeek(inWindow, inUserID, inS3db, inEnforcer) }
+    case linkNotifications:
+        run = func(inWindow *app.Window) error { return runNotifications(inWindow, inUserID, inS3db, inEnforcer) }
+    case linkSettings:
+        run = func(inWindow *app.Window) error { return runSettings(inWindow, inUserID, inS3db) }
+    default:
+        return
+    }
+
+    inWindows.open(run)
 }
 
 
 // DB functions
+
+// dbRunner is what *sql.DB and *sql.Tx have in common, so a function can run inside or outside a transaction
+type dbRunner interface {
+    Exec(query string, args ...any) (sql.Result, error)
+    Query(query string, args ...any) (*sql.Rows, error)
+    QueryRow(query string, args ...any) *sql.Row
+}
+
 func openDb () *sql.DB {
 
     db, err := sql.Open("sqlite3", "data/database/showcase_db")
@@ -461,49 +325,39 @@ func runReturnQuery(query string, db *sql.DB) *sql.Rows {
 func checkSignIn(inUsername string, inPassword string, inDB *sql.DB) (bool, int) {
     var userId int
 
-    signInQuery := fmt.Sprintf(`
+    // The credentials are bound as parameters, so quotes in them can't change the query
+    scanErr := inDB.QueryRow(`
 SELECT
     user_id
 FROM
     user_dim
 WHERE
-        username = '%s'
-    AND password = '%s'
-	`, inUsername, inPassword)
-
-    results := runReturnQuery(signInQuery, inDB)
-
-    results.Next()
-    scanErr := results.Scan(&userId)
+        username = ?
+    AND password = ?
+	`, inUsername, inPassword).Scan(&userId)
     if scanErr != nil {
         log.Print(scanErr)
+        logAuditEvent(inDB, AuditEvent{ActorName: inUsername, EventType: auditSignInFailed})
         return false, 0
     }
-    fmt.Printf("UserId = %d\n", userId)
+    logAuditEvent(inDB, AuditEvent{ActorID: userId, ActorName: inUsername, EventType: auditSignIn})
 
     return true, userId
 }
 
 //Casbin functions
-func initCasbinEnforcers() *casbin.Enforcer {
-    // Create connection to DB for Gorm
-    casbinDB, dbOpenErr := gorm.Open(sqlite.Open("data/database/showcase_db"), &gorm.Config{})
-    if dbOpenErr        != nil {
-       log.Fatalf("Failed to connect to database for Casbin: %v", dbOpenErr)
-    }
-    db, _ := casbinDB.DB()
-    defer db.Close()
-
+func initCasbinEnforcers(inOrganizationID int) *OrgEnforcer {
     userAdapter, userAdapterErr := NewCustomAdapter("data/database/showcase_db")
     if userAdapterErr           != nil {
       log.Fatalf("Failed to create userAdapter: %v", userAdapterErr)
     }
 
     // Load Casbin userEnforcer
-    userEnforcer, userEnforcerErr := casbin.NewEnforcer("data/steaby_casbin_model.conf", userAdapter)
+    userEnforcer, userEnforcerErr := casbin.NewSyncedEnforcer("data/steaby_casbin_model.conf", userAdapter)
     if userEnforcerErr            != nil {
         log.Fatalf("Failed to create user enforcer: %v", userEnforcerErr)
     }
+    registerConditionFunctions(userEnforcer)
 
     // Load user policies from DB
     userPoliciesErr    := userEnforcer.LoadPolicy()
@@ -511,17 +365,7 @@ func initCasbinEnforcers() *casbin.Enforcer {
         log.Fatalf("Failed to load user policy: %v", userPoliciesErr)
     }
 
-    return userEnforcer
-}
-
-func enforceCasbin(inEnforcer *casbin.Enforcer, subject string, object string, action string) bool {
-    ok, enfErr := inEnforcer.Enforce(subject, object, action)
-    if enfErr != nil {
-        log.Fatalf("Failed to check the policy: %v", enfErr)
-    }
-    fmt.Printf("ok? = %v\n", ok)
-
-    return ok
+    return &OrgEnforcer{SyncedEnforcer: userEnforcer, OrganizationID: inOrganizationID}
 }
 
 
@@ -529,7 +373,20 @@ func enforceCasbin(inEnforcer *casbin.Enforcer, subject string, object string, a
 
 // CustomAdapter Define structure and functions for custom policy adapter
 type CustomAdapter struct {
-    db *sql.DB
+    db              *sql.DB
+    actorID         int     // user the changes are audited for, see OrgEnforcer.SetActor
+    organizationID  int
+}
+
+// auditRule records a rule the adapter added or removed in the same transaction as the change
+func (a *CustomAdapter) auditRule(inDB dbRunner, inEventType string, inPtype string, inRule []string) error {
+    event := AuditEvent{ActorID: a.actorID, OrganizationID: a.organizationID, EventType: inEventType, Object: policyObject}
+    if inEventType == auditPolicyRemove {
+        event.Before = casbinRuleAuditValue(inPtype, inRule)
+    } else {
+        event.After = casbinRuleAuditValue(inPtype, inRule)
+    }
+    return recordAuditEvent(inDB, event)
 }
 
 func NewCustomAdapter(dbPath string) (*CustomAdapter, error) {
@@ -546,82 +403,210 @@ func (a *CustomAdapter) Close() error {
 
 // LoadPolicy loads all policies from the database into Casbin
 func (a *CustomAdapter) LoadPolicy(model model.Model) error {
-    rows, err := a.db.Query("SELECT subject, object, action, effect FROM casbin_rule")
+    rules, err := queryCasbinRules(a.db)
     if err != nil {
         return err
     }
+
+    for _, rule := range rules {
+        if err := persist.LoadPolicyArray(rule, model); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// queryCasbinRules returns every stored rule the way Casbin has it, ptype first
+func queryCasbinRules(inDB dbRunner) ([][]string, error) {
+    rows, err := inDB.Query("SELECT ptype, subject, domain, object, action, effect, condition FROM casbin_rule")
+    if err != nil {
+        return nil, err
+    }
     defer rows.Close()
 
+    var rules [][]string
     for rows.Next() {
-        var line, sub, obj, act, eff    string
-        var nil1, nil2                  interface{}
+        var ptype, sub, obj             string
+        var dom, act, eff, cond         sql.NullString
+
+        if err := rows.Scan(&ptype, &sub, &dom, &obj, &act, &eff, &cond); err != nil {
+            return nil, err
+        }
 
-        isUserPolicy := true
+        // Role mappings hold in their organization's domain, resource groups everywhere.
+        // Rules go in as arrays, conditions may hold commas and quotes a policy line would split on.
+        var rule []string
+        switch ptype {
+        case "p":
+            rule = []string{ptype, sub, dom.String, obj, act.String, eff.String, policyCondition(cond.String)}
+        case "g":
+            rule = []string{ptype, sub, obj, dom.String}
+        default:
+            rule = []string{ptype, sub, obj}
+        }
+        rules = append(rules, rule)
+    }
 
-        if err := rows.Scan(&sub, &obj, &act, &eff); err != nil {
-            // If error was encountered it means that action and effect are missing -> which means that we are trying to load a role policy
-            isUserPolicy = false
+    return rules, rows.Err()
+}
 
-            s2err := rows.Scan(&sub, &obj, &nil1, &nil2)
-            if s2err != nil {
-                return s2err
-            }
+// SavePolicy replaces every rule in the auth tables with the model's p and g rules. Project groups (g2) stay
+// as they are, they come from the projects.
+func (a *CustomAdapter) SavePolicy(model model.Model) error {
+    tx, err := a.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    for _, table := range []string{"auth_user_policy", "auth_role_policy", "auth_user_role_map_policy", "auth_role_inheritance"} {
+        if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+            return err
         }
+    }
 
-        if isUserPolicy {
-            line = fmt.Sprintf("p, %s, %s, %s, %s", sub, obj, act, eff) // Casbin policy format
-        } else {
-            line = fmt.Sprintf("g, %s, %s", sub, obj)
+    count := 0
+    for _, sec := range []string{"p", "g"} {
+        for ptype, assertion := range model[sec] {
+            if ptype == "g2" {
+                continue
+            }
+            for _, rule := range assertion.Policy {
+                if err := insertCasbinRule(tx, ptype, rule); err != nil {
+                    return err
+                }
+                count++
+            }
         }
+    }
 
-        persist.LoadPolicyLine(line, model)
+    event := AuditEvent{ActorID: a.actorID, OrganizationID: a.organizationID, EventType: auditPolicySave, Object: policyObject,
+        After: fmt.Sprintf("%d rules", count)}
+    if err := recordAuditEvent(tx, event); err != nil {
+        return err
     }
 
-    return nil
+    return tx.Commit()
 }
 
-// SavePolicy saves all policies
-// TODO will need improvement
-func (a *CustomAdapter) SavePolicy(model model.Model) error {
-    _, err := a.db.Exec("DELETE FROM casbin_rule") // Clear existing policies
+// AddPolicy inserts a single rule into the auth table it belongs to
+func (a *CustomAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+    return a.AddPolicies(sec, ptype, [][]string{rule})
+}
+
+// AddPolicies inserts the rules in one transaction, Casbin's batch calls and role helpers go through it
+func (a *CustomAdapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+    tx, err := a.db.Begin()
     if err != nil {
         return err
     }
+    defer tx.Rollback()
 
-    stmt, err := a.db.Prepare("INSERT INTO casbin_rule (subject, object, action, effect) VALUES (?, ?, ?, ?)")
+    for _, rule := range rules {
+        if err := insertCasbinRule(tx, ptype, rule); err != nil {
+            return err
+        }
+        if err := a.auditRule(tx, auditPolicyAdd, ptype, rule); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+// RemovePolicy deletes a single rule from the auth table it belongs to
+func (a *CustomAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
+    return a.RemovePolicies(sec, ptype, [][]string{rule})
+}
+
+// RemovePolicies deletes the rules in one transaction, e.g. when Casbin takes all roles of a user in a domain
+func (a *CustomAdapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+    tx, err := a.db.Begin()
     if err != nil {
         return err
     }
-    defer stmt.Close()
+    defer tx.Rollback()
 
-    for _, assertion := range model["p"]["p"].Policy {
-        _, err := stmt.Exec(assertion[0], assertion[1], assertion[2], assertion[3])
-        if err != nil {
+    for _, rule := range rules {
+        if err := deleteCasbinRule(tx, ptype, rule); err != nil {
+            return err
+        }
+        if err := a.auditRule(tx, auditPolicyRemove, ptype, rule); err != nil {
             return err
         }
     }
 
-    return nil
+    return tx.Commit()
 }
 
-// AddPolicy inserts a single policy rule
-// TODO will need improvement
-func (a *CustomAdapter) AddPolicy(sec string, ptype string, rule []string) error {
-    _, err := a.db.Exec("INSERT INTO casbin_rule (subject, object, action, effect) VALUES (?, ?, ?, ?)", rule[0], rule[1], rule[2], rule[3])
-    return err
+// RemoveFilteredPolicy deletes the rules whose fields from fieldIndex on are the non-empty fieldValues, e.g. every
+// role of a user in a domain, in one transaction
+func (a *CustomAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+    tx, err := a.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    rules, err := queryCasbinRules(tx)
+    if err != nil {
+        return err
+    }
+    for _, rule := range rules {
+        if rule[0] != ptype || !ruleMatchesFilter(rule[1:], fieldIndex, fieldValues) {
+            continue
+        }
+        if err := deleteCasbinRule(tx, ptype, rule[1:]); err != nil {
+            return err
+        }
+        if err := a.auditRule(tx, auditPolicyRemove, ptype, rule[1:]); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
 }
 
-// RemovePolicy deletes a policy rule
-// TODO will need improvement
-func (a *CustomAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
-    _, err := a.db.Exec("DELETE FROM casbin_rule WHERE subject = ? AND object = ? AND action = ? AND effect = ?", rule[0], rule[1], rule[2], rule[3])
-    return err
+// ruleMatchesFilter is Casbin's field filter, an empty value matches any field
+func ruleMatchesFilter(inRule []string, inFieldIndex int, inFieldValues []string) bool {
+    for i, value := range inFieldValues {
+        if value == "" {
+            continue
+        }
+        if inFieldIndex+i >= len(inRule) || inRule[inFieldIndex+i] != value {
+            return false
+        }
+    }
+    return true
 }
 
-// RemoveFilteredPolicy removes a filtered policy
-// TODO will need improvement
-func (a *CustomAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
-    return errors.New("don't tell my boss, but this is not implemented")
+// ApplyPolicyDiff removes and adds the diff's rules in one transaction, so an import is applied whole or not at all
+func (a *CustomAdapter) ApplyPolicyDiff(inDiff PolicyDiff) error {
+    tx, err := a.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    for _, rule := range inDiff.Removed {
+        if err := deleteCasbinRule(tx, rule[0], rule[1:]); err != nil {
+            return err
+        }
+        if err := a.auditRule(tx, auditPolicyRemove, rule[0], rule[1:]); err != nil {
+            return err
+        }
+    }
+    for _, rule := range inDiff.Added {
+        if err := insertCasbinRule(tx, rule[0], rule[1:]); err != nil {
+            return err
+        }
+        if err := a.auditRule(tx, auditPolicyAdd, rule[0], rule[1:]); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
 }
 
 //</editor-fold>
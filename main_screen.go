@@ -0,0 +1,589 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "gioui.org/io/key"
+    "gioui.org/layout"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "showcase_desktop/widgets"
+    "sync/atomic"
+    "time"
+)
+
+
+// Guards of the main window's elements
+var (
+    adminTextGuard          = Guard{Object: "admin_text",           View: "read"}
+    reportTextGuard         = Guard{Object: "report_text",          View: "read"}
+    clientNameGuard         = Guard{Object: "inputbox_client_name", View: "read", Use: "write"}
+    timeSpentGuard          = Guard{Object: "inputbox_time_spent",  View: "read", Use: "write"}
+    submitWeekGuard         = Guard{Object: timesheetObject,        View: timesheetActSubmit}
+    approvalsGuard          = Guard{Object: timesheetObject,        View: timesheetActApprove}
+    billingGuard            = Guard{Object: invoiceObject,          View: "write"}
+    exportOwnGuard          = Guard{Object: timeEntryObject,        View: "read"}
+    exportTeamGuard         = Guard{Object: teamTimeEntryObject,    View: "read"}
+    importGuard             = Guard{Object: timeEntryObject,        View: "write"}
+    reportHoursGuard        = Guard{Object: reportHoursByClient,    View: "read"}
+    reportBillableGuard     = Guard{Object: reportBillable,         View: "read"}
+    checkAccessGuard        = Guard{Object: checkAccessObject,      View: "read"}
+    rolesGuard              = Guard{Object: roleObject,             View: "write"}
+    policiesGuard           = Guard{Object: policyObject,           View: "write"}
+    myWeekGuard             = Guard{Object: timeEntryObject,        View: "read"}
+    auditLogGuard           = Guard{Object: auditObject,            View: "read"}
+)
+
+// Everything the main window asks Casbin about, decided once per policy version
+var mainWindowChecks = guardChecks(adminTextGuard, reportTextGuard, clientNameGuard, timeSpentGuard, submitWeekGuard, approvalsGuard,
+    billingGuard, exportOwnGuard, exportTeamGuard, importGuard, reportHoursGuard, reportBillableGuard, checkAccessGuard, rolesGuard,
+    policiesGuard, myWeekGuard, auditLogGuard)
+
+// mainWindowLink is what the main window's buttons and shortcuts ask runApp for, another window or signing out
+type mainWindowLink int
+
+const (
+    linkApprovals mainWindowLink = iota
+    linkBilling
+    linkExport
+    linkImport
+    linkReports
+    linkCheckAccess
+    linkRoles
+    linkPolicies
+    linkAuditLog
+    linkMyWeek
+    linkNotifications
+    linkSettings
+    linkSignOut
+)
+
+
+// mainScreen is the state of the main window of a signed in user. Opening other windows is left to runApp,
+// so tests can drive the screen without a display.
+type mainScreen struct {
+    db                  *sql.DB
+    enforcer            *OrgEnforcer
+    permissions         *PermissionCache
+    picker              *projectPicker      // client -> project -> task selectors above the inputs
+    userID              int
+    inputConfirmBtn     widget.Clickable
+    clientTextbox       guardedEditor
+    timeTextbox         guardedEditor
+    submitWeekBtn       widget.Clickable
+    approvalsBtn        widget.Clickable
+    billingBtn          widget.Clickable
+    exportBtn           widget.Clickable
+    importBtn           widget.Clickable
+    reportsBtn          widget.Clickable
+    notificationsBtn    widget.Clickable
+    settingsBtn         widget.Clickable
+    checkAccessBtn      widget.Clickable
+    rolesBtn            widget.Clickable
+    policiesBtn         widget.Clickable
+    myWeekBtn           widget.Clickable
+    auditLogBtn         widget.Clickable
+    adminWhyBtn         widget.Clickable
+    deniedWhyBtn        widget.Clickable
+    deniedCheck         PermissionCheck     // last check that stopped the user, explained by "Why?"
+    whyText             string
+    clickCntText        string
+    weekText            string
+    budgetText          string
+    subTitleText        string
+    clicksCnt           int
+    timerStart          time.Time           // zero while the timer is stopped
+    timerText           string
+    helpOpen            bool                // the shortcut help overlay is shown
+    perms               PermissionSnapshot
+    policyChanged       atomic.Bool         // set by the policy watcher, the next frame picks up the new decisions
+}
+
+func newMainScreen(inS3db *sql.DB, inEnforcer *OrgEnforcer, inUserID int, inUsername string, inOrganization Organization) *mainScreen {
+    s := &mainScreen{
+        db:             inS3db,
+        enforcer:       inEnforcer,
+        permissions:    NewPermissionCache(inEnforcer),
+        picker:         newProjectPicker(inS3db, inOrganization.OrganizationID),
+        userID:         inUserID,
+        subTitleText:   tr().Sprintf("Welcome back to %s, %s! We did not miss you!", inOrganization.OrganizationName, inUsername),
+    }
+    s.refreshPermissions()
+    s.refreshWeek()
+    s.refreshBudget()
+
+    // Enter in the client moves on to the time, Enter in the time confirms
+    for _, editor := range []*widget.Editor{&s.clientTextbox.Editor, &s.timeTextbox.Editor} {
+        editor.SingleLine = true
+        editor.Submit     = true
+    }
+
+    return s
+}
+
+// focusOrder goes through the inputs and Confirm, then the window buttons from top to bottom.
+// What the user may not use is left out, the picker's options are picked with the mouse.
+func (s *mainScreen) focusOrder() focusOrder {
+    var order focusOrder
+    if s.perms.State(clientNameGuard) == GuardEnabled {
+        order = append(order, &s.clientTextbox.Editor)
+    }
+    if s.perms.State(timeSpentGuard) == GuardEnabled {
+        order = append(order, &s.timeTextbox.Editor)
+    }
+    if s.perms.State(clientNameGuard) == GuardEnabled && s.perms.State(timeSpentGuard) == GuardEnabled {
+        order = append(order, &s.inputConfirmBtn)
+    }
+
+    for _, opener := range []struct {
+        btn     *widget.Clickable
+        guards  []Guard
+    }{
+        {&s.myWeekBtn,          []Guard{myWeekGuard}},
+        {&s.submitWeekBtn,      []Guard{submitWeekGuard}},
+        {&s.approvalsBtn,       []Guard{approvalsGuard}},
+        {&s.billingBtn,         []Guard{billingGuard}},
+        {&s.exportBtn,          []Guard{exportOwnGuard, exportTeamGuard}},
+        {&s.importBtn,          []Guard{importGuard}},
+        {&s.reportsBtn,         []Guard{reportHoursGuard, reportBillableGuard}},
+        {&s.checkAccessBtn,     []Guard{checkAccessGuard}},
+        {&s.rolesBtn,           []Guard{rolesGuard}},
+        {&s.policiesBtn,        []Guard{policiesGuard}},
+        {&s.auditLogBtn,        []Guard{auditLogGuard}},
+    } {
+        if s.perms.State(opener.guards...) == GuardEnabled {
+            order = append(order, opener.btn)
+        }
+    }
+
+    return append(order, &s.notificationsBtn, &s.settingsBtn)
+}
+
+// shortcutHelp is what the help overlay lists
+func (s *mainScreen) shortcutHelp() []shortcutHelp {
+    return []shortcutHelp{
+        {nextFocusShortcut.keys(),      tr().Text("Next input or button")},
+        {previousFocusShortcut.keys(),  tr().Text("Previous input or button")},
+        {submitShortcut.keys(),         tr().Text("Log the time, in the time input")},
+        {newEntryShortcut.keys(),       tr().Text("New entry")},
+        {timerShortcut.keys(),          tr().Text("Start or stop the timer")},
+        {signOutShortcut.keys(),        tr().Text("Sign out")},
+        {helpShortcut.keys(),           tr().Text("Show or hide the shortcuts")},
+    }
+}
+
+// toggleTimer starts the timer, or stops it and puts the time it ran into the time input, ready to confirm
+func (s *mainScreen) toggleTimer(inGTX layout.Context) {
+    if s.perms.State(timeSpentGuard) != GuardEnabled {
+        return
+    }
+
+    if s.timerStart.IsZero() {
+        s.timerStart = inGTX.Now
+        s.timerText  = tr().Sprintf("Timer started at %s, %s stops it", s.timerStart.Format("15:04"), timerShortcut.keys())
+        return
+    }
+
+    // Whole minutes, at least one so the entry can be logged
+    minutes     := max(int(inGTX.Now.Sub(s.timerStart).Round(time.Minute).Minutes()), 1)
+    s.timerStart = time.Time{}
+    s.timerText  = tr().Sprintf("Timer stopped after %s, press Enter to log it", tr().Duration(minutes))
+    s.timeTextbox.SetText(fmt.Sprintf("%d:%02d", minutes/60, minutes%60))
+    inGTX.Execute(key.FocusCmd{Tag: &s.timeTextbox.Editor})
+}
+
+// refreshPermissions rebuilds the snapshot frames read - it is rebuilt whenever the policy changes
+func (s *mainScreen) refreshPermissions() {
+    var permErr error
+    s.perms, permErr = s.permissions.Snapshot(fmt.Sprintf("u%d", s.userID), mainWindowChecks)
+    if permErr != nil {
+        log.Printf("Failed to check the policy: %v", permErr)
+    }
+
+    // Read-only inputs get their "Why?" up front
+    s.deniedCheck = PermissionCheck{}
+    for _, guard := range []Guard{clientNameGuard, timeSpentGuard} {
+        if s.perms.State(guard) == GuardDisabled {
+            s.deniedCheck = PermissionCheck{guard.Object, guard.Use}
+            break
+        }
+    }
+}
+
+// refreshWeek loads the current week's timesheet - shown under the report text and refreshed after every change
+func (s *mainScreen) refreshWeek() {
+    currentWeek, weekErr := getOrCreateTimesheet(s.db, s.enforcer.OrganizationID, s.userID, time.Now())
+    if weekErr != nil {
+        log.Print(weekErr)
+        s.weekText = tr().Text("Could not load this week's timesheet")
+        return
+    }
+    s.weekText = tr().Sprintf("Week of %s: %s, %s logged", tr().Date(currentWeek.WeekStart), timesheetStateText(currentWeek.State), tr().Duration(currentWeek.TotalMinutes))
+    if currentWeek.State == timesheetRejected && len(currentWeek.ReviewNote) > 0 {
+        s.weekText += tr().Sprintf(" (rejected: %s)", currentWeek.ReviewNote)
+    }
+}
+
+// refreshBudget lists the projects past a budget threshold - empty when all are fine
+func (s *mainScreen) refreshBudget() {
+    statuses, budgetErr := budgetStatuses(s.db, s.enforcer, s.userID)
+    if budgetErr != nil {
+        log.Print(budgetErr)
+        s.budgetText = tr().Text("Could not load the project budgets")
+        return
+    }
+    s.budgetText = budgetAlertText(statuses)
+}
+
+// confirm logs the time of the inputs, or tells the user why they may not
+func (s *mainScreen) confirm() {
+    s.whyText = ""
+
+    // Confirm is disabled without write on both inputs, this only guards against a stale snapshot
+    if s.perms.State(clientNameGuard) != GuardEnabled || s.perms.State(timeSpentGuard) != GuardEnabled {
+        s.clickCntText = tr().Text("You shall not pass!.. the reports")
+        s.deniedCheck  = PermissionCheck{"inputbox_client_name", "write"}
+        if s.perms.Can("inputbox_client_name", "write") {
+            s.deniedCheck = PermissionCheck{"inputbox_time_spent", "write"}
+        }
+        return
+    }
+
+    minutes, parseErr := parseTimeSpent(s.timeTextbox.Text())
+    if parseErr != nil {
+        s.clickCntText = errorText(parseErr)
+        return
+    }
+
+    // Time goes on the picked task if there is one, otherwise on the client only
+    var addErr error
+    if taskID := s.picker.TaskID(s.clientTextbox.Text()); taskID != 0 {
+        _, addErr = addTaskTimeEntry(s.db, s.enforcer, s.userID, taskID, time.Now(), minutes)
+    } else {
+        _, addErr = addTimeEntry(s.db, s.enforcer.OrganizationID, s.userID, s.clientTextbox.Text(), time.Now(), minutes)
+    }
+    if addErr != nil {
+        s.clickCntText = tr().Sprintf("Could not log the time: %s", errorText(addErr))
+        return
+    }
+
+    // Increase on click
+    s.clicksCnt   += 1
+    s.clickCntText = tr().Plural(s.clicksCnt, "Logged %[2]s for %[3]s, confirmed %[1]d time", "Logged %[2]s for %[3]s, confirmed %[1]d times",
+        s.clicksCnt, tr().Duration(minutes), s.clientTextbox.Text())
+    s.clientTextbox.SetText("")
+    s.timeTextbox.SetText("")
+    s.refreshWeek()
+    s.refreshBudget()
+}
+
+// update handles the clicks of the frame and returns the windows the user asked to open
+func (s *mainScreen) update(inGTX layout.Context) []mainWindowLink {
+    // The policy was reloaded since the last frame
+    if s.policyChanged.Swap(false) {
+        s.refreshPermissions()
+        s.refreshBudget()
+    }
+
+    // Picking a client fills in the client name
+    if s.picker.update(inGTX, s.db, s.enforcer, s.userID) {
+        s.clientTextbox.SetText(s.picker.ClientName())
+    }
+
+    // Keyboard shortcuts, wherever the focus is
+    var links []mainWindowLink
+    for _, ev := range shortcutEvents(inGTX, nextFocusShortcut, previousFocusShortcut, newEntryShortcut, timerShortcut,
+        signOutShortcut, helpShortcut, closeHelpShortcut) {
+        switch {
+        case nextFocusShortcut.pressed(ev), previousFocusShortcut.pressed(ev):
+            s.focusOrder().move(inGTX, previousFocusShortcut.pressed(ev))
+        case newEntryShortcut.pressed(ev):
+            s.clientTextbox.SetText("")
+            s.timeTextbox.SetText("")
+            inGTX.Execute(key.FocusCmd{Tag: &s.clientTextbox.Editor})
+        case timerShortcut.pressed(ev):
+            s.toggleTimer(inGTX)
+        case signOutShortcut.pressed(ev):
+            links = append(links, linkSignOut)
+        case helpShortcut.pressed(ev):
+            s.helpOpen = !s.helpOpen
+        case closeHelpShortcut.pressed(ev):
+            s.helpOpen = false
+        }
+    }
+
+    // Enter in the client moves on to the time, the button is asked first like on the sign in screen
+    confirmClicked := s.inputConfirmBtn.Clicked(inGTX)
+    if submitted(inGTX, &s.clientTextbox.Editor) {
+        inGTX.Execute(key.FocusCmd{Tag: &s.timeTextbox.Editor})
+    }
+
+    // Set an action for button click, or for Enter in the time
+    if (confirmClicked || submitted(inGTX, &s.timeTextbox.Editor)) && len(s.clientTextbox.Text()) > 0 && len(s.timeTextbox.Text()) > 0 {
+        s.confirm()
+    }
+
+    // Explain why the user was stopped
+    if s.adminWhyBtn.Clicked(inGTX) {
+        s.whyText = explainAccessText(s.db, s.enforcer, fmt.Sprintf("u%d", s.userID), "admin_text", "read")
+    }
+    if s.deniedWhyBtn.Clicked(inGTX) {
+        s.whyText = explainAccessText(s.db, s.enforcer, fmt.Sprintf("u%d", s.userID), s.deniedCheck.Object, s.deniedCheck.Action)
+    }
+
+    // Submit the current week for approval
+    if s.submitWeekBtn.Clicked(inGTX) {
+        currentWeek, weekErr := getOrCreateTimesheet(s.db, s.enforcer.OrganizationID, s.userID, time.Now())
+        if weekErr == nil {
+            _, weekErr = transitionTimesheet(s.db, s.enforcer, s.userID, currentWeek.TimesheetID, timesheetActSubmit, "")
+        }
+        if weekErr != nil {
+            s.clickCntText = tr().Sprintf("Could not submit the week: %s", errorText(weekErr))
+        }
+        s.refreshWeek()
+    }
+
+    // Buttons that open another window
+    for _, opener := range []struct {
+        link    mainWindowLink
+        btn     *widget.Clickable
+    }{
+        {linkApprovals,     &s.approvalsBtn},
+        {linkBilling,       &s.billingBtn},
+        {linkExport,        &s.exportBtn},
+        {linkImport,        &s.importBtn},
+        {linkReports,       &s.reportsBtn},
+        {linkCheckAccess,   &s.checkAccessBtn},
+        {linkRoles,         &s.rolesBtn},
+        {linkPolicies,      &s.policiesBtn},
+        {linkAuditLog,      &s.auditLogBtn},
+        {linkMyWeek,        &s.myWeekBtn},
+        {linkNotifications, &s.notificationsBtn},
+        {linkSettings,      &s.settingsBtn},
+    } {
+        if opener.btn.Clicked(inGTX) {
+            links = append(links, opener.link)
+        }
+    }
+
+    return links
+}
+
+func (s *mainScreen) layout(inGTX layout.Context, inTheme *widgets.Theme) layout.Dimensions {
+    return layout.Stack{}.Layout(inGTX,
+        layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+            return s.layoutWindow(gtx, inTheme)
+        }),
+
+        // Shortcut help over the window, until F1 or Esc closes it
+        layout.Expanded(func(gtx layout.Context) layout.Dimensions {
+            if !s.helpOpen {
+                return layout.Dimensions{}
+            }
+            return shortcutHelpElement(gtx, inTheme, tr().Text("Keyboard shortcuts"), s.shortcutHelp())
+        }),
+    )
+}
+
+func (s *mainScreen) layoutWindow(inGTX layout.Context, inTheme *widgets.Theme) layout.Dimensions {
+    titleText               := tr().Text("Very Simple showcase app with unnecessarily long title")
+    adminTextAllowed        := tr().Sprintf("Your user ID is %d, probably", s.userID)
+    adminTextDenied         := tr().Text("Only Admin users can view their ID, you are just a minion")
+    btnText                 := tr().Text("Confirm")
+    helpText                := tr().Sprintf("Press %s for the keyboard shortcuts", helpShortcut.keys())
+    theme                   := inTheme
+    perms                   := s.perms
+
+    return layout.Flex{
+        // Vertical alignment, from top to bottom
+        Axis: layout.Vertical,
+        // Empty space is left at the start, i.e. at the top
+        Spacing: layout.SpaceStart,
+    }.Layout(inGTX,
+        // Title on top - in Flex Layout Flexed objects start filling from the top
+        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+            return widgets.Title(theme, titleText, widgets.TitleLarge, theme.Title).Layout(gtx)
+        }),
+
+        // Empty spacer
+        layout.Flexed(1,layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+        // Subtitle
+        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+            return widgets.Title(theme, s.subTitleText, widgets.TitleSmall, theme.Subtitle).Layout(gtx)
+        }),
+
+        // Empty spacer
+        layout.Flexed(1,layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+        // Admin text
+        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+            if perms.State(adminTextGuard) == GuardEnabled {
+                return widgets.ReportBox(theme, adminTextAllowed, theme.Success).Layout(gtx)
+            }
+
+            // Denied text with a "Why?" next to it
+            return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, adminTextDenied, theme.Success).Layout(gtx)
+                }),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return layout.UniformInset(unit.Dp(3)).Layout(gtx, material.Button(theme.Theme, &s.adminWhyBtn, tr().Text("Why?")).Layout)
+                }),
+            )
+        }),
+
+        // Ticks and clicks count textbox
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedLabelElement(gtx, theme, perms, reportTextGuard, s.clickCntText, theme.Success)
+        }),
+
+        // "Why?" for the last denied action and its answer
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            if len(s.deniedCheck.Object) == 0 {
+                return layout.Dimensions{}
+            }
+            return widgets.Button(theme, &s.deniedWhyBtn, tr().Text("Why?")).Layout(gtx)
+        }),
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            if len(s.whyText) == 0 {
+                return layout.Dimensions{}
+            }
+            return widgets.ReportBox(theme, s.whyText, theme.Subtitle).Layout(gtx)
+        }),
+
+        // Current week's timesheet state
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return widgets.ReportBox(theme, s.weekText, theme.Subtitle).Layout(gtx)
+        }),
+
+        // Running or just stopped timer
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            if len(s.timerText) == 0 {
+                return layout.Dimensions{}
+            }
+            return widgets.ReportBox(theme, s.timerText, theme.Subtitle).Layout(gtx)
+        }),
+
+        // Budget alert, only when a project crossed a threshold
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            if len(s.budgetText) == 0 {
+                return layout.Dimensions{}
+            }
+            return widgets.ReportBox(theme, s.budgetText, theme.Error).Layout(gtx)
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(30)}.Layout),
+
+        // Client, project and task selectors
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return s.picker.layout(gtx, theme)
+        }),
+
+        // Input box
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedInputBoxElement(gtx, theme, perms, clientNameGuard, &s.clientTextbox, tr().Text("Input for T&B client name"),
+                tr().Text("Read-only: you may not change the client name"))
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+        // Input box
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedInputBoxElement(gtx, theme, perms, timeSpentGuard, &s.timeTextbox, tr().Text("Input for T&B time spent"),
+                tr().Text("Read-only: you may not change the time spent"))
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(50)}.Layout),
+
+        // Button for counting clicks
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            // Only usable when both inputs are
+            confirmState := min(perms.State(clientNameGuard), perms.State(timeSpentGuard))
+            return guardedElement(gtx, confirmState, func(gtx layout.Context) layout.Dimensions {
+                return widgets.Button(theme, &s.inputConfirmBtn, btnText).Layout(gtx)
+            })
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+        // Button for the user's week, where entries can be changed while the rules allow it
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.myWeekBtn, tr().Text("My week"), myWeekGuard)
+        }),
+
+        // Button for submitting the week, only for users who may submit
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.submitWeekBtn, tr().Text("Submit week"), submitWeekGuard)
+        }),
+
+        // Button for the approval window, only for users who may approve
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.approvalsBtn, tr().Text("Approvals"), approvalsGuard)
+        }),
+
+        // Button for the billing window, only for users who may write invoices
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.billingBtn, tr().Text("Billing"), billingGuard)
+        }),
+
+        // Button for the export window, only for users who may read time entries
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.exportBtn, tr().Text("Export"), exportOwnGuard, exportTeamGuard)
+        }),
+
+        // Button for the import window, only for users who may write their own time entries
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.importBtn, tr().Text("Import"), importGuard)
+        }),
+
+        // Button for the reports window, only for users who may read at least one report
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.reportsBtn, tr().Text("Reports"), reportHoursGuard, reportBillableGuard)
+        }),
+
+        // Button for the check access tool, only for admins
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.checkAccessBtn, tr().Text("Check access"), checkAccessGuard)
+        }),
+
+        // Button for the role hierarchy, only for admins
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.rolesBtn, tr().Text("Roles"), rolesGuard)
+        }),
+
+        // Button for the policy editor, only for admins
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.policiesBtn, tr().Text("Policies"), policiesGuard)
+        }),
+
+        // Button for the audit log, only for admins
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return guardedBtnElement(gtx, theme, perms, &s.auditLogBtn, tr().Text("Audit log"), auditLogGuard)
+        }),
+
+        // Button for the notifications window
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return widgets.Button(theme, &s.notificationsBtn, tr().Text("Notifications")).Layout(gtx)
+        }),
+
+        // Button for the user's own settings, e.g. the theme
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return widgets.Button(theme, &s.settingsBtn, tr().Text("Settings")).Layout(gtx)
+        }),
+
+        // Where to find the shortcuts
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return widgets.ReportBox(theme, helpText, theme.Muted).Layout(gtx)
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(25)}.Layout),
+    )
+}
@@ -2,25 +2,273 @@ package main
 
 import (
     "gioui.org/app"
+    "gioui.org/io/key"
+    "gioui.org/layout"
+    "image"
+    "showcase_desktop/i18n"
+    "showcase_desktop/widgets"
     "testing"
+    "time"
 )
 
-func Test_runApp(t *testing.T) {
-	type args struct {
-		in_window *app.Window
-	}
-	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
-	}{
-		// TODO: Add test cases.
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if err := runApp(tt.args.in_window); (err != nil) != tt.wantErr {
-				t.Errorf("runApp() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
+func Test_signInScreen(t *testing.T) {
+    tests := []struct {
+        name        string
+        username    string
+        password    string
+        pick        string      // organization picked when the user is in more than one
+        wantUserID  int
+        wantOrgID   int
+        wantText    string      // shown when the sign in does not go through
+    }{
+        {"empty inputs",        "",      "",         "",            0, 0, "Please enter a username and a password"},
+        {"wrong password",      "Ray",   "nopass",   "",            0, 0, "Wrong username or password"},
+        {"one organization",    "Petar", "nopass",   "",            3, 1, ""},
+        {"picks organization",  "Tadej", "goodpass", "Steaby Labs", 2, 2, ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db, _  := openTestDb(t)
+            screen := newSignInScreen(db)
+
+            var signIn   signInResult
+            var signedIn bool
+            h := newScreenHarness(t, image.Pt(800, 600), func(gtx layout.Context) {
+                if result, ok := screen.update(gtx); ok {
+                    signIn, signedIn = result, true
+                }
+                screen.layout(gtx, widgets.Light())
+            })
+
+            if len(tt.username) > 0 {
+                h.typeInto("Enter username", tt.username)
+                h.typeInto("Enter password", tt.password)
+            }
+            h.click("Sign In")
+            if len(tt.pick) > 0 {
+                h.click(tt.pick)
+                h.click("Continue")
+            }
+
+            if len(tt.wantText) > 0 {
+                if signedIn || !h.hasText(tt.wantText) {
+                    t.Errorf("signed in = %v, labels %v, want %q", signedIn, h.labels(), tt.wantText)
+                }
+                return
+            }
+            if !signedIn || signIn.UserID != tt.wantUserID || signIn.Username != tt.username || signIn.Organization.OrganizationID != tt.wantOrgID {
+                t.Errorf("signed in = %v, %+v, want user %d in organization %d", signedIn, signIn, tt.wantUserID, tt.wantOrgID)
+            }
+        })
+    }
+}
+
+func Test_mainScreen(t *testing.T) {
+    db, dbPath := openTestDb(t)
+
+    // Tadej may write both inputs
+    enforcer := openTestEnforcer(t, dbPath)
+    screen   := newMainScreen(db, enforcer, 2, "Tadej", Organization{OrganizationID: 1, OrganizationName: "Steaby"})
+    var links []mainWindowLink
+    h := newScreenHarness(t, image.Pt(1000, 1400), func(gtx layout.Context) {
+        links = append(links, screen.update(gtx)...)
+        screen.layout(gtx, widgets.Light())
+    })
+
+    h.typeInto("Input for T&B client name", "ACME")
+    h.typeInto("Input for T&B time spent", "1:30")
+    h.click("Confirm")
+    if !h.hasText("Logged 1.50h for ACME, confirmed 1 time") {
+        t.Errorf("labels after Confirm = %v", h.labels())
+    }
+    if week, err := getOrCreateTimesheet(db, 1, 2, time.Now()); err != nil || week.TotalMinutes != 90 {
+        t.Errorf("week after Confirm = %+v, %v, want 90 minutes", week, err)
+    }
+
+    h.click("My week")
+    if len(links) != 1 || links[0] != linkMyWeek {
+        t.Errorf("links after My week = %v, want %v", links, linkMyWeek)
+    }
+
+    // Ray is an admin, who may only read the inputs
+    adminEnforcer := openTestEnforcer(t, dbPath)
+    adminScreen   := newMainScreen(db, adminEnforcer, 1, "Ray", Organization{OrganizationID: 1, OrganizationName: "Steaby"})
+    h              = newScreenHarness(t, image.Pt(1000, 1400), func(gtx layout.Context) {
+        adminScreen.update(gtx)
+        adminScreen.layout(gtx, widgets.Light())
+    })
+    if !h.hasText("Your user ID is 1") || h.canClick("Confirm") || !h.canClick("Audit log") {
+        t.Errorf("admin labels = %v, Confirm clickable = %v", h.labels(), h.canClick("Confirm"))
+    }
+}
+
+func Test_settingsScreen(t *testing.T) {
+    db, _ := openTestDb(t)
+    t.Cleanup(func() {
+        setTheme(widgets.ThemeLight)
+        setLanguage(i18n.English)
+    })
+
+    screen := newSettingsScreen(db, 2)
+    h      := newScreenHarness(t, image.Pt(600, 300), func(gtx layout.Context) {
+        screen.update(gtx)
+        screen.layout(gtx, widgets.Light())
+    })
+
+    // Picking a preset switches every window to it and keeps it for the next sign in
+    h.click("Dark")
+    if got := currentThemeName(); got != widgets.ThemeDark {
+        t.Errorf("theme after picking Dark = %q, labels %v", got, h.labels())
+    }
+    if got, err := getUserSetting(db, 2, settingTheme, ""); err != nil || got != widgets.ThemeDark {
+        t.Errorf("saved theme = %q, %v, want %q", got, err, widgets.ThemeDark)
+    }
+
+    // Picking a language translates the screens at once and is kept the same way
+    h.click("Slovenščina")
+    if got := tr().Tag(); got != i18n.Slovenian {
+        t.Errorf("language after picking Slovenščina = %q, labels %v", got, h.labels())
+    }
+    if !h.hasText("Nastavitve") {
+        t.Errorf("labels after picking Slovenščina = %v", h.labels())
+    }
+    if got, err := getUserSetting(db, 2, settingLanguage, ""); err != nil || got != i18n.Slovenian {
+        t.Errorf("saved language = %q, %v, want %q", got, err, i18n.Slovenian)
+    }
+}
+
+func Test_signInKeyboard(t *testing.T) {
+    db, _  := openTestDb(t)
+    screen := newSignInScreen(db)
+
+    var signIn   signInResult
+    var signedIn bool
+    h := newScreenHarness(t, image.Pt(800, 600), func(gtx layout.Context) {
+        if result, ok := screen.update(gtx); ok {
+            signIn, signedIn = result, true
+        }
+        screen.layout(gtx, widgets.Light())
+    })
+
+    // Tab goes username, password, Sign In and around again, Shift+Tab goes back
+    h.press(key.NameTab, 0)
+    h.press(key.NameTab, 0)
+    if !h.focused(&screen.passwordTextbox) {
+        t.Errorf("Tab twice did not focus the password")
+    }
+    h.press(key.NameTab, 0)
+    h.press(key.NameTab, 0)
+    h.press(key.NameTab, key.ModShift)
+    if !h.focused(&screen.signInBtn) {
+        t.Errorf("Tab four times and Shift+Tab did not focus Sign In")
+    }
+
+    // Enter in the username moves on to the password, Enter in the password signs in
+    h.typeInto("Enter username", "Petar")
+    h.press(key.NameReturn, 0)
+    if !h.focused(&screen.passwordTextbox) {
+        t.Errorf("Enter in the username did not focus the password")
+    }
+    h.typeInto("Enter password", "nopass")
+    h.press(key.NameReturn, 0)
+    if !signedIn || signIn.UserID != 3 {
+        t.Errorf("signed in = %v, %+v, want Petar, labels %v", signedIn, signIn, h.labels())
+    }
+}
+
+func Test_mainScreenKeyboard(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    screen     := newMainScreen(db, enforcer, 2, "Tadej", Organization{OrganizationID: 1, OrganizationName: "Steaby"})
+    var links []mainWindowLink
+    h := newScreenHarness(t, image.Pt(1000, 1400), func(gtx layout.Context) {
+        links = append(links, screen.update(gtx)...)
+        screen.layout(gtx, widgets.Light())
+    })
+
+    // Tab starts at the client, then the time and Confirm
+    h.press(key.NameTab, 0)
+    h.press(key.NameTab, 0)
+    if !h.focused(&screen.timeTextbox.Editor) {
+        t.Errorf("Tab twice did not focus the time")
+    }
+    h.press(key.NameTab, key.ModShift)
+    if !h.focused(&screen.clientTextbox.Editor) {
+        t.Errorf("Shift+Tab did not go back to the client")
+    }
+
+    // Enter in the time confirms
+    h.typeInto("Input for T&B client name", "ACME")
+    h.typeInto("Input for T&B time spent", "0:45")
+    h.press(key.NameReturn, 0)
+    if !h.hasText("Logged 0.75h for ACME, confirmed 1 time") {
+        t.Errorf("labels after Enter = %v", h.labels())
+    }
+
+    // Ctrl+N starts a new entry in the client
+    h.typeInto("Input for T&B client name", "ACME")
+    h.press("N", key.ModShortcut)
+    if screen.clientTextbox.Text() != "" || !h.focused(&screen.clientTextbox.Editor) {
+        t.Errorf("client after Ctrl+N = %q, focused %v", screen.clientTextbox.Text(), h.focused(&screen.clientTextbox.Editor))
+    }
+
+    // Ctrl+T starts the timer and stops it into the time input
+    h.press("T", key.ModShortcut)
+    if screen.timerStart.IsZero() || !h.hasText("Timer started at") {
+        t.Errorf("labels after starting the timer = %v", h.labels())
+    }
+    h.press("T", key.ModShortcut)
+    if got := screen.timeTextbox.Text(); !screen.timerStart.IsZero() || got != "0:01" || !h.focused(&screen.timeTextbox.Editor) {
+        t.Errorf("time after stopping the timer = %q", got)
+    }
+
+    // F1 shows the shortcuts, Esc hides them
+    h.press(key.NameF1, 0)
+    if !h.hasText("Keyboard shortcuts") || !h.hasText("Start or stop the timer") {
+        t.Errorf("labels after F1 = %v", h.labels())
+    }
+    h.press(key.NameEscape, 0)
+    if h.hasText("Keyboard shortcuts") {
+        t.Errorf("labels after Esc = %v", h.labels())
+    }
+
+    // Ctrl+Q asks runApp to sign out
+    h.press("Q", key.ModShortcut)
+    if len(links) != 1 || links[0] != linkSignOut {
+        t.Errorf("links after Ctrl+Q = %v, want %v", links, linkSignOut)
+    }
+}
+
+// Signing out closes the windows opened from the main window and waits for them, later links open nothing
+func Test_sessionWindows(t *testing.T) {
+    var windows sessionWindows
+
+    release := make(chan struct{})
+    windows.open(func(inWindow *app.Window) error {
+        <-release
+        return nil
+    })
+
+    closed := make(chan struct{})
+    go func() {
+        windows.closeAll()
+        close(closed)
+    }()
+    select {
+    case <-closed:
+        t.Fatal("closeAll() returned while a window was still open")
+    case <-time.After(50 * time.Millisecond):
+    }
+    close(release)
+    <-closed
+
+    opened := false
+    windows.open(func(inWindow *app.Window) error {
+        opened = true
+        return nil
+    })
+    windows.running.Wait()
+    if opened {
+        t.Error("a window opened after the session ended")
+    }
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+    "database/sql"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "showcase_desktop/widgets"
+)
+
+
+// runNotifications lists the budget alerts and the current budget use of the projects the user may read
+func runNotifications(inWindow *app.Window, inUserID int, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var refreshBtn          widget.Clickable
+    var notificationList    widget.List
+    var alerts              []BudgetAlert
+    var statuses            []BudgetStatus
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Notifications")
+    notificationList.Axis    = layout.Vertical
+
+    refreshNotifications := func() {
+        var err error
+        if alerts, err = listBudgetAlerts(inS3db, inEnforcer, inUserID); err == nil {
+            statuses, err = budgetStatuses(inS3db, inEnforcer, inUserID)
+        }
+        if err != nil {
+            log.Print(err)
+            statusMsg = tr().Text("Could not load the notifications")
+            return
+        }
+        statusMsg = tr().Plural(len(alerts), "%d budget alert", "%d budget alerts")
+    }
+    refreshNotifications()
+
+    inWindow.Option(app.Title(tr().Text("Notifications")), app.Size(unit.Dp(800), unit.Dp(600)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            if refreshBtn.Clicked(gtx) {
+                refreshNotifications()
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Button(theme, &refreshBtn, tr().Text("Refresh")).Layout(gtx)
+                }),
+
+                // Budget use first, then the alerts newest first
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &notificationList).Layout(gtx, len(statuses)+len(alerts), func(gtx layout.Context, index int) layout.Dimensions {
+                        var label material.LabelStyle
+                        if index < len(statuses) {
+                            label = material.Body1(theme.Theme, statuses[index].Text())
+                        } else {
+                            alert := alerts[index-len(statuses)]
+                            label  = material.Body1(theme.Theme, tr().Sprintf("%s  %s / %s crossed %d%% of its budget (%d%% used)",
+                                tr().DateTime(alert.RaisedAt.Local()), alert.ClientName, alert.ProjectName, alert.Threshold, alert.UsedPercent))
+                            if alert.Threshold >= 100 {
+                                label.Color = theme.Error
+                            }
+                        }
+                        return layout.UniformInset(unit.Dp(5)).Layout(gtx, label.Layout)
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+func Test_userOrganization(t *testing.T) {
+    db, _ := openTestDb(t)
+
+    tests := []struct {
+        name     string
+        userID   int
+        orgName  string
+        wantID   int
+        wantErr  bool
+    }{
+        {"first one by default",        1, "",             1, false},
+        {"picked by name",              1, "steaby labs",  2, false},
+        {"only in one",                 3, "",             1, false},
+        {"not a member",                3, "Steaby Labs",  0, true},
+        {"no organization at all",      99, "",            0, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := userOrganization(db, tt.userID, tt.orgName)
+            if (err != nil) != tt.wantErr || got.OrganizationID != tt.wantID {
+                t.Errorf("userOrganization() = %+v, %v, want %d", got, err, tt.wantID)
+            }
+        })
+    }
+
+    if _, err := userOrganization(db, 99, ""); !errors.Is(err, errNoOrganization) {
+        t.Errorf("userOrganization() error = %v, want %v", err, errNoOrganization)
+    }
+}
+
+func Test_OrgEnforcer(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    steaby     := openTestOrgEnforcer(t, dbPath, 1)
+    labs       := openTestOrgEnforcer(t, dbPath, 2)
+
+    // Same users, different roles per organization
+    tests := []struct {
+        name     string
+        enforcer *OrgEnforcer
+        subject  string
+        object   string
+        action   string
+        want     bool
+    }{
+        {"Tadej is a minion at Steaby",     steaby, "u2", "admin_text",  "read",    false},
+        {"and an admin in the labs",        labs,   "u2", "admin_text",  "read",    true},
+        {"Ray approves at Steaby",          steaby, "u1", "timesheet",   "approve", true},
+        {"but not in the labs",             labs,   "u1", "timesheet",   "approve", false},
+        {"Ray logs time in the labs",       labs,   "u1", "time_entry",  "write",   true},
+        {"Petar's deny stays at Steaby",    labs,   "u3", "report_text", "read",    false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := tt.enforcer.Enforce(tt.subject, tt.object, tt.action)
+            if err != nil || got != tt.want {
+                t.Errorf("Enforce() = %v, %v, want %v", got, err, tt.want)
+            }
+        })
+    }
+
+    // Clients and their projects belong to one organization
+    clients, err := listClients(db, 2)
+    if err != nil || len(clients) != 1 || clients[0].ClientName != "Globex" {
+        t.Errorf("listClients() = %+v, %v", clients, err)
+    }
+    projects, err := listProjects(db, labs, 2, 0)
+    if err != nil || len(projects) != 0 {
+        t.Errorf("listProjects() in the labs = %+v, %v", projects, err)
+    }
+    roles, err := labs.GetRolesForUser("u2")
+    if err != nil || len(roles) != 1 || roles[0] != "r1" {
+        t.Errorf("GetRolesForUser() = %v, %v", roles, err)
+    }
+
+    // The same client name is another client in each organization
+    steabyGlobex, err := ensureClient(db, 1, "Globex")
+    if err != nil || steabyGlobex.ClientID == clients[0].ClientID {
+        t.Errorf("ensureClient() at Steaby = %+v, %v, want a client of its own", steabyGlobex, err)
+    }
+
+    // Tadej's week in the labs is another timesheet, out of sight and reach of Steaby's managers and exports
+    day            := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+    labsEntry, err := addTimeEntry(db, 2, 2, "Globex", day, 60)
+    if err != nil {
+        t.Fatal(err)
+    }
+    steabyEntry, err := addTimeEntry(db, 1, 2, "ACME", day, 30)
+    if err != nil || steabyEntry.TimesheetID == labsEntry.TimesheetID {
+        t.Fatalf("addTimeEntry() at Steaby = %+v, %v, want another timesheet than %d", steabyEntry, err, labsEntry.TimesheetID)
+    }
+    if _, err := transitionTimesheet(db, steaby, 1, labsEntry.TimesheetID, timesheetActApprove, ""); !errors.Is(err, errTimesheetNotFound) {
+        t.Errorf("Steaby approving a labs week error = %v, want %v", err, errTimesheetNotFound)
+    }
+    rows, err := queryExportRows(db, steaby, 1, ExportFilter{})
+    if err != nil || len(rows) != 1 || rows[0].ClientName != "ACME" {
+        t.Errorf("queryExportRows() at Steaby = %+v, %v, want only the ACME entry", rows, err)
+    }
+}
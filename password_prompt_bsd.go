@@ -0,0 +1,11 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package main
+
+import "golang.org/x/sys/unix"
+
+
+const (
+    ioctlReadTermios  = unix.TIOCGETA
+    ioctlWriteTermios = unix.TIOCSETA
+)
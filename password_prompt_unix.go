@@ -0,0 +1,29 @@
+//go:build darwin || freebsd || linux || netbsd || openbsd
+
+package main
+
+import (
+    "os"
+
+    "golang.org/x/sys/unix"
+)
+
+
+// readHiddenLine reads a line from inFile with the terminal's echo switched off, a pipe is read as it is
+func readHiddenLine(inFile *os.File) (string, error) {
+    fd := int(inFile.Fd())
+    termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+    if err != nil {
+        return readPasswordLine(inFile)
+    }
+
+    hidden := *termios
+    hidden.Lflag &^= unix.ECHO
+    hidden.Lflag |= unix.ICANON | unix.ISIG
+    if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, &hidden); err != nil {
+        return "", err
+    }
+    defer unix.IoctlSetTermios(fd, ioctlWriteTermios, termios)
+
+    return readPasswordLine(inFile)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+    "os"
+
+    "golang.org/x/sys/windows"
+)
+
+
+// readHiddenLine reads a line from inFile with the console's echo switched off, a pipe is read as it is
+func readHiddenLine(inFile *os.File) (string, error) {
+    handle := windows.Handle(inFile.Fd())
+    var mode uint32
+    if err := windows.GetConsoleMode(handle, &mode); err != nil {
+        return readPasswordLine(inFile)
+    }
+
+    hidden := mode&^windows.ENABLE_ECHO_INPUT | windows.ENABLE_LINE_INPUT | windows.ENABLE_PROCESSED_INPUT
+    if err := windows.SetConsoleMode(handle, hidden); err != nil {
+        return "", err
+    }
+    defer windows.SetConsoleMode(handle, mode)
+
+    return readPasswordLine(inFile)
+}
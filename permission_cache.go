@@ -0,0 +1,151 @@
+package main
+
+import (
+    "sync"
+)
+
+
+// PermissionCheck is an object and action the UI asks about, the subject is given by the snapshot
+type PermissionCheck struct {
+    Object  string
+    Action  string
+}
+
+type permissionKey struct {
+    subject string
+    object  string
+    action  string
+}
+
+// PermissionCache keeps Casbin decisions per (sub, obj, act) until the policy is reloaded
+type PermissionCache struct {
+    enforcer    *OrgEnforcer
+    mu          sync.RWMutex
+    decisions   map[permissionKey]bool
+    version     uint64
+}
+
+// PermissionSnapshot holds one subject's decisions for a fixed list of checks, cheap enough to read every frame
+type PermissionSnapshot struct {
+    Version     uint64
+    decisions   map[PermissionCheck]bool
+}
+
+func NewPermissionCache(inEnforcer *OrgEnforcer) *PermissionCache {
+    return &PermissionCache{enforcer: inEnforcer, decisions: map[permissionKey]bool{}}
+}
+
+// Enforce returns the cached decision, asking Casbin only the first time
+func (c *PermissionCache) Enforce(inSubject string, inObject string, inAction string) (bool, error) {
+    key := permissionKey{inSubject, inObject, inAction}
+
+    c.mu.RLock()
+    allowed, found := c.decisions[key]
+    c.mu.RUnlock()
+    if found {
+        return allowed, nil
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    allowed, err := c.enforcer.Enforce(inSubject, inObject, inAction)
+    if err != nil {
+        return false, err
+    }
+    c.decisions[key] = allowed
+
+    return allowed, nil
+}
+
+// Reload loads the policy from the DB again and forgets every cached decision
+func (c *PermissionCache) Reload() error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if err := c.enforcer.LoadPolicy(); err != nil {
+        return err
+    }
+    c.decisions = map[permissionKey]bool{}
+    c.version++
+
+    return nil
+}
+
+// Version counts the reloads, a snapshot with an older version is stale
+func (c *PermissionCache) Version() uint64 {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    return c.version
+}
+
+// Snapshot decides all checks for the subject at once, failed checks are denied
+func (c *PermissionCache) Snapshot(inSubject string, inChecks []PermissionCheck) (PermissionSnapshot, error) {
+    snapshot := PermissionSnapshot{Version: c.Version(), decisions: make(map[PermissionCheck]bool, len(inChecks))}
+
+    var firstErr error
+    for _, check := range inChecks {
+        allowed, err := c.Enforce(inSubject, check.Object, check.Action)
+        if err != nil && firstErr == nil {
+            firstErr = err
+        }
+        snapshot.decisions[check] = allowed
+    }
+
+    return snapshot, firstErr
+}
+
+// Can tells if the snapshot allows the action, checks it was not built with are denied
+func (s PermissionSnapshot) Can(inObject string, inAction string) bool {
+    return s.decisions[PermissionCheck{inObject, inAction}]
+}
+
+
+// Guard names the checks behind a UI element: View to show it at all and Use to work with it.
+// An empty View shows the element to everybody, an empty Use makes seeing it enough.
+type Guard struct {
+    Object  string
+    View    string
+    Use     string
+}
+
+// How a guarded element is drawn
+type GuardState int
+
+const (
+    GuardHidden GuardState = iota
+    GuardDisabled
+    GuardEnabled
+)
+
+// guardChecks lists the checks a snapshot needs to decide the guards
+func guardChecks(inGuards ...Guard) []PermissionCheck {
+    var checks []PermissionCheck
+    for _, guard := range inGuards {
+        if guard.View != "" {
+            checks = append(checks, PermissionCheck{guard.Object, guard.View})
+        }
+        if guard.Use != "" {
+            checks = append(checks, PermissionCheck{guard.Object, guard.Use})
+        }
+    }
+
+    return checks
+}
+
+// State decides how to draw an element behind the guards, any guard letting the user in is enough
+func (s PermissionSnapshot) State(inGuards ...Guard) GuardState {
+    state := GuardHidden
+    for _, guard := range inGuards {
+        switch {
+        case guard.View != "" && !s.Can(guard.Object, guard.View):
+            continue
+        case guard.Use != "" && !s.Can(guard.Object, guard.Use):
+            state = max(state, GuardDisabled)
+        default:
+            return GuardEnabled
+        }
+    }
+
+    return state
+}
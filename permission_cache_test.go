@@ -0,0 +1,120 @@
+package main
+
+import (
+    "testing"
+)
+
+func Test_PermissionCache(t *testing.T) {
+    db, dbPath  := openTestDb(t)
+    permissions := NewPermissionCache(openTestEnforcer(t, dbPath))
+
+    checks := []PermissionCheck{{"admin_text", "read"}, {timesheetObject, timesheetActApprove}}
+    before, err := permissions.Snapshot("u1", checks)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // Revoked in the DB, but the cache keeps the old decision until the policy is reloaded
+    if _, err := db.Exec("INSERT INTO auth_user_policy (user_policy_id, subject, object, action, effect) VALUES (3, 1, 'admin_text', 'read', 'deny')"); err != nil {
+        t.Fatal(err)
+    }
+    cached, err := permissions.Enforce("u1", "admin_text", "read")
+    if err != nil || !cached {
+        t.Errorf("Enforce() before Reload() = %v, %v, want the cached true", cached, err)
+    }
+    if err := permissions.Reload(); err != nil {
+        t.Fatal(err)
+    }
+    after, err := permissions.Snapshot("u1", checks)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name     string
+        snapshot PermissionSnapshot
+        object   string
+        action   string
+        want     bool
+    }{
+        {"allowed before the revoke",   before, "admin_text",     "read",              true},
+        {"denied after the reload",     after,  "admin_text",     "read",              false},
+        {"untouched rule stays",        after,  timesheetObject,  timesheetActApprove, true},
+        {"check not in the snapshot",   after,  "report_text",    "read",              false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.snapshot.Can(tt.object, tt.action); got != tt.want {
+                t.Errorf("Can() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+
+    if before.Version == after.Version || after.Version != permissions.Version() {
+        t.Errorf("snapshot versions %d and %d, cache at %d", before.Version, after.Version, permissions.Version())
+    }
+}
+
+func Test_PermissionSnapshot_State(t *testing.T) {
+    _, dbPath   := openTestDb(t)
+    permissions := NewPermissionCache(openTestEnforcer(t, dbPath))
+
+    tests := []struct {
+        name    string
+        subject string
+        guards  []Guard
+        want    GuardState
+    }{
+        {"minion types the client name",    "u2", []Guard{clientNameGuard},                         GuardEnabled},
+        {"admin only reads it",             "u1", []Guard{clientNameGuard},                         GuardDisabled},
+        {"admin sees the admin text",       "u1", []Guard{adminTextGuard},                          GuardEnabled},
+        {"minion does not",                 "u2", []Guard{adminTextGuard},                          GuardHidden},
+        {"Petar's report text is hidden",   "u3", []Guard{reportTextGuard},                         GuardHidden},
+        {"any guard is enough",             "u1", []Guard{reportBillableGuard, reportHoursGuard},   GuardEnabled},
+        {"no guard hides",                  "u1", nil,                                              GuardHidden},
+        {"no actions show to everybody",    "u2", []Guard{{Object: "anything"}},                    GuardEnabled},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            snapshot, err := permissions.Snapshot(tt.subject, guardChecks(tt.guards...))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := snapshot.State(tt.guards...); got != tt.want {
+                t.Errorf("State() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+// Windows check the session's enforcer directly from their own goroutines while the watcher reloads it
+func Test_PermissionCache_concurrentReload(t *testing.T) {
+    _, dbPath   := openTestDb(t)
+    enforcer    := openTestEnforcer(t, dbPath)
+    permissions := NewPermissionCache(enforcer)
+
+    done := make(chan error)
+    go func() {
+        for i := 0; i < 50; i++ {
+            if err := permissions.Reload(); err != nil {
+                done <- err
+                return
+            }
+        }
+        done <- nil
+    }()
+
+    for reloading := true; reloading; {
+        select {
+        case err := <-done:
+            if err != nil {
+                t.Fatal(err)
+            }
+            reloading = false
+        default:
+        }
+        if allowed, err := enforcer.Enforce("u1", timesheetObject, timesheetActApprove); err != nil || !allowed {
+            t.Fatalf("Enforce() during a reload = %v, %v, want true", allowed, err)
+        }
+    }
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "os"
+    "path/filepath"
+    "showcase_desktop/widgets"
+    "strings"
+    "time"
+)
+
+
+// policyRow keeps the remove button of one rule between frames
+type policyRow struct {
+    rule        PolicyRule
+    removeBtn   widget.Clickable
+}
+
+
+// runPolicies lets an admin list, add and remove the organization's allow and deny rules, conditions included
+func runPolicies(inWindow *app.Window, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var subjectTextbox      widget.Editor
+    var objectTextbox       widget.Editor
+    var actionTextbox       widget.Editor
+    var conditionTextbox    widget.Editor
+    var effectEnum          widget.Enum
+    var addBtn              widget.Clickable
+    var pathTextbox         widget.Editor
+    var exportBtn           widget.Clickable
+    var diffBtn             widget.Clickable
+    var importBtn           widget.Clickable
+    var policyList          widget.List
+    var diffList            widget.List
+    var rows                []*policyRow
+    var diffLines           []string
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Policies")
+    conditionHint           := tr().Sprintf("Condition, e.g. %s", strings.Join(conditionExamples, tr().Text(" or ")))
+    effectKeys              := []string{"allow", "deny"}
+    policyList.Axis          = layout.Vertical
+    diffList.Axis            = layout.Vertical
+    effectEnum.Value         = effectKeys[0]
+
+    refreshRows := func() {
+        rules, err := listPolicyRules(inS3db, inEnforcer.OrganizationID)
+        if err != nil {
+            log.Print(err)
+            statusMsg = tr().Text("Could not load the policies")
+            return
+        }
+
+        rows = rows[:0]
+        for _, rule := range rules {
+            rows = append(rows, &policyRow{rule: rule})
+        }
+    }
+    refreshRows()
+
+    inWindow.Option(app.Title(tr().Text("Policies")), app.Size(unit.Dp(1050), unit.Dp(700)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            // New rules reach running apps through the policy watcher
+            if addBtn.Clicked(gtx) {
+                err := inEnforcer.checkActorWrite(inS3db, policyObject, errPolicyDenied)
+                var kind      string
+                var subjectID int
+                if err == nil {
+                    kind, subjectID, err = policySubject(inS3db, subjectTextbox.Text())
+                }
+                if err == nil {
+                    rule := PolicyRule{
+                        Kind:        kind,
+                        SubjectID:   subjectID,
+                        SubjectName: strings.TrimSpace(subjectTextbox.Text()),
+                        Object:      strings.TrimSpace(objectTextbox.Text()),
+                        Action:      strings.TrimSpace(actionTextbox.Text()),
+                        Effect:      effectEnum.Value,
+                        Condition:   strings.TrimSpace(conditionTextbox.Text()),
+                    }
+                    err = addPolicyRule(inEnforcer, rule)
+                }
+                if err != nil {
+                    statusMsg = errorText(err)
+                } else {
+                    statusMsg = tr().Sprintf("Added an allow rule for %s", strings.TrimSpace(subjectTextbox.Text()))
+                    if effectEnum.Value == "deny" {
+                        statusMsg = tr().Sprintf("Added a deny rule for %s", strings.TrimSpace(subjectTextbox.Text()))
+                    }
+                    conditionTextbox.SetText("")
+                    refreshRows()
+                }
+            }
+
+            // Casbin CSV with names, to move the policy between databases
+            if exportBtn.Clicked(gtx) {
+                path := strings.TrimSpace(pathTextbox.Text())
+                if path == "" {
+                    path = filepath.Join(exportFolder, fmt.Sprintf("policy_%s.csv", time.Now().Format("20060102_150405")))
+                }
+                names, err := subjectNames(inS3db)
+                var file *os.File
+                if err == nil {
+                    file, err = os.Create(path)
+                }
+                var count int
+                if err == nil {
+                    count, err = writePolicyCSV(file, inEnforcer, names)
+                    file.Close()
+                }
+                if err != nil {
+                    statusMsg = tr().Sprintf("Could not export the policy: %s", errorText(err))
+                } else {
+                    statusMsg = tr().Plural(count, "Exported %d rule to %s", "Exported %d rules to %s", count, path)
+                }
+            }
+
+            // Diff only shows what an import would change, import applies it
+            diffClicked, importClicked := diffBtn.Clicked(gtx), importBtn.Clicked(gtx)
+            if diffClicked || importClicked {
+                dryRun := !importClicked
+                var err error
+                if !dryRun {
+                    err = inEnforcer.checkActorWrite(inS3db, policyObject, errPolicyDenied)
+                }
+                var names map[string]string
+                if err == nil {
+                    names, err = subjectNames(inS3db)
+                }
+                var file *os.File
+                if err == nil {
+                    file, err = os.Open(strings.TrimSpace(pathTextbox.Text()))
+                }
+                var diff PolicyDiff
+                if err == nil {
+                    diff, err = importPolicyCSV(file, inS3db, inEnforcer, dryRun)
+                    file.Close()
+                }
+                diffLines = diff.Lines(names)
+                added     := tr().Plural(len(diff.Added), "%d rule", "%d rules")
+                removed   := tr().Plural(len(diff.Removed), "%d rule", "%d rules")
+                switch {
+                case err != nil:
+                    statusMsg = tr().Sprintf("Could not read the policy: %s", errorText(err))
+                case dryRun:
+                    statusMsg = tr().Sprintf("Importing would add %s and remove %s", added, removed)
+                default:
+                    statusMsg = tr().Sprintf("Added %s and removed %s", added, removed)
+                    refreshRows()
+                }
+            }
+
+            for _, row := range rows {
+                if !row.removeBtn.Clicked(gtx) {
+                    continue
+                }
+                err := inEnforcer.checkActorWrite(inS3db, policyObject, errPolicyDenied)
+                if err == nil {
+                    err = removePolicyRule(inEnforcer, row.rule)
+                }
+                if err != nil {
+                    statusMsg = errorText(err)
+                } else {
+                    statusMsg = tr().Sprintf("Removed %s", policyRuleText(row.rule))
+                }
+                refreshRows()
+                break
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                // Who may or may not do what
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &subjectTextbox, tr().Text("Role or user, e.g. B_minion")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &objectTextbox, tr().Text("Object, e.g. time_entry")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &actionTextbox, tr().Text("Action, e.g. edit")).Layout(gtx) },
+                    )
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return radioRowElement(gtx, theme, tr().Text("Effect"), &effectEnum, effectKeys, []string{tr().Text("Allow"), tr().Text("Deny")})
+                }),
+
+                // Optional condition on the request's attributes
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.InputBox(theme, &conditionTextbox, conditionHint).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Button(theme, &addBtn, tr().Text("Add rule")).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+                // Import and export
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &pathTextbox, tr().Sprintf("CSV file, empty exports to %s", exportFolder)).Layout(gtx) },
+                        material.Button(theme.Theme, &exportBtn, tr().Text("Export")).Layout,
+                        material.Button(theme.Theme, &diffBtn, tr().Text("Diff")).Layout,
+                        material.Button(theme.Theme, &importBtn, tr().Text("Import")).Layout,
+                    )
+                }),
+
+                // What the last diff or import changes
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    gtx.Constraints.Max.Y = gtx.Dp(150)
+                    return material.List(theme.Theme, &diffList).Layout(gtx, len(diffLines), func(gtx layout.Context, index int) layout.Dimensions {
+                        return layout.UniformInset(unit.Dp(2)).Layout(gtx, material.Body2(theme.Theme, diffLines[index]).Layout)
+                    })
+                }),
+
+                // The organization's rules
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &policyList).Layout(gtx, len(rows), func(gtx layout.Context, index int) layout.Dimensions {
+                        return policyRowElement(gtx, theme, rows[index])
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
+
+
+func policyRowElement(inGTX layout.Context, inTheme *widgets.Theme, inRow *policyRow) layout.Dimensions {
+    return layout.UniformInset(unit.Dp(5)).Layout(inGTX, func(gtx layout.Context) layout.Dimensions {
+        return layout.Flex{
+            Axis:      layout.Horizontal,
+            Alignment: layout.Middle,
+        }.Layout(gtx,
+            layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                return material.Body1(inTheme.Theme, policyRuleText(inRow.rule)).Layout(gtx)
+            }),
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                return material.Button(inTheme.Theme, &inRow.removeBtn, tr().Text("Remove")).Layout(gtx)
+            }),
+        )
+    })
+}
+
+// policyRuleText is the rule in the user's language, the audit log keeps it as PolicyRule.Text writes it
+func policyRuleText(inRule PolicyRule) string {
+    kind := tr().Text("role")
+    if inRule.Kind == policyKindUser {
+        kind = tr().Text("user")
+    }
+
+    text := tr().Sprintf("%s %s: %s %s on %s", kind, inRule.SubjectName, effectText(inRule.Effect), inRule.Action, inRule.Object)
+    if inRule.Condition != "" {
+        text = tr().Sprintf("%s when %s", text, inRule.Condition)
+    }
+    return text
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+)
+
+
+// Kinds of subjects a rule is written for, each kind has its own table
+const (
+    policyKindRole  = "role"
+    policyKindUser  = "user"
+)
+
+var errPolicyDenied = errors.New("you shall not pass!.. the policy")
+
+// PolicyRule is one allow or deny rule of an organization as admins see it, with names instead of IDs
+type PolicyRule struct {
+    PolicyID        int
+    Kind            string
+    SubjectID       int
+    SubjectName     string
+    Object          string
+    Action          string
+    Effect          string
+    Condition       string      // empty when the rule always holds
+}
+
+// casbinRule is the rule in Casbin form in the domain, without the ptype
+func (r PolicyRule) casbinRule(inDomain string) []string {
+    subject := fmt.Sprintf("r%d", r.SubjectID)
+    if r.Kind == policyKindUser {
+        subject = fmt.Sprintf("u%d", r.SubjectID)
+    }
+    return []string{subject, inDomain, r.Object, r.Action, r.Effect, policyCondition(r.Condition)}
+}
+
+func (r PolicyRule) Text() string {
+    text := fmt.Sprintf("%s %s: %s %s on %s", r.Kind, r.SubjectName, r.Effect, r.Action, r.Object)
+    if r.Condition != "" {
+        text += " when " + r.Condition
+    }
+    return text
+}
+
+
+// policySubject finds the role or, failing that, the user with the name
+func policySubject(inDB dbRunner, inName string) (string, int, error) {
+    var subjectID int
+
+    err := inDB.QueryRow("SELECT role_dim_id FROM auth_role_dim WHERE role_name = ?", strings.TrimSpace(inName)).Scan(&subjectID)
+    if err == nil {
+        return policyKindRole, subjectID, nil
+    }
+    if !errors.Is(err, sql.ErrNoRows) {
+        return "", 0, err
+    }
+
+    err = inDB.QueryRow("SELECT user_id FROM user_dim WHERE username = ?", strings.TrimSpace(inName)).Scan(&subjectID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return "", 0, newError("there is no role or user called %q", strings.TrimSpace(inName))
+    }
+    if err != nil {
+        return "", 0, err
+    }
+
+    return policyKindUser, subjectID, nil
+}
+
+// listPolicyRules returns the organization's role rules followed by its user rules
+func listPolicyRules(inDB dbRunner, inOrganizationID int) ([]PolicyRule, error) {
+    rows, err := inDB.Query(`
+SELECT
+      'role'                    AS kind
+    , arp.role_policy_id        AS policy_id
+    , arp.subject               AS subject_id
+    , ard.role_name             AS subject_name
+    , arp.object                AS object
+    , arp.action                AS action
+    , arp.effect                AS effect
+    , arp.condition             AS condition
+FROM
+    auth_role_policy            AS arp
+    JOIN auth_role_dim          AS ard
+        ON ard.role_dim_id = arp.subject
+WHERE
+    arp.organization_id = ?
+UNION ALL
+SELECT
+      'user'                    AS kind
+    , aup.user_policy_id        AS policy_id
+    , aup.subject               AS subject_id
+    , ud.username               AS subject_name
+    , aup.object                AS object
+    , aup.action                AS action
+    , aup.effect                AS effect
+    , aup.condition             AS condition
+FROM
+    auth_user_policy            AS aup
+    JOIN user_dim               AS ud
+        ON ud.user_id = aup.subject
+WHERE
+    aup.organization_id = ?
+ORDER BY
+      kind
+    , subject_name
+    , object
+    , action
+    `, inOrganizationID, inOrganizationID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var rules []PolicyRule
+    for rows.Next() {
+        var rule PolicyRule
+        if err := rows.Scan(&rule.Kind, &rule.PolicyID, &rule.SubjectID, &rule.SubjectName, &rule.Object, &rule.Action, &rule.Effect, &rule.Condition); err != nil {
+            return nil, err
+        }
+        rules = append(rules, rule)
+    }
+
+    return rules, rows.Err()
+}
+
+// addPolicyRule checks the rule, its condition included, and adds it to the enforcer's organization. The adapter
+// stores and audits it in one transaction.
+func addPolicyRule(inEnforcer *OrgEnforcer, inRule PolicyRule) error {
+    inRule.Object    = strings.TrimSpace(inRule.Object)
+    inRule.Action    = strings.TrimSpace(inRule.Action)
+    inRule.Condition = strings.TrimSpace(inRule.Condition)
+
+    if inRule.Object == "" || inRule.Action == "" {
+        return newError("please enter an object and an action")
+    }
+    if inRule.Effect != "allow" && inRule.Effect != "deny" {
+        return newError("effect %q must be allow or deny", inRule.Effect)
+    }
+    if err := checkCondition(inRule.Condition); err != nil {
+        return err
+    }
+
+    // Casbin reports a rule it already has as added
+    rule        := inRule.casbinRule(inEnforcer.Domain())
+    exists, err := inEnforcer.HasPolicy(rule)
+    if err != nil {
+        return err
+    }
+    if exists {
+        return newError("the rule already exists")
+    }
+
+    _, err = inEnforcer.AddPolicy(rule)
+    return err
+}
+
+// removePolicyRule deletes one of the enforcer's organization's rules, through the adapter like addPolicyRule
+func removePolicyRule(inEnforcer *OrgEnforcer, inRule PolicyRule) error {
+    // Casbin hands a rule it doesn't have to the adapter all the same, which would audit a remove that never was
+    rule        := inRule.casbinRule(inEnforcer.Domain())
+    exists, err := inEnforcer.HasPolicy(rule)
+    if err != nil {
+        return err
+    }
+    if !exists {
+        return newError("the rule does not exist")
+    }
+
+    _, err = inEnforcer.RemovePolicy(rule)
+    return err
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+
+var errProjectGroupRule = errors.New("project groups (g2) are set on the projects, not in the policy")
+
+// PolicyDiff is what an import changes, rules are in Casbin form with the ptype first
+type PolicyDiff struct {
+    Added       [][]string
+    Removed     [][]string
+}
+
+func (d PolicyDiff) Empty() bool {
+    return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// Lines shows the diff with names, "+" for rules the import adds and "-" for rules it removes
+func (d PolicyDiff) Lines(inNames map[string]string) []string {
+    var lines []string
+    for _, rule := range d.Removed {
+        lines = append(lines, "- "+policyCSVLine(rule, inNames))
+    }
+    for _, rule := range d.Added {
+        lines = append(lines, "+ "+policyCSVLine(rule, inNames))
+    }
+    return lines
+}
+
+
+// casbinID reads the number of a Casbin subject or domain with the given prefix, e.g. 3 from "u3"
+func casbinID(inValue string, inPrefix string) (int, error) {
+    id, err := strconv.Atoi(strings.TrimPrefix(inValue, inPrefix))
+    if err != nil || !strings.HasPrefix(inValue, inPrefix) {
+        return 0, newError("%q is not a %s<id> value", inValue, inPrefix)
+    }
+    return id, nil
+}
+
+// casbinRuleRow maps a p or g rule in Casbin form to the auth table, columns and values it is stored as
+func casbinRuleRow(inPtype string, inRule []string) (string, []string, []any, error) {
+    switch inPtype {
+    case "p":
+        // sub, dom, obj, act, eft and the condition, which is stored empty when it always holds
+        if len(inRule) < 5 {
+            return "", nil, nil, newError("p rule %v needs a subject, domain, object, action and effect", inRule)
+        }
+        organizationID, err := casbinID(inRule[1], "o")
+        if err != nil {
+            return "", nil, nil, err
+        }
+        condition := ""
+        if len(inRule) > 5 && inRule[5] != policyCondition("") {
+            condition = inRule[5]
+        }
+        table, prefix := "auth_role_policy", "r"
+        if strings.HasPrefix(inRule[0], "u") {
+            table, prefix = "auth_user_policy", "u"
+        }
+        subjectID, err := casbinID(inRule[0], prefix)
+        if err != nil {
+            return "", nil, nil, err
+        }
+        return table, []string{"subject", "organization_id", "object", "action", "effect", "condition"},
+            []any{subjectID, organizationID, inRule[2], inRule[3], inRule[4], condition}, nil
+
+    case "g":
+        // a user in a role, or a role inheriting from another one
+        if len(inRule) < 3 {
+            return "", nil, nil, newError("g rule %v needs a subject, role and domain", inRule)
+        }
+        roleID, err := casbinID(inRule[1], "r")
+        if err != nil {
+            return "", nil, nil, err
+        }
+        organizationID, err := casbinID(inRule[2], "o")
+        if err != nil {
+            return "", nil, nil, err
+        }
+        if strings.HasPrefix(inRule[0], "r") {
+            childRoleID, err := casbinID(inRule[0], "r")
+            if err != nil {
+                return "", nil, nil, err
+            }
+            return "auth_role_inheritance", []string{"role_id", "parent_role_id", "organization_id"}, []any{childRoleID, roleID, organizationID}, nil
+        }
+        userID, err := casbinID(inRule[0], "u")
+        if err != nil {
+            return "", nil, nil, err
+        }
+        return "auth_user_role_map_policy", []string{"subject", "object", "organization_id"}, []any{userID, roleID, organizationID}, nil
+
+    default:
+        return "", nil, nil, errProjectGroupRule
+    }
+}
+
+// insertCasbinRule stores a rule in Casbin form in its auth table
+func insertCasbinRule(inDB dbRunner, inPtype string, inRule []string) error {
+    table, columns, values, err := casbinRuleRow(inPtype, inRule)
+    if err != nil {
+        return err
+    }
+
+    placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+    _, err = inDB.Exec("INSERT INTO "+table+" ("+strings.Join(columns, ", ")+") VALUES ("+placeholders+")", values...)
+    return err
+}
+
+// deleteCasbinRule removes a rule in Casbin form from its auth table
+func deleteCasbinRule(inDB dbRunner, inPtype string, inRule []string) error {
+    table, columns, values, err := casbinRuleRow(inPtype, inRule)
+    if err != nil {
+        return err
+    }
+
+    _, err = inDB.Exec("DELETE FROM "+table+" WHERE "+strings.Join(columns, " = ? AND ")+" = ?", values...)
+    return err
+}
+
+
+// currentPolicyRules returns the organization's p and g rules as the enforcer has them, ptype first and sorted
+func currentPolicyRules(inEnforcer *OrgEnforcer) ([][]string, error) {
+    policy, err := inEnforcer.GetPolicy()
+    if err != nil {
+        return nil, err
+    }
+    grouping, err := inEnforcer.GetFilteredNamedGroupingPolicy("g", 2, inEnforcer.Domain())
+    if err != nil {
+        return nil, err
+    }
+
+    var rules [][]string
+    for _, rule := range policy {
+        rules = append(rules, append([]string{"p"}, rule...))
+    }
+    for _, rule := range grouping {
+        rules = append(rules, append([]string{"g"}, rule...))
+    }
+    sortPolicyRules(rules)
+
+    return rules, nil
+}
+
+func sortPolicyRules(inRules [][]string) {
+    sort.SliceStable(inRules, func(i, j int) bool {
+        return strings.Join(inRules[i], "\x00") < strings.Join(inRules[j], "\x00")
+    })
+}
+
+// policyCSVLine is a rule as a Casbin CSV line with subjects, roles and domains named
+func policyCSVLine(inRule []string, inNames map[string]string) string {
+    fields := make([]string, len(inRule))
+    for i, field := range inRule {
+        // Objects, actions, effects and conditions stay as they are
+        named, found := inNames[field]
+        if !found || (inRule[0] == "p" && i > 2) {
+            named = field
+        }
+        fields[i] = csvField(named)
+    }
+    return strings.Join(fields, ", ")
+}
+
+// csvField quotes a field the way Casbin's CSV reader expects when it holds a comma or a quote
+func csvField(inField string) string {
+    if !strings.ContainsAny(inField, ",\"\n") && strings.TrimSpace(inField) == inField {
+        return inField
+    }
+    return `"` + strings.ReplaceAll(inField, `"`, `""`) + `"`
+}
+
+// writePolicyCSV writes the organization's effective policy as Casbin CSV with names resolved
+func writePolicyCSV(inWriter io.Writer, inEnforcer *OrgEnforcer, inNames map[string]string) (int, error) {
+    rules, err := currentPolicyRules(inEnforcer)
+    if err != nil {
+        return 0, err
+    }
+
+    if _, err := fmt.Fprintf(inWriter, "# Policy of %s - p, subject, organization, object, action, effect, condition and g, subject, role, organization\n", inNames[inEnforcer.Domain()]); err != nil {
+        return 0, err
+    }
+    for _, rule := range rules {
+        if _, err := fmt.Fprintln(inWriter, policyCSVLine(rule, inNames)); err != nil {
+            return 0, err
+        }
+    }
+
+    return len(rules), nil
+}
+
+// readPolicyCSV reads Casbin CSV with names into rules in Casbin form for the organization.
+// Every line has to be about the organization, names have to exist and conditions have to work.
+func readPolicyCSV(inReader io.Reader, inDB dbRunner, inOrganizationID int) ([][]string, error) {
+    names, err := subjectNames(inDB)
+    if err != nil {
+        return nil, err
+    }
+    // Roles win over users of the same name, the same way the policy editor resolves them
+    subjects := map[string]string{}
+    for _, prefix := range []string{"o", "u", "r"} {
+        for subject, name := range names {
+            if strings.HasPrefix(subject, prefix) {
+                subjects[prefix+":"+strings.ToLower(name)] = subject
+                if prefix != "o" {
+                    subjects[strings.ToLower(name)] = subject
+                }
+            }
+        }
+    }
+    domain := organizationDomain(inOrganizationID)
+
+    reader                 := csv.NewReader(inReader)
+    reader.Comment          = '#'
+    reader.TrimLeadingSpace = true
+    reader.FieldsPerRecord  = -1
+
+    var rules [][]string
+    var edges []RoleInheritance
+    seen := map[string]bool{}
+    for {
+        record, err := reader.Read()
+        if errors.Is(err, io.EOF) {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+        line, _ := reader.FieldPos(0)
+        for i := range record {
+            record[i] = strings.TrimSpace(record[i])
+        }
+
+        lineErr := func(inErr error) error {
+            return fmt.Errorf("%w: %w", newError("line %d", line), inErr)
+        }
+        subject := func(inName string, inOnlyRoles bool) (string, error) {
+            key, missing := strings.ToLower(inName), newError("there is no role or user called %q", inName)
+            if inOnlyRoles {
+                key, missing = "r:"+key, newError("there is no role called %q", inName)
+            }
+            if found, ok := subjects[key]; ok {
+                return found, nil
+            }
+            return "", lineErr(missing)
+        }
+        inDomain := func(inName string) error {
+            if subjects["o:"+strings.ToLower(inName)] != domain {
+                return lineErr(newError("the rule is for %q, not for %s", inName, names[domain]))
+            }
+            return nil
+        }
+
+        var rule []string
+        switch record[0] {
+        case "p":
+            if len(record) != 6 && len(record) != 7 {
+                return nil, lineErr(newError("p rules have a subject, organization, object, action, effect and an optional condition"))
+            }
+            sub, err := subject(record[1], false)
+            if err != nil {
+                return nil, err
+            }
+            if err := inDomain(record[2]); err != nil {
+                return nil, err
+            }
+            if record[5] != "allow" && record[5] != "deny" {
+                return nil, lineErr(newError("effect %q must be allow or deny", record[5]))
+            }
+            condition := ""
+            if len(record) == 7 && record[6] != policyCondition("") {
+                condition = record[6]
+            }
+            if err := checkCondition(condition); err != nil {
+                return nil, lineErr(err)
+            }
+            rule = []string{"p", sub, domain, record[3], record[4], record[5], policyCondition(condition)}
+
+        case "g":
+            if len(record) != 4 {
+                return nil, lineErr(newError("g rules have a subject, role and organization"))
+            }
+            sub, err := subject(record[1], false)
+            if err != nil {
+                return nil, err
+            }
+            role, err := subject(record[2], true)
+            if err != nil {
+                return nil, err
+            }
+            if err := inDomain(record[3]); err != nil {
+                return nil, err
+            }
+            // Role inheritance must not loop, the same check the roles window makes
+            if strings.HasPrefix(sub, "r") {
+                roleID, _   := casbinID(sub, "r")
+                parentID, _ := casbinID(role, "r")
+                if roleInheritanceCycle(edges, roleID, parentID) {
+                    return nil, lineErr(errRoleCycle)
+                }
+                edges = append(edges, RoleInheritance{RoleID: roleID, ParentRoleID: parentID})
+            }
+            rule = []string{"g", sub, role, domain}
+
+        case "g2":
+            continue
+
+        default:
+            return nil, lineErr(newError("unknown rule type %q", record[0]))
+        }
+
+        key := strings.Join(rule, "\x00")
+        if !seen[key] {
+            seen[key] = true
+            rules     = append(rules, rule)
+        }
+    }
+    sortPolicyRules(rules)
+
+    return rules, nil
+}
+
+// diffPolicy tells which rules an import would add and which it would remove
+func diffPolicy(inCurrent [][]string, inImported [][]string) PolicyDiff {
+    inSet := func(inRules [][]string) map[string]bool {
+        set := map[string]bool{}
+        for _, rule := range inRules {
+            set[strings.Join(rule, "\x00")] = true
+        }
+        return set
+    }
+    current  := inSet(inCurrent)
+    imported := inSet(inImported)
+
+    var diff PolicyDiff
+    for _, rule := range inCurrent {
+        if !imported[strings.Join(rule, "\x00")] {
+            diff.Removed = append(diff.Removed, rule)
+        }
+    }
+    for _, rule := range inImported {
+        if !current[strings.Join(rule, "\x00")] {
+            diff.Added = append(diff.Added, rule)
+        }
+    }
+
+    return diff
+}
+
+// importPolicyCSV reads the CSV, diffs it against the enforcer's policy and, unless inDryRun, applies the diff through
+// the adapter. Running apps pick the change up through the policy watcher.
+func importPolicyCSV(inReader io.Reader, inDB dbRunner, inEnforcer *OrgEnforcer, inDryRun bool) (PolicyDiff, error) {
+    imported, err := readPolicyCSV(inReader, inDB, inEnforcer.OrganizationID)
+    if err != nil {
+        return PolicyDiff{}, err
+    }
+    current, err := currentPolicyRules(inEnforcer)
+    if err != nil {
+        return PolicyDiff{}, err
+    }
+
+    diff := diffPolicy(current, imported)
+    if inDryRun || diff.Empty() {
+        return diff, nil
+    }
+
+    adapter, ok := inEnforcer.GetAdapter().(*CustomAdapter)
+    if !ok {
+        return diff, newError("the enforcer does not store its policy in the auth tables")
+    }
+
+    return diff, adapter.ApplyPolicyDiff(diff)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+)
+
+func Test_writePolicyCSV(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    names, err := subjectNames(db)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var out bytes.Buffer
+    count, err := writePolicyCSV(&out, enforcer, names)
+    if err != nil || count == 0 {
+        t.Fatalf("writePolicyCSV() = %d, %v", count, err)
+    }
+    for _, want := range []string{
+        "p, B_admin, Steaby, check_access, read, allow, true\n",
+        "p, Petar, Steaby, time_entry, edit, deny, r.env.Client == 'ACME'\n",
+        `p, B_minion, Steaby, time_entry, edit, allow, "r.env.Owner == r.sub && withinDays(r.env.EntryDate, r.env.Today, 7)"` + "\n",
+        "g, Ray, B_admin, Steaby\n",
+        "g, B_minion, B_base, Steaby\n",
+    } {
+        if !strings.Contains(out.String(), want) {
+            t.Errorf("writePolicyCSV() is missing %q", want)
+        }
+    }
+    if strings.Contains(out.String(), "Steaby Labs") || strings.Contains(out.String(), "g2") {
+        t.Errorf("writePolicyCSV() wrote rules of other organizations or project groups")
+    }
+
+    // Importing the export changes nothing
+    diff, err := importPolicyCSV(strings.NewReader(out.String()), db, enforcer, true)
+    if err != nil || !diff.Empty() {
+        t.Errorf("importPolicyCSV() of the export = %+v, %v", diff, err)
+    }
+}
+
+func Test_importPolicyCSV(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    names, _ := subjectNames(db)
+
+    var out bytes.Buffer
+    if _, err := writePolicyCSV(&out, enforcer, names); err != nil {
+        t.Fatal(err)
+    }
+    // Petar joins the admins and loses the ACME deny, Tadej may read invoices
+    edited := strings.Replace(out.String(), "p, Petar, Steaby, time_entry, edit, deny, r.env.Client == 'ACME'\n", "", 1)
+    edited += "g, Petar, B_admin, Steaby\np, Tadej, Steaby, invoice, read, allow\n"
+
+    diff, err := importPolicyCSV(strings.NewReader(edited), db, enforcer, true)
+    if err != nil || len(diff.Added) != 2 || len(diff.Removed) != 1 {
+        t.Fatalf("importPolicyCSV() diff = %+v, %v", diff, err)
+    }
+    lines := diff.Lines(names)
+    if lines[0] != "- p, Petar, Steaby, time_entry, edit, deny, r.env.Client == 'ACME'" || lines[1] != "+ g, Petar, B_admin, Steaby" {
+        t.Errorf("Lines() = %q", lines)
+    }
+
+    // Applying goes through the adapter into the auth tables
+    if _, err := importPolicyCSV(strings.NewReader(edited), db, enforcer, false); err != nil {
+        t.Fatalf("importPolicyCSV() error = %v", err)
+    }
+    var roles, denies int
+    db.QueryRow("SELECT COUNT(*) FROM auth_user_role_map_policy WHERE subject = 3 AND organization_id = 1").Scan(&roles)
+    db.QueryRow("SELECT COUNT(*) FROM auth_user_policy WHERE subject = 3 AND object = 'time_entry'").Scan(&denies)
+    if roles != 2 || denies != 0 {
+        t.Errorf("after the import Petar has %d roles and %d edit denies, want 2 and 0", roles, denies)
+    }
+    if err := enforcer.LoadPolicy(); err != nil {
+        t.Fatal(err)
+    }
+    if allowed, _ := enforcer.Enforce("u2", "invoice", "read"); !allowed {
+        t.Errorf("Enforce() after the import: Tadej may not read invoices")
+    }
+    diff, err = importPolicyCSV(strings.NewReader(edited), db, enforcer, true)
+    if err != nil || !diff.Empty() {
+        t.Errorf("importPolicyCSV() again = %+v, %v", diff, err)
+    }
+}
+
+func Test_readPolicyCSV(t *testing.T) {
+    db, _ := openTestDb(t)
+
+    tests := []struct {
+        name     string
+        csv      string
+        wantLen  int
+        wantErr  string
+    }{
+        {"rules and comments",  "# policy\np, B_minion, Steaby, invoice, read, allow\ng, Petar, B_admin, Steaby\n",   2, ""},
+        {"condition quoted",    `p, Tadej, Steaby, time_entry, edit, allow, "withinDays(r.env.EntryDate, r.env.Today, 3)"`,  1, ""},
+        {"duplicates once",     "p, Ray, Steaby, x, read, allow\np, Ray, Steaby, x, read, allow, true\n",              1, ""},
+        {"project groups",      "g2, project_1, client_work\n",                                                          0, ""},
+        {"unknown user",        "p, Nobody, Steaby, x, read, allow\n",                                                   0, "line 1: there is no role or user called \"Nobody\""},
+        {"user as a role",      "g, Petar, Tadej, Steaby\n",                                                             0, "no role called"},
+        {"other organization",  "p, Ray, Steaby Labs, x, read, allow\n",                                                 0, "not for Steaby"},
+        {"bad effect",          "p, Ray, Steaby, x, read, maybe\n",                                                      0, "effect"},
+        {"bad condition",       "p, Ray, Steaby, x, read, allow, r.env.Owner ==\n",                                      0, "does not work"},
+        {"role cycle",          "g, B_admin, B_base, Steaby\ng, B_base, B_admin, Steaby\n",                              0, "line 2"},
+        {"unknown type",        "q, Ray, Steaby\n",                                                                      0, "unknown rule type"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rules, err := readPolicyCSV(strings.NewReader(tt.csv), db, 1)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Errorf("readPolicyCSV() error = %v, want %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil || len(rules) != tt.wantLen {
+                t.Errorf("readPolicyCSV() = %v, %v, want %d rules", rules, err, tt.wantLen)
+            }
+        })
+    }
+}
+
+func Test_CustomAdapter_AddPolicy(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+
+    // With auto-save the enforcer writes through the adapter
+    if _, err := enforcer.AddPolicy("r2", "o1", "invoice", "read", "allow", "true"); err != nil {
+        t.Fatalf("AddPolicy() error = %v", err)
+    }
+    if _, err := enforcer.AddNamedGroupingPolicy("g", "r1", "r2", "o1"); err != nil {
+        t.Fatalf("AddNamedGroupingPolicy() error = %v", err)
+    }
+    var rules, edges int
+    db.QueryRow("SELECT COUNT(*) FROM auth_role_policy WHERE subject = 2 AND object = 'invoice' AND action = 'read' AND condition = ''").Scan(&rules)
+    db.QueryRow("SELECT COUNT(*) FROM auth_role_inheritance WHERE role_id = 1 AND parent_role_id = 2").Scan(&edges)
+    if rules != 1 || edges != 1 {
+        t.Errorf("AddPolicy() stored %d rules and %d edges, want 1 and 1", rules, edges)
+    }
+
+    if _, err := enforcer.RemovePolicy("r2", "o1", "invoice", "read", "allow", "true"); err != nil {
+        t.Fatalf("RemovePolicy() error = %v", err)
+    }
+    db.QueryRow("SELECT COUNT(*) FROM auth_role_policy WHERE subject = 2 AND object = 'invoice' AND action = 'read'").Scan(&rules)
+    if rules != 0 {
+        t.Errorf("RemovePolicy() left %d rules", rules)
+    }
+
+    // Tadej loses his roles in the labs and keeps the ones at Steaby, by batch and by filter
+    var labsRoles, steabyRoles int
+    countRoles := func() {
+        db.QueryRow("SELECT COUNT(*) FROM auth_user_role_map_policy WHERE subject = 2 AND organization_id = 2").Scan(&labsRoles)
+        db.QueryRow("SELECT COUNT(*) FROM auth_user_role_map_policy WHERE subject = 2 AND organization_id = 1").Scan(&steabyRoles)
+    }
+    if _, err := enforcer.DeleteRolesForUserInDomain("u2", "o2"); err != nil {
+        t.Fatalf("DeleteRolesForUserInDomain() error = %v", err)
+    }
+    if countRoles(); labsRoles != 0 || steabyRoles != 1 {
+        t.Errorf("DeleteRolesForUserInDomain() left %d roles in the labs and %d at Steaby, want 0 and 1", labsRoles, steabyRoles)
+    }
+    if _, err := enforcer.AddGroupingPolicy("u2", "r1", "o2"); err != nil {
+        t.Fatalf("AddGroupingPolicy() error = %v", err)
+    }
+    if _, err := enforcer.RemoveFilteredGroupingPolicy(0, "u2", "", "o2"); err != nil {
+        t.Fatalf("RemoveFilteredGroupingPolicy() error = %v", err)
+    }
+    if countRoles(); labsRoles != 0 || steabyRoles != 1 {
+        t.Errorf("RemoveFilteredGroupingPolicy() left %d roles in the labs and %d at Steaby, want 0 and 1", labsRoles, steabyRoles)
+    }
+}
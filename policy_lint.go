@@ -0,0 +1,385 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "slices"
+    "sort"
+    "strings"
+)
+
+
+// Severities of lint findings, errors fail the policy lint command
+const (
+    lintError   = "error"
+    lintWarning = "warning"
+)
+
+// policyObjects lists every object the code asks the enforcer about and the actions it asks for.
+// Rules on anything else never match a request. Project and project group objects come from the database.
+var policyObjects = map[string][]string{
+    "admin_text":           {"read"},
+    "report_text":          {"read"},
+    "inputbox_client_name": {"read", "write"},
+    "inputbox_time_spent":  {"read", "write"},
+    timesheetObject:        {timesheetActSubmit, timesheetActApprove, timesheetActReject},
+    invoiceObject:          {"write"},
+    timeEntryObject:        {"read", "write", timeEntryActEdit},
+    teamTimeEntryObject:    {"read"},
+    reportHoursByClient:    {"read"},
+    reportBillable:         {"read"},
+    checkAccessObject:      {"read"},
+    budgetOverrideObject:   {"write"},
+    roleObject:             {"write"},
+    policyObject:           {"write"},
+    auditObject:            {"read"},
+}
+
+// LintFinding is one problem the policy linter found
+type LintFinding struct {
+    Severity    string
+    Message     string
+}
+
+func (f LintFinding) Text() string {
+    return f.Severity + ": " + f.Message
+}
+
+// lintRule is a rule as stored, names are empty when the subject or organization is missing
+type lintRule struct {
+    PolicyRule
+    OrganizationID      int
+    OrganizationName    string
+}
+
+func (r lintRule) Text() string {
+    if r.SubjectName == "" {
+        r.SubjectName = fmt.Sprintf("#%d", r.SubjectID)
+    }
+    if r.OrganizationName == "" {
+        r.OrganizationName = fmt.Sprintf("#%d", r.OrganizationID)
+    }
+    return fmt.Sprintf("%s rule %d in %s (%s)", r.Kind, r.PolicyID, r.OrganizationName, r.PolicyRule.Text())
+}
+
+
+// lintPolicy cross-references the auth tables of every organization with the roles, users and the objects
+// the code checks. Errors are rules that can't work or are overruled, warnings are rules that do nothing.
+func lintPolicy(inDB dbRunner) ([]LintFinding, error) {
+    var findings []LintFinding
+    report := func(inSeverity string, inFormat string, inArgs ...any) {
+        findings = append(findings, LintFinding{Severity: inSeverity, Message: fmt.Sprintf(inFormat, inArgs...)})
+    }
+
+    // Objects declared in the database, projects and the groups they belong to
+    objects, err := lintDatabaseObjects(inDB)
+    if err != nil {
+        return nil, err
+    }
+
+    rules, err := lintRules(inDB)
+    if err != nil {
+        return nil, err
+    }
+
+    type ruleKey struct {
+        kind, object, action    string
+        subjectID, orgID        int
+    }
+    byKey := map[ruleKey][]lintRule{}
+
+    for _, rule := range rules {
+        switch {
+        case rule.OrganizationName == "":
+            report(lintError, "%s belongs to organization %d, which does not exist", rule.Text(), rule.OrganizationID)
+        case rule.SubjectName == "":
+            report(lintError, "%s is for a %s that does not exist", rule.Text(), rule.Kind)
+        }
+        if rule.Effect != "allow" && rule.Effect != "deny" {
+            report(lintError, "%s has effect %q, not allow or deny", rule.Text(), rule.Effect)
+        }
+        if err := checkCondition(rule.Condition); err != nil {
+            report(lintError, "%s: %v", rule.Text(), err)
+        }
+
+        actions, known := policyObjects[rule.Object]
+        if !known {
+            actions, known = objects[rule.Object]
+        }
+        switch {
+        case !known:
+            report(lintWarning, "%s: no widget or check uses the object %q", rule.Text(), rule.Object)
+        case !slices.Contains(actions, rule.Action) && rule.Action != "read" && rule.Action != "write":
+            report(lintWarning, "%s: action %q is neither read nor write and nothing checks it on %s", rule.Text(), rule.Action, rule.Object)
+        case !slices.Contains(actions, rule.Action):
+            report(lintWarning, "%s: nothing checks %s on %s", rule.Text(), rule.Action, rule.Object)
+        }
+
+        key       := ruleKey{rule.Kind, rule.Object, rule.Action, rule.SubjectID, rule.OrganizationID}
+        byKey[key] = append(byKey[key], rule)
+    }
+
+    // Deny wins over allow, an allow next to an unconditional deny never applies
+    var keys []ruleKey
+    for key := range byKey {
+        keys = append(keys, key)
+    }
+    sort.Slice(keys, func(i, j int) bool { return byKey[keys[i]][0].PolicyID < byKey[keys[j]][0].PolicyID })
+    for _, key := range keys {
+        same := byKey[key]
+        for i, rule := range same {
+            for _, other := range same[i+1:] {
+                switch {
+                case rule.Effect == other.Effect && rule.Condition == other.Condition:
+                    report(lintWarning, "%s repeats %s rule %d", other.Text(), rule.Kind, rule.PolicyID)
+                case rule.Effect == other.Effect:
+                    continue
+                case rule.Condition == "" && rule.Effect == "deny", other.Condition == "" && other.Effect == "deny":
+                    report(lintError, "%s conflicts with %s rule %d, the deny always wins", other.Text(), rule.Kind, rule.PolicyID)
+                default:
+                    report(lintWarning, "%s conflicts with %s rule %d when both conditions hold", other.Text(), rule.Kind, rule.PolicyID)
+                }
+            }
+        }
+    }
+
+    mappingFindings, err := lintRoleMappings(inDB)
+    if err != nil {
+        return nil, err
+    }
+    findings = append(findings, mappingFindings...)
+
+    inheritanceFindings, err := lintRoleInheritance(inDB)
+    if err != nil {
+        return nil, err
+    }
+    findings = append(findings, inheritanceFindings...)
+
+    return findings, nil
+}
+
+// lintDatabaseObjects returns the project and project group objects with the actions the code checks on them
+func lintDatabaseObjects(inDB dbRunner) (map[string][]string, error) {
+    objects := map[string][]string{}
+
+    rows, err := inDB.Query(`
+SELECT 'project_' || project_id     FROM project
+UNION ALL
+SELECT group_name                   FROM project_group
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var object string
+        if err := rows.Scan(&object); err != nil {
+            return nil, err
+        }
+        objects[object] = []string{"read", "write"}
+    }
+
+    return objects, rows.Err()
+}
+
+// lintRules returns the role and user rules of all organizations, missing subjects and organizations included
+func lintRules(inDB dbRunner) ([]lintRule, error) {
+    rows, err := inDB.Query(`
+SELECT
+      'role'                            AS kind
+    , arp.role_policy_id                AS policy_id
+    , arp.subject                       AS subject_id
+    , COALESCE(ard.role_name, '')       AS subject_name
+    , arp.object                        AS object
+    , COALESCE(arp.action, '')          AS action
+    , COALESCE(arp.effect, '')          AS effect
+    , arp.condition                     AS condition
+    , arp.organization_id               AS organization_id
+    , COALESCE(o.organization_name, '') AS organization_name
+FROM
+    auth_role_policy                    AS arp
+    LEFT JOIN auth_role_dim             AS ard
+        ON ard.role_dim_id = arp.subject
+    LEFT JOIN organization              AS o
+        ON o.organization_id = arp.organization_id
+UNION ALL
+SELECT
+      'user'                            AS kind
+    , aup.user_policy_id                AS policy_id
+    , aup.subject                       AS subject_id
+    , COALESCE(ud.username, '')         AS subject_name
+    , aup.object                        AS object
+    , COALESCE(aup.action, '')          AS action
+    , COALESCE(aup.effect, '')          AS effect
+    , aup.condition                     AS condition
+    , aup.organization_id               AS organization_id
+    , COALESCE(o.organization_name, '') AS organization_name
+FROM
+    auth_user_policy                    AS aup
+    LEFT JOIN user_dim                  AS ud
+        ON ud.user_id = aup.subject
+    LEFT JOIN organization              AS o
+        ON o.organization_id = aup.organization_id
+ORDER BY
+      kind
+    , policy_id
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var rules []lintRule
+    for rows.Next() {
+        var rule lintRule
+        if err := rows.Scan(&rule.Kind, &rule.PolicyID, &rule.SubjectID, &rule.SubjectName, &rule.Object, &rule.Action,
+            &rule.Effect, &rule.Condition, &rule.OrganizationID, &rule.OrganizationName); err != nil {
+            return nil, err
+        }
+        rules = append(rules, rule)
+    }
+
+    return rules, rows.Err()
+}
+
+// lintRoleMappings reports g mappings whose user, role or organization does not exist
+func lintRoleMappings(inDB dbRunner) ([]LintFinding, error) {
+    rows, err := inDB.Query(`
+SELECT
+      m.map_policy_id
+    , m.subject
+    , m.object
+    , m.organization_id
+    , ud.user_id IS NOT NULL            AS has_user
+    , ard.role_dim_id IS NOT NULL       AS has_role
+    , o.organization_id IS NOT NULL     AS has_organization
+FROM
+    auth_user_role_map_policy           AS m
+    LEFT JOIN user_dim                  AS ud
+        ON ud.user_id = m.subject
+    LEFT JOIN auth_role_dim             AS ard
+        ON ard.role_dim_id = m.object
+    LEFT JOIN organization              AS o
+        ON o.organization_id = m.organization_id
+ORDER BY
+    m.map_policy_id
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var findings []LintFinding
+    for rows.Next() {
+        var mapID, organizationID int
+        var userID, roleID string
+        var hasUser, hasRole, hasOrganization bool
+        if err := rows.Scan(&mapID, &userID, &roleID, &organizationID, &hasUser, &hasRole, &hasOrganization); err != nil {
+            return nil, err
+        }
+
+        var missing []string
+        if !hasUser {
+            missing = append(missing, "user "+userID)
+        }
+        if !hasRole {
+            missing = append(missing, "role "+roleID)
+        }
+        if !hasOrganization {
+            missing = append(missing, fmt.Sprintf("organization %d", organizationID))
+        }
+        if len(missing) > 0 {
+            findings = append(findings, LintFinding{
+                Severity: lintError,
+                Message:  fmt.Sprintf("role mapping %d points to %s, which does not exist", mapID, strings.Join(missing, " and ")),
+            })
+        }
+    }
+
+    return findings, rows.Err()
+}
+
+// lintRoleInheritance reports inheritance edges between missing roles and edges that are part of a cycle
+func lintRoleInheritance(inDB dbRunner) ([]LintFinding, error) {
+    rows, err := inDB.Query(`
+SELECT
+      ari.role_inheritance_id
+    , ari.organization_id
+    , ari.role_id
+    , COALESCE(role.role_name, '')
+    , ari.parent_role_id
+    , COALESCE(parent.role_name, '')
+FROM
+    auth_role_inheritance               AS ari
+    LEFT JOIN auth_role_dim             AS role
+        ON role.role_dim_id = ari.role_id
+    LEFT JOIN auth_role_dim             AS parent
+        ON parent.role_dim_id = ari.parent_role_id
+ORDER BY
+    ari.role_inheritance_id
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var findings []LintFinding
+    edgesByOrg := map[int][]RoleInheritance{}
+    for rows.Next() {
+        var edge RoleInheritance
+        var organizationID int
+        if err := rows.Scan(&edge.RoleInheritanceID, &organizationID, &edge.RoleID, &edge.RoleName, &edge.ParentRoleID, &edge.ParentRoleName); err != nil {
+            return nil, err
+        }
+        if edge.RoleName == "" || edge.ParentRoleName == "" {
+            findings = append(findings, LintFinding{
+                Severity: lintError,
+                Message:  fmt.Sprintf("role inheritance %d links role %d to role %d, one of them does not exist", edge.RoleInheritanceID, edge.RoleID, edge.ParentRoleID),
+            })
+            continue
+        }
+        edgesByOrg[organizationID] = append(edgesByOrg[organizationID], edge)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    // An edge is in a cycle when its role is among its parent's ancestors
+    var organizationIDs []int
+    for organizationID := range edgesByOrg {
+        organizationIDs = append(organizationIDs, organizationID)
+    }
+    sort.Ints(organizationIDs)
+    for _, organizationID := range organizationIDs {
+        edges := edgesByOrg[organizationID]
+        for _, edge := range edges {
+            if roleInheritanceCycle(edges, edge.RoleID, edge.ParentRoleID) {
+                findings = append(findings, LintFinding{
+                    Severity: lintError,
+                    Message:  fmt.Sprintf("role inheritance %d (%s) in organization %d is part of a cycle", edge.RoleInheritanceID, edge.Text(), organizationID),
+                })
+            }
+        }
+    }
+
+    return findings, nil
+}
+
+// writeLintFindings prints the findings errors first and returns the number of errors
+func writeLintFindings(inOut io.Writer, inFindings []LintFinding) int {
+    sort.SliceStable(inFindings, func(i, j int) bool {
+        return inFindings[i].Severity == lintError && inFindings[j].Severity != lintError
+    })
+
+    errorCount := 0
+    for _, finding := range inFindings {
+        if finding.Severity == lintError {
+            errorCount++
+        }
+        fmt.Fprintln(inOut, finding.Text())
+    }
+    fmt.Fprintf(inOut, "%d errors, %d warnings\n", errorCount, len(inFindings)-errorCount)
+
+    return errorCount
+}
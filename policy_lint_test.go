@@ -0,0 +1,77 @@
+package main
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+)
+
+func Test_lintPolicy(t *testing.T) {
+    tests := []struct {
+        name         string
+        insert       string
+        wantSeverity string
+        wantMessage  string
+    }{
+        {"unused object",         "INSERT INTO auth_role_policy (subject, object, action, effect) VALUES (2, 'admin_txt', 'read', 'allow')",                  lintWarning, `uses the object "admin_txt"`},
+        {"unknown action",        "INSERT INTO auth_role_policy (subject, object, action, effect) VALUES (2, 'invoice', 'print', 'allow')",                   lintWarning, `action "print" is neither read nor write`},
+        {"unchecked action",      "INSERT INTO auth_role_policy (subject, object, action, effect) VALUES (2, 'admin_text', 'write', 'allow')",                lintWarning, "nothing checks write on admin_text"},
+        {"project objects",       "INSERT INTO auth_role_policy (subject, object, action, effect) VALUES (2, 'project_99', 'read', 'allow')",                 lintWarning, `"project_99"`},
+        {"missing role",          "INSERT INTO auth_role_policy (subject, object, action, effect) VALUES (99, 'invoice', 'write', 'allow')",                  lintError,   "is for a role that does not exist"},
+        {"missing organization",  "INSERT INTO auth_user_policy (subject, object, action, effect, organization_id) VALUES (2, 'invoice', 'write', 'allow', 9)", lintError, "organization 9, which does not exist"},
+        {"bad effect",            "INSERT INTO auth_user_policy (subject, object, action, effect) VALUES (2, 'invoice', 'write', 'maybe')",                   lintError,   `effect "maybe"`},
+        {"bad condition",         "INSERT INTO auth_user_policy (subject, object, action, effect, condition) VALUES (2, 'invoice', 'write', 'allow', 'r.env.Owner ==')", lintError, "does not work"},
+        {"allow and deny",        "INSERT INTO auth_role_policy (subject, object, action, effect) VALUES (1, 'invoice', 'write', 'deny')",                    lintError,   "the deny always wins"},
+        {"conditional deny",      "INSERT INTO auth_role_policy (subject, object, action, effect, condition) VALUES (1, 'invoice', 'write', 'deny', 'r.env.Client == ''ACME''')", lintWarning, "when both conditions hold"},
+        {"duplicate rule",        "INSERT INTO auth_role_policy (subject, object, action, effect) VALUES (1, 'invoice', 'write', 'allow')",                   lintWarning, "repeats role rule"},
+        {"mapping to no role",    "INSERT INTO auth_user_role_map_policy (subject, object) VALUES (3, 99)",                                                  lintError,   "points to role 99, which does not exist"},
+        {"mapping of no user",    "INSERT INTO auth_user_role_map_policy (subject, object) VALUES (42, 1)",                                                  lintError,   "points to user 42"},
+        {"inheriting no role",    "INSERT INTO auth_role_inheritance (role_id, parent_role_id) VALUES (2, 99)",                                              lintError,   "one of them does not exist"},
+        {"inheritance cycle",     "INSERT INTO auth_role_inheritance (role_id, parent_role_id) VALUES (3, 1)",                                               lintError,   "is part of a cycle"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db, _ := openTestDb(t)
+            if _, err := db.Exec(tt.insert); err != nil {
+                t.Fatal(err)
+            }
+
+            findings, err := lintPolicy(db)
+            if err != nil {
+                t.Fatalf("lintPolicy() error = %v", err)
+            }
+            for _, finding := range findings {
+                if finding.Severity == tt.wantSeverity && strings.Contains(finding.Message, tt.wantMessage) {
+                    return
+                }
+            }
+            t.Errorf("lintPolicy() = %+v, want a %s containing %q", findings, tt.wantSeverity, tt.wantMessage)
+        })
+    }
+}
+
+func Test_runPolicyLintCommand(t *testing.T) {
+    db, _ := openTestDb(t)
+
+    var stdout, stderr bytes.Buffer
+    if code := runCommand([]string{"policy", "lint"}, db, &stdout, &stderr); code != 0 {
+        t.Fatalf("policy lint of the test policy = %d, output %q %q", code, stdout.String(), stderr.String())
+    }
+
+    // Warnings only fail with -strict, errors always
+    db.Exec("INSERT INTO auth_role_policy (subject, object, action, effect) VALUES (2, 'admin_txt', 'read', 'allow')")
+    if code := runCommand([]string{"policy", "lint"}, db, &stdout, &stderr); code != 0 {
+        t.Errorf("policy lint with a warning = %d, want 0", code)
+    }
+    if code := runCommand([]string{"policy", "lint", "-strict"}, db, &stdout, &stderr); code != 1 {
+        t.Errorf("policy lint -strict with a warning = %d, want 1", code)
+    }
+    db.Exec("INSERT INTO auth_user_role_map_policy (subject, object) VALUES (3, 99)")
+    stdout.Reset()
+    if code := runCommand([]string{"policy", "lint"}, db, &stdout, &stderr); code != 1 {
+        t.Errorf("policy lint with an error = %d, want 1", code)
+    }
+    if !strings.HasPrefix(stdout.String(), "error: ") || !strings.HasSuffix(stdout.String(), "1 errors, 1 warnings\n") {
+        t.Errorf("policy lint output = %q", stdout.String())
+    }
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+func Test_policySubject(t *testing.T) {
+    db, _ := openTestDb(t)
+
+    tests := []struct {
+        name     string
+        subject  string
+        wantKind string
+        wantID   int
+        wantErr  bool
+    }{
+        {"role",        "B_minion",  policyKindRole, 2, false},
+        {"user",        "Petar",     policyKindUser, 3, false},
+        {"trimmed",     " B_base ",  policyKindRole, 3, false},
+        {"unknown",     "Nobody",    "",             0, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            kind, id, err := policySubject(db, tt.subject)
+            if (err != nil) != tt.wantErr || kind != tt.wantKind || id != tt.wantID {
+                t.Errorf("policySubject() = %s, %d, %v, want %s, %d", kind, id, err, tt.wantKind, tt.wantID)
+            }
+        })
+    }
+}
+
+func Test_addPolicyRule(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    enforcer.SetActor(1)
+
+    tests := []struct {
+        name     string
+        rule     PolicyRule
+        wantErr  bool
+    }{
+        {"plain rule",      PolicyRule{Kind: policyKindRole, SubjectID: 1, Object: "time_entry", Action: "edit", Effect: "allow"},                                 false},
+        {"with condition",  PolicyRule{Kind: policyKindUser, SubjectID: 2, Object: "time_entry", Action: "edit", Effect: "deny", Condition: "r.env.Client == 'Globex'"}, false},
+        {"bad condition",   PolicyRule{Kind: policyKindRole, SubjectID: 1, Object: "time_entry", Action: "edit", Effect: "allow", Condition: "r.env.Owner =="},   true},
+        {"bad effect",      PolicyRule{Kind: policyKindRole, SubjectID: 1, Object: "time_entry", Action: "edit", Effect: "maybe"},                                 true},
+        {"no action",       PolicyRule{Kind: policyKindRole, SubjectID: 1, Object: "time_entry", Effect: "allow"},                                                 true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if err := addPolicyRule(enforcer, tt.rule); (err != nil) != tt.wantErr {
+                t.Errorf("addPolicyRule() error = %v, wantErr %v", err, tt.wantErr)
+            }
+        })
+    }
+
+    // The admin's new rule is stored and audited, and admins may now edit any entry
+    allowed, err := canEditTimeEntry(openTestEnforcer(t, dbPath), 1, TimeEntry{UserID: 2, ClientName: "ACME"}, time.Now())
+    if err != nil || !allowed {
+        t.Errorf("canEditTimeEntry() for the admin = %v, %v", allowed, err)
+    }
+    var audited int
+    db.QueryRow("SELECT COUNT(*) FROM audit_event WHERE event_type = ? AND actor_id = 1", auditPolicyAdd).Scan(&audited)
+    if audited != 2 {
+        t.Errorf("audit_event has %d rule adds, want 2", audited)
+    }
+    if err := addPolicyRule(enforcer, tests[0].rule); err == nil {
+        t.Error("addPolicyRule() added the same rule twice")
+    }
+
+    rules, err := listPolicyRules(db, 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var added PolicyRule
+    for _, rule := range rules {
+        if rule.Kind == policyKindUser && rule.SubjectName == "Tadej" {
+            added = rule
+        }
+    }
+    if added.Text() != "user Tadej: deny edit on time_entry when r.env.Client == 'Globex'" {
+        t.Errorf("listPolicyRules() has %q", added.Text())
+    }
+    if err := removePolicyRule(openTestOrgEnforcer(t, dbPath, 2), added); err == nil {
+        t.Errorf("removePolicyRule() of another organization's rule should fail")
+    }
+    if err := removePolicyRule(enforcer, added); err != nil {
+        t.Errorf("removePolicyRule() error = %v", err)
+    }
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+    "database/sql"
+    "log"
+    "strconv"
+    "sync"
+    "time"
+)
+
+
+// How often running apps look for policy changes made by others
+const policyPollInterval = 2 * time.Second
+
+// PolicyWatcher is a Casbin watcher polling the policy_version row, which triggers bump on every change to the policy tables
+type PolicyWatcher struct {
+    db          *sql.DB
+    mu          sync.Mutex
+    callback    func(string)
+    version     int64
+    stop        chan struct{}
+    done        chan struct{}
+}
+
+// NewPolicyWatcher opens its own connection to the DB and starts polling it every inInterval
+func NewPolicyWatcher(inDbPath string, inInterval time.Duration) (*PolicyWatcher, error) {
+    db, err := sql.Open("sqlite3", inDbPath)
+    if err != nil {
+        return nil, err
+    }
+
+    watcher := &PolicyWatcher{db: db, stop: make(chan struct{}), done: make(chan struct{})}
+    if watcher.version, err = watcher.readVersion(); err != nil {
+        db.Close()
+        return nil, err
+    }
+
+    go func() {
+        defer close(watcher.done)
+
+        ticker := time.NewTicker(inInterval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-watcher.stop:
+                return
+            case <-ticker.C:
+                if _, err := watcher.poll(); err != nil {
+                    log.Printf("Failed to check the policy version: %v", err)
+                }
+            }
+        }
+    }()
+
+    return watcher, nil
+}
+
+func (w *PolicyWatcher) readVersion() (int64, error) {
+    var version int64
+    err := w.db.QueryRow("SELECT version FROM policy_version WHERE policy_version_id = 1").Scan(&version)
+
+    return version, err
+}
+
+// poll calls the callback if the version moved since the last look and tells if it did
+func (w *PolicyWatcher) poll() (bool, error) {
+    version, err := w.readVersion()
+    if err != nil {
+        return false, err
+    }
+
+    w.mu.Lock()
+    changed  := version != w.version
+    w.version = version
+    callback := w.callback
+    w.mu.Unlock()
+
+    if changed && callback != nil {
+        callback(strconv.FormatInt(version, 10))
+    }
+
+    return changed, nil
+}
+
+// SetUpdateCallback sets what runs when the policy changed, Enforcer.SetWatcher sets it to LoadPolicy
+func (w *PolicyWatcher) SetUpdateCallback(inCallback func(string)) error {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    w.callback = inCallback
+
+    return nil
+}
+
+// Update bumps the version after this app changed the policy itself, so the other running apps reload
+func (w *PolicyWatcher) Update() error {
+    if _, err := w.db.Exec("UPDATE policy_version SET version = version + 1 WHERE policy_version_id = 1"); err != nil {
+        return err
+    }
+
+    // Our own enforcer already has the change
+    version, err := w.readVersion()
+    if err != nil {
+        return err
+    }
+    w.mu.Lock()
+    w.version = version
+    w.mu.Unlock()
+
+    return nil
+}
+
+// Close stops the polling, the callback is not called afterwards
+func (w *PolicyWatcher) Close() {
+    select {
+    case <-w.stop:
+        return
+    default:
+    }
+    close(w.stop)
+    <-w.done
+    w.db.Close()
+}
+
+
+// watchPolicy reloads the enforcer and drops the cached decisions whenever the policy in the DB changes,
+// then calls inOnReload - windows pass their Invalidate so the new decisions show up without waiting for input
+func watchPolicy(inPermissions *PermissionCache, inDbPath string, inOnReload func()) (*PolicyWatcher, error) {
+    watcher, err := NewPolicyWatcher(inDbPath, policyPollInterval)
+    if err != nil {
+        return nil, err
+    }
+    if err := inPermissions.enforcer.SetWatcher(watcher); err != nil {
+        watcher.Close()
+        return nil, err
+    }
+
+    // Replaces the LoadPolicy callback SetWatcher put in, so reload errors get logged
+    err = watcher.SetUpdateCallback(func(inVersion string) {
+        if err := inPermissions.Reload(); err != nil {
+            log.Printf("Failed to reload the policy at version %s: %v", inVersion, err)
+            return
+        }
+        if inOnReload != nil {
+            inOnReload()
+        }
+    })
+
+    return watcher, err
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+    "testing"
+)
+
+func Test_watchPolicy(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+
+    reloads := 0
+    watcher, err := watchPolicy(NewPermissionCache(enforcer), dbPath, func() { reloads++ })
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(watcher.Close)
+
+    // Each step changes the DB, then looks at the version the way the polling does
+    tests := []struct {
+        name        string
+        change      string
+        wantChanged bool
+        wantAllowed bool
+    }{
+        {"nothing changed",         "",                                                                                                         false, true},
+        {"unrelated table",         "UPDATE client_dim SET currency = 'USD' WHERE client_id = 1",                                               false, true},
+        {"admin text revoked",      "INSERT INTO auth_user_policy (user_policy_id, subject, object, action, effect) VALUES (3, 1, 'admin_text', 'read', 'deny')", true,  false},
+        {"revoke taken back",       "DELETE FROM auth_user_policy WHERE user_policy_id = 3",                                                    true,  true},
+        {"role dropped from user",  "DELETE FROM auth_user_role_map_policy WHERE subject = 1",                                                  true,  false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if tt.change != "" {
+                if _, err := db.Exec(tt.change); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            before       := reloads
+            changed, err := watcher.poll()
+            if err != nil || changed != tt.wantChanged || (reloads > before) != tt.wantChanged {
+                t.Errorf("poll() = %v, %v with %d reloads, want %v", changed, err, reloads-before, tt.wantChanged)
+            }
+            if got, _ := enforcer.Enforce("u1", "admin_text", "read"); got != tt.wantAllowed {
+                t.Errorf("Enforce() = %v, want %v", got, tt.wantAllowed)
+            }
+        })
+    }
+
+    // Our own changes are already in our enforcer, only the other apps reload
+    if err := watcher.Update(); err != nil {
+        t.Fatal(err)
+    }
+    if changed, err := watcher.poll(); err != nil || changed {
+        t.Errorf("poll() after Update() = %v, %v", changed, err)
+    }
+}
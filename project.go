@@ -0,0 +1,309 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+)
+
+
+// Project budget kinds - an hours budget is kept in minutes, a money budget in cents
+const (
+    budgetHours = "hours"
+    budgetMoney = "money"
+)
+
+var (
+    errProjectInactive = errors.New("project is not running on that date")
+    errProjectDenied   = errors.New("you shall not pass!.. the project")
+    errTaskNotFound    = errors.New("task not found")
+)
+
+// Project is a piece of work for a client, with an optional budget and a date range
+type Project struct {
+    ProjectID       int
+    ClientID        int
+    ClientName      string
+    ProjectName     string
+    GroupName       string
+    BudgetKind      string          // empty means no budget
+    BudgetAmount    int64
+    StartDate       time.Time
+    EndDate         time.Time       // zero means open-ended
+    Billable        bool
+    BlockOverBudget bool
+}
+
+// Task is a line of work inside a project, it is billable only if the project is too
+type Task struct {
+    TaskID          int
+    ProjectID       int
+    TaskName        string
+    Billable        bool
+}
+
+// projectObject is the Casbin object of a project, g2 rules put it into its project group
+func projectObject(inProjectID int) string {
+    return fmt.Sprintf("project_%d", inProjectID)
+}
+
+// ActiveOn tells if time may be logged on the project at the date
+func (p Project) ActiveOn(inDate time.Time) bool {
+    day := dateKey(inDate)
+    if day < dateKey(p.StartDate) {
+        return false
+    }
+
+    return p.EndDate.IsZero() || day <= dateKey(p.EndDate)
+}
+
+// BudgetText is the budget in the user's language, e.g. "100.00h" or "5,000.00 EUR"
+func (p Project) BudgetText(inCurrency string) string {
+    switch p.BudgetKind {
+    case budgetHours:
+        return tr().Duration(int(p.BudgetAmount))
+    case budgetMoney:
+        return tr().Money(p.BudgetAmount, inCurrency)
+    default:
+        return tr().Text("no budget")
+    }
+}
+
+
+// DB functions for projects and tasks
+func listClients(inDB dbRunner, inOrganizationID int) ([]Client, error) {
+    rows, err := inDB.Query("SELECT client_id, client_name, currency FROM client_dim WHERE organization_id = ? ORDER BY client_name", inOrganizationID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var clients []Client
+    for rows.Next() {
+        var client Client
+        if err := rows.Scan(&client.ClientID, &client.ClientName, &client.Currency); err != nil {
+            return nil, err
+        }
+        clients = append(clients, client)
+    }
+
+    return clients, rows.Err()
+}
+
+// listProjects returns the client's projects the user may read, a zero client ID returns the projects of all clients
+// in the enforcer's organization
+func listProjects(inDB dbRunner, inEnforcer *OrgEnforcer, inUserID int, inClientID int) ([]Project, error) {
+    projects, err := queryProjects(inDB, "cd.organization_id = ? AND (? = 0 OR pr.client_id = ?)", inEnforcer.OrganizationID, inClientID, inClientID)
+    if err != nil {
+        return nil, err
+    }
+
+    var visible []Project
+    for _, project := range projects {
+        canRead, err := inEnforcer.Enforce(fmt.Sprintf("u%d", inUserID), projectObject(project.ProjectID), "read")
+        if err != nil {
+            return nil, err
+        }
+        if canRead {
+            visible = append(visible, project)
+        }
+    }
+
+    return visible, nil
+}
+
+func getProject(inDB dbRunner, inProjectID int) (Project, error) {
+    projects, err := queryProjects(inDB, "pr.project_id = ?", inProjectID)
+    if err != nil {
+        return Project{}, err
+    }
+    if len(projects) == 0 {
+        return Project{}, newError("project %d not found", inProjectID)
+    }
+
+    return projects[0], nil
+}
+
+func queryProjects(inDB dbRunner, inWhere string, inArgs ...any) ([]Project, error) {
+    rows, err := inDB.Query(`
+SELECT
+      pr.project_id
+    , pr.client_id
+    , cd.client_name
+    , pr.project_name
+    , COALESCE(pg.group_name, '')
+    , COALESCE(pr.budget_kind, '')
+    , pr.budget_amount
+    , pr.start_date
+    , pr.end_date
+    , pr.billable
+    , pr.block_over_budget
+FROM
+    project                     AS pr
+    JOIN client_dim             AS cd
+        ON cd.client_id = pr.client_id
+    LEFT JOIN project_group     AS pg
+        ON pg.project_group_id = pr.project_group_id
+WHERE
+    `+inWhere+`
+ORDER BY
+    cd.client_name, pr.project_name
+    `, inArgs...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var projects []Project
+    for rows.Next() {
+        var project Project
+        var endDate sql.NullTime
+        if err := rows.Scan(&project.ProjectID, &project.ClientID, &project.ClientName, &project.ProjectName, &project.GroupName,
+            &project.BudgetKind, &project.BudgetAmount, &project.StartDate, &endDate, &project.Billable, &project.BlockOverBudget); err != nil {
+            return nil, err
+        }
+        project.EndDate = endDate.Time
+        projects        = append(projects, project)
+    }
+
+    return projects, rows.Err()
+}
+
+func listTasks(inDB dbRunner, inProjectID int) ([]Task, error) {
+    rows, err := inDB.Query("SELECT task_id, project_id, task_name, billable FROM task WHERE project_id = ? ORDER BY task_name", inProjectID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var tasks []Task
+    for rows.Next() {
+        var task Task
+        if err := rows.Scan(&task.TaskID, &task.ProjectID, &task.TaskName, &task.Billable); err != nil {
+            return nil, err
+        }
+        tasks = append(tasks, task)
+    }
+
+    return tasks, rows.Err()
+}
+
+func getTask(inDB dbRunner, inTaskID int) (Task, error) {
+    var task Task
+
+    err := inDB.QueryRow("SELECT task_id, project_id, task_name, billable FROM task WHERE task_id = ?", inTaskID).
+        Scan(&task.TaskID, &task.ProjectID, &task.TaskName, &task.Billable)
+    if errors.Is(err, sql.ErrNoRows) {
+        return task, errTaskNotFound
+    }
+
+    return task, err
+}
+
+// addProject registers a project under an existing client and returns its ID
+func addProject(inDB dbRunner, inProject Project) (int, error) {
+    var budgetKind any
+    var endDate    any
+
+    switch inProject.BudgetKind {
+    case "":
+    case budgetHours, budgetMoney:
+        budgetKind = inProject.BudgetKind
+    default:
+        return 0, newError("unknown budget kind %q, use %s or %s", inProject.BudgetKind, budgetHours, budgetMoney)
+    }
+    if !inProject.EndDate.IsZero() {
+        if inProject.EndDate.Before(inProject.StartDate) {
+            return 0, newError("the project ends before it starts")
+        }
+        endDate = dateKey(inProject.EndDate)
+    }
+    if strings.TrimSpace(inProject.ProjectName) == "" {
+        return 0, newError("the project needs a name")
+    }
+
+    var groupID any
+    if inProject.GroupName != "" {
+        var id int
+        if err := inDB.QueryRow("SELECT project_group_id FROM project_group WHERE group_name = ?", inProject.GroupName).Scan(&id); err != nil {
+            return 0, fmt.Errorf("%w: %w", newError("project group %q", inProject.GroupName), err)
+        }
+        groupID = id
+    }
+
+    result, err := inDB.Exec(`INSERT INTO project (client_id, project_name, project_group_id, budget_kind, budget_amount, start_date, end_date, billable, block_over_budget) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+        inProject.ClientID, strings.TrimSpace(inProject.ProjectName), groupID, budgetKind, inProject.BudgetAmount, dateKey(inProject.StartDate), endDate, inProject.Billable, inProject.BlockOverBudget)
+    if err != nil {
+        return 0, err
+    }
+    projectID, _ := result.LastInsertId()
+
+    return int(projectID), nil
+}
+
+func addTask(inDB dbRunner, inTask Task) (int, error) {
+    if strings.TrimSpace(inTask.TaskName) == "" {
+        return 0, newError("the task needs a name")
+    }
+
+    result, err := inDB.Exec("INSERT INTO task (project_id, task_name, billable) VALUES (?, ?, ?)", inTask.ProjectID, strings.TrimSpace(inTask.TaskName), inTask.Billable)
+    if err != nil {
+        return 0, err
+    }
+    taskID, _ := result.LastInsertId()
+
+    return int(taskID), nil
+}
+
+// checkProjectTimeEntry runs the checks of every entry on a project - the project has to be running on the day, the
+// user needs "write" on it and a blocking budget has to have room for the minutes
+func checkProjectTimeEntry(inDB dbRunner, inEnforcer *OrgEnforcer, inUserID int, inProject Project, inDate time.Time, inMinutes int) error {
+    if !inProject.ActiveOn(inDate) {
+        return fmt.Errorf("%w: %w", errProjectInactive, newError("%s, %s", inProject.ProjectName, inDate))
+    }
+
+    canWrite, err := inEnforcer.Enforce(fmt.Sprintf("u%d", inUserID), projectObject(inProject.ProjectID), "write")
+    if err != nil {
+        return err
+    }
+    if !canWrite {
+        logAuditEvent(inDB, AuditEvent{ActorID: inUserID, OrganizationID: inEnforcer.OrganizationID, EventType: auditDenied,
+            Object: projectObject(inProject.ProjectID), After: "write"})
+        return errProjectDenied
+    }
+
+    return checkBudget(inDB, inEnforcer, inUserID, inProject, inDate, inMinutes)
+}
+
+// addTaskTimeEntry logs time on a task. The project has to be running on the day and the user needs "write" on it.
+// Entries that cross a budget threshold raise a budget alert.
+func addTaskTimeEntry(inDB dbRunner, inEnforcer *OrgEnforcer, inUserID int, inTaskID int, inDate time.Time, inMinutes int) (TimeEntry, error) {
+    task, err := getTask(inDB, inTaskID)
+    if err != nil {
+        return TimeEntry{}, err
+    }
+    project, err := getProject(inDB, task.ProjectID)
+    if err != nil {
+        return TimeEntry{}, err
+    }
+    if err := checkProjectTimeEntry(inDB, inEnforcer, inUserID, project, inDate, inMinutes); err != nil {
+        return TimeEntry{}, err
+    }
+
+    entry, err := insertTimeEntry(inDB, inEnforcer.OrganizationID, TimeEntry{
+        UserID:     inUserID,
+        ClientName: project.ClientName,
+        ProjectID:  project.ProjectID,
+        TaskID:     task.TaskID,
+        EntryDate:  inDate,
+        Minutes:    inMinutes,
+    })
+    if err != nil {
+        return TimeEntry{}, err
+    }
+    updateBudgetAlerts(inDB, project)
+
+    return entry, nil
+}
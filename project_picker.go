@@ -0,0 +1,135 @@
+package main
+
+import (
+    "database/sql"
+    "gioui.org/layout"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "showcase_desktop/widgets"
+    "strconv"
+    "strings"
+)
+
+
+// projectPicker is the cascading client -> project -> task selector of the main window.
+// Picking a client loads the projects the user may read, picking a project loads its tasks.
+type projectPicker struct {
+    clients     []Client
+    projects    []Project
+    tasks       []Task
+    clientEnum  widget.Enum
+    projectEnum widget.Enum
+    taskEnum    widget.Enum
+}
+
+func newProjectPicker(inDB *sql.DB, inOrganizationID int) *projectPicker {
+    picker := &projectPicker{}
+
+    clients, err := listClients(inDB, inOrganizationID)
+    if err != nil {
+        log.Print(err)
+    }
+    picker.clients = clients
+
+    return picker
+}
+
+// update handles the clicks of the frame and returns true if another client got picked
+func (p *projectPicker) update(inGTX layout.Context, inDB *sql.DB, inEnforcer *OrgEnforcer, inUserID int) bool {
+    clientChanged := p.clientEnum.Update(inGTX)
+    if clientChanged {
+        clientID, _ := strconv.Atoi(p.clientEnum.Value)
+        projects, err := listProjects(inDB, inEnforcer, inUserID, clientID)
+        if err != nil {
+            log.Print(err)
+        }
+        p.projects, p.tasks = projects, nil
+        p.projectEnum.Value = ""
+        p.taskEnum.Value    = ""
+    }
+
+    if p.projectEnum.Update(inGTX) {
+        projectID, _ := strconv.Atoi(p.projectEnum.Value)
+        tasks, err   := listTasks(inDB, projectID)
+        if err != nil {
+            log.Print(err)
+        }
+        p.tasks          = tasks
+        p.taskEnum.Value = ""
+    }
+    p.taskEnum.Update(inGTX)
+
+    return clientChanged
+}
+
+// ClientName is the picked client, or empty
+func (p *projectPicker) ClientName() string {
+    for _, client := range p.clients {
+        if strconv.Itoa(client.ClientID) == p.clientEnum.Value {
+            return client.ClientName
+        }
+    }
+
+    return ""
+}
+
+// TaskID is the picked task, zero when no task is picked or the client was typed in by hand
+func (p *projectPicker) TaskID(inClientText string) int {
+    if !strings.EqualFold(strings.TrimSpace(inClientText), p.ClientName()) {
+        return 0
+    }
+    taskID, _ := strconv.Atoi(p.taskEnum.Value)
+
+    return taskID
+}
+
+func (p *projectPicker) layout(inGTX layout.Context, inTheme *widgets.Theme) layout.Dimensions {
+    var clientKeys, projectKeys, taskKeys     []string
+    var clientNames, projectNames, taskNames  []string
+
+    for _, client := range p.clients {
+        clientKeys  = append(clientKeys, strconv.Itoa(client.ClientID))
+        clientNames = append(clientNames, client.ClientName)
+    }
+    for _, project := range p.projects {
+        projectKeys  = append(projectKeys, strconv.Itoa(project.ProjectID))
+        projectNames = append(projectNames, project.ProjectName)
+    }
+    for _, task := range p.tasks {
+        taskKeys  = append(taskKeys, strconv.Itoa(task.TaskID))
+        taskNames = append(taskNames, task.TaskName)
+    }
+
+    return layout.Flex{Axis: layout.Vertical}.Layout(inGTX,
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return radioRowElement(gtx, inTheme, tr().Text("Client"), &p.clientEnum, clientKeys, clientNames)
+        }),
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return radioRowElement(gtx, inTheme, tr().Text("Project"), &p.projectEnum, projectKeys, projectNames)
+        }),
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return radioRowElement(gtx, inTheme, tr().Text("Task"), &p.taskEnum, taskKeys, taskNames)
+        }),
+    )
+}
+
+// radioRowElement puts a label and one radio button per option in a row, nothing is shown without options
+func radioRowElement(inGTX layout.Context, inTheme *widgets.Theme, inLabel string, inEnum *widget.Enum, inKeys []string, inNames []string) layout.Dimensions {
+    if len(inKeys) == 0 {
+        return layout.Dimensions{}
+    }
+
+    children := []layout.FlexChild{
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            gtx.Constraints.Min.X = gtx.Dp(unit.Dp(70))
+            return material.Body1(inTheme.Theme, inLabel).Layout(gtx)
+        }),
+    }
+    for i := range inKeys {
+        children = append(children, layout.Rigid(material.RadioButton(inTheme.Theme, inEnum, inKeys[i], inNames[i]).Layout))
+    }
+
+    return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(inGTX, children...)
+}
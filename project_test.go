@@ -0,0 +1,135 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+    "time"
+)
+
+func Test_projectPermissions(t *testing.T) {
+    _, dbPath := openTestDb(t)
+    enforcer  := openTestEnforcer(t, dbPath)
+
+    tests := []struct {
+        name      string
+        userID    int
+        projectID int
+        action    string
+        want      bool
+    }{
+        {"minion writes on client work",      2, 1, "write", true},
+        {"minion writes on internal work",    2, 3, "write", true},
+        {"admin reads client work",           1, 2, "read",  true},
+        {"admin does not log time",           1, 2, "write", false},
+        {"per project deny beats the group",  3, 2, "write", false},
+        {"the group still allows the rest",   3, 1, "write", true},
+        {"unknown project is in no group",    2, 99, "read", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := enforcer.Enforce(fmt.Sprintf("u%d", tt.userID), projectObject(tt.projectID), tt.action)
+            if err != nil || got != tt.want {
+                t.Errorf("Enforce() = %v, %v, want %v", got, err, tt.want)
+            }
+        })
+    }
+}
+
+func Test_addTaskTimeEntry(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    day        := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    projects, err := listProjects(db, enforcer, 2, 1)
+    if err != nil || len(projects) != 3 || projects[0].ProjectName != "Pre-sales" || projects[2].BudgetText("EUR") != "100.00h" {
+        t.Fatalf("listProjects() = %+v, %v", projects, err)
+    }
+
+    tests := []struct {
+        name    string
+        userID  int
+        taskID  int
+        day     time.Time
+        wantErr error
+    }{
+        {"logs on a task",              2, 2,  day,                                     nil},
+        {"project has ended",           2, 5,  time.Date(2027, 1, 4, 0, 0, 0, 0, time.UTC), errProjectInactive},
+        {"project has not started",     2, 1,  time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), errProjectInactive},
+        {"no write on the project",     3, 3,  day,                                     errProjectDenied},
+        {"unknown task",                2, 99, day,                                     errTaskNotFound},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            entry, err := addTaskTimeEntry(db, enforcer, tt.userID, tt.taskID, tt.day, 60)
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("addTaskTimeEntry() error = %v, want %v", err, tt.wantErr)
+            }
+            if err == nil && (entry.ClientName != "ACME" || entry.ProjectID != 1 || entry.TimeEntryID == 0) {
+                t.Errorf("addTaskTimeEntry() = %+v", entry)
+            }
+        })
+    }
+
+    denials, err := listAuditEvents(db, AuditFilter{OrganizationID: 1, EventType: auditDenied})
+    if err != nil || len(denials) != 1 || denials[0].Object != "project_2" || denials[0].ActorName != "Petar" {
+        t.Errorf("listAuditEvents() of the denials = %+v, %v, want Petar's on the support project", denials, err)
+    }
+
+    // Task and project billable flags decide, plain client entries still go by the rate cards
+    if _, err := addTaskTimeEntry(db, enforcer, 2, 4, day, 30); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := addTimeEntry(db, 1, 2, "ACME", day, 15); err != nil {
+        t.Fatal(err)
+    }
+    rows, err := queryExportRows(db, enforcer, 2, ExportFilter{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    wantBillable := map[string]bool{"Development": true, "Warranty fixes": false, "": true}
+    for _, row := range rows {
+        got, err := isBillable(db, 1, row)
+        if err != nil || got != wantBillable[row.TaskName] {
+            t.Errorf("isBillable(%q) = %v, %v, want %v", row.TaskName, got, err, wantBillable[row.TaskName])
+        }
+    }
+    if len(rows) != 3 || rows[0].ProjectName != "Website" {
+        t.Errorf("queryExportRows() = %+v", rows)
+    }
+}
+
+func Test_addProject(t *testing.T) {
+    db, _ := openTestDb(t)
+    day   := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    tests := []struct {
+        name    string
+        project Project
+        wantErr bool
+    }{
+        {"with a budget",       Project{ClientID: 1, ProjectName: "Mobile app", GroupName: "client_work", BudgetKind: budgetHours, BudgetAmount: 600, StartDate: day, Billable: true}, false},
+        {"same name again",     Project{ClientID: 1, ProjectName: "mobile APP", StartDate: day}, true},
+        {"unknown budget kind", Project{ClientID: 1, ProjectName: "Audit", BudgetKind: "beans", StartDate: day}, true},
+        {"ends before start",   Project{ClientID: 1, ProjectName: "Audit", StartDate: day, EndDate: day.AddDate(0, 0, -1)}, true},
+        {"unknown group",       Project{ClientID: 1, ProjectName: "Audit", GroupName: "secret", StartDate: day}, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            projectID, err := addProject(db, tt.project)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("addProject() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if err != nil {
+                return
+            }
+            project, err := getProject(db, projectID)
+            if err != nil || project.GroupName != "client_work" || !project.EndDate.IsZero() || !project.ActiveOn(day) || project.ActiveOn(day.AddDate(0, 0, -1)) {
+                t.Errorf("getProject() = %+v, %v", project, err)
+            }
+            if _, err := addTask(db, Task{ProjectID: projectID, TaskName: "Build", Billable: true}); err != nil {
+                t.Errorf("addTask() error = %v", err)
+            }
+        })
+    }
+}
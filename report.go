@@ -0,0 +1,161 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+)
+
+
+// Casbin objects for the reports, each one is shown only with "read" on it
+const (
+    reportHoursByClient = "report_hours_by_client"
+    reportBillable      = "report_billable"
+)
+
+// Report is the time entries behind the dashboard, with the totals its charts need
+type Report struct {
+    Rows                []ExportRow
+    // Weeks and Clients are sorted, Minutes is indexed [week][client]
+    Weeks               []time.Time
+    Clients             []string
+    Minutes             [][]int
+    BillableMinutes     int
+    NonBillableMinutes  int
+    billable            map[int]bool
+}
+
+// MaxWeekMinutes is the tallest bar of the weekly chart
+func (r Report) MaxWeekMinutes() int {
+    maxMinutes := 0
+    for _, week := range r.Minutes {
+        total := 0
+        for _, minutes := range week {
+            total += minutes
+        }
+        maxMinutes = max(maxMinutes, total)
+    }
+
+    return maxMinutes
+}
+
+// EntriesFor is the drill-down of one bar segment
+func (r Report) EntriesFor(inWeek time.Time, inClient string) []ExportRow {
+    var rows []ExportRow
+    for _, row := range r.Rows {
+        if row.WeekStart.Equal(inWeek) && strings.EqualFold(row.ClientName, inClient) {
+            rows = append(rows, row)
+        }
+    }
+
+    return rows
+}
+
+// EntriesByBillable is the drill-down of one pie slice
+func (r Report) EntriesByBillable(inBillable bool) []ExportRow {
+    var rows []ExportRow
+    for _, row := range r.Rows {
+        if r.billable[row.TimeEntryID] == inBillable {
+            rows = append(rows, row)
+        }
+    }
+
+    return rows
+}
+
+
+// visibleReports returns which of the reports the user may read
+func visibleReports(inEnforcer *OrgEnforcer, inUserID int) (bool, bool, error) {
+    subject := fmt.Sprintf("u%d", inUserID)
+
+    canReadHours, err := inEnforcer.Enforce(subject, reportHoursByClient, "read")
+    if err != nil {
+        return false, false, err
+    }
+    canReadBillable, err := inEnforcer.Enforce(subject, reportBillable, "read")
+
+    return canReadHours, canReadBillable, err
+}
+
+// buildReport loads the entries the user may read, the same way as the export does, and sums them up for the charts
+func buildReport(inDB *sql.DB, inEnforcer *OrgEnforcer, inUserID int, inFilter ExportFilter) (Report, error) {
+    rows, err := queryExportRows(inDB, inEnforcer, inUserID, inFilter)
+    if err != nil {
+        return Report{}, err
+    }
+
+    report := Report{Rows: rows, billable: map[int]bool{}}
+
+    // Collect the weeks and clients first, the chart shows them sorted
+    weekIndex   := map[time.Time]int{}
+    clientIndex := map[string]int{}
+    for _, row := range rows {
+        if _, ok := weekIndex[row.WeekStart]; !ok {
+            weekIndex[row.WeekStart] = len(report.Weeks)
+            report.Weeks             = append(report.Weeks, row.WeekStart)
+        }
+        if _, ok := clientIndex[strings.ToLower(row.ClientName)]; !ok {
+            clientIndex[strings.ToLower(row.ClientName)] = len(report.Clients)
+            report.Clients                               = append(report.Clients, row.ClientName)
+        }
+    }
+    sort.Slice(report.Weeks, func(i, j int) bool { return report.Weeks[i].Before(report.Weeks[j]) })
+    sort.Slice(report.Clients, func(i, j int) bool { return strings.ToLower(report.Clients[i]) < strings.ToLower(report.Clients[j]) })
+    for i, week := range report.Weeks {
+        weekIndex[week] = i
+    }
+    for i, client := range report.Clients {
+        clientIndex[strings.ToLower(client)] = i
+    }
+
+    report.Minutes = make([][]int, len(report.Weeks))
+    for i := range report.Minutes {
+        report.Minutes[i] = make([]int, len(report.Clients))
+    }
+
+    for _, row := range rows {
+        report.Minutes[weekIndex[row.WeekStart]][clientIndex[strings.ToLower(row.ClientName)]] += row.Minutes
+
+        billable, err := isBillable(inDB, inEnforcer.OrganizationID, row)
+        if err != nil {
+            return Report{}, err
+        }
+        report.billable[row.TimeEntryID] = billable
+        if billable {
+            report.BillableMinutes += row.Minutes
+        } else {
+            report.NonBillableMinutes += row.Minutes
+        }
+    }
+
+    return report, nil
+}
+
+// isBillable tells if an entry would end up on an invoice. Entries on a task follow the billable flags of the task
+// and its project, other entries are billable when their client is registered in the organization and a rate card applies.
+func isBillable(inDB dbRunner, inOrganizationID int, inRow ExportRow) (bool, error) {
+    if inRow.TaskID != 0 {
+        var billable bool
+        err := inDB.QueryRow("SELECT pr.billable AND tk.billable FROM task AS tk JOIN project AS pr ON pr.project_id = tk.project_id WHERE tk.task_id = ?", inRow.TaskID).Scan(&billable)
+        return billable, err
+    }
+
+    var clientID int
+    err := inDB.QueryRow("SELECT client_id FROM client_dim WHERE organization_id = ? AND client_name = ?", inOrganizationID, inRow.ClientName).Scan(&clientID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    _, _, err = resolveRate(inDB, clientID, inRow.UserID, inRow.EntryDate)
+    if errors.Is(err, errNoRateCard) {
+        return false, nil
+    }
+
+    return err == nil, err
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+func Test_visibleReports(t *testing.T) {
+    _, dbPath := openTestDb(t)
+    enforcer  := openTestEnforcer(t, dbPath)
+
+    tests := []struct {
+        name         string
+        userID       int
+        wantHours    bool
+        wantBillable bool
+    }{
+        {"admin reads both",          1, true, true},
+        {"minion only reads hours",   2, true, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            gotHours, gotBillable, err := visibleReports(enforcer, tt.userID)
+            if err != nil || gotHours != tt.wantHours || gotBillable != tt.wantBillable {
+                t.Errorf("visibleReports() = %v, %v, %v, want %v, %v", gotHours, gotBillable, err, tt.wantHours, tt.wantBillable)
+            }
+        })
+    }
+}
+
+func Test_buildReport(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    monday     := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+
+    for _, entry := range []struct {
+        userID  int
+        client  string
+        day     time.Time
+        minutes int
+    }{
+        {2, "ACME",     monday,                   60},
+        {2, "Internal", monday.AddDate(0, 0, 1),  30},
+        {2, "acme",     monday.AddDate(0, 0, 7),  90},
+        {3, "ACME",     monday.AddDate(0, 0, 8),  120},
+    } {
+        if _, err := addTimeEntry(db, 1, entry.userID, entry.client, entry.day, entry.minutes); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    // Ray sees the whole team, Tadej only their own entries
+    report, err := buildReport(db, enforcer, 1, ExportFilter{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(report.Weeks) != 2 || len(report.Clients) != 2 || report.Clients[0] != "ACME" {
+        t.Fatalf("buildReport() weeks %v, clients %v", report.Weeks, report.Clients)
+    }
+    if report.Minutes[0][0] != 60 || report.Minutes[0][1] != 30 || report.Minutes[1][0] != 210 || report.MaxWeekMinutes() != 210 {
+        t.Errorf("buildReport() minutes = %v", report.Minutes)
+    }
+    if report.BillableMinutes != 270 || report.NonBillableMinutes != 30 {
+        t.Errorf("buildReport() billable = %v, non-billable = %v", report.BillableMinutes, report.NonBillableMinutes)
+    }
+    if rows := report.EntriesFor(monday.AddDate(0, 0, 7), "ACME"); len(rows) != 2 {
+        t.Errorf("EntriesFor() = %+v, want 2 rows", rows)
+    }
+    if rows := report.EntriesByBillable(false); len(rows) != 1 || rows[0].ClientName != "Internal" {
+        t.Errorf("EntriesByBillable(false) = %+v", rows)
+    }
+
+    own, err := buildReport(db, enforcer, 2, ExportFilter{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(own.Rows) != 3 || own.BillableMinutes+own.NonBillableMinutes != 180 {
+        t.Errorf("buildReport() for Tadej = %+v", own)
+    }
+}
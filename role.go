@@ -0,0 +1,315 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+)
+
+
+// Casbin object of the admin's role hierarchy editor
+const roleObject = "role"
+
+var (
+    errRoleCycle  = errors.New("a role can not inherit from itself, directly or through other roles")
+    errRoleDenied = errors.New("you shall not pass!.. the roles")
+)
+
+// RoleInheritance is one edge of an organization's role hierarchy, the role gets every rule of the parent
+type RoleInheritance struct {
+    RoleInheritanceID   int
+    RoleID              int
+    RoleName            string
+    ParentRoleID        int
+    ParentRoleName      string
+}
+
+func (r RoleInheritance) Text() string {
+    return fmt.Sprintf("%s inherits from %s", r.RoleName, r.ParentRoleName)
+}
+
+
+// roleIDByName looks up a role by its name
+func roleIDByName(inDB dbRunner, inRoleName string) (int, error) {
+    var roleID int
+
+    err := inDB.QueryRow("SELECT role_dim_id FROM auth_role_dim WHERE role_name = ?", strings.TrimSpace(inRoleName)).Scan(&roleID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return 0, newError("role %q does not exist", strings.TrimSpace(inRoleName))
+    }
+
+    return roleID, err
+}
+
+// listRoleInheritance returns the organization's role hierarchy, ordered by role and parent name
+func listRoleInheritance(inDB dbRunner, inOrganizationID int) ([]RoleInheritance, error) {
+    rows, err := inDB.Query(`
+SELECT
+      ari.role_inheritance_id
+    , ari.role_id
+    , role.role_name
+    , ari.parent_role_id
+    , parent.role_name
+FROM
+    auth_role_inheritance       AS ari
+    JOIN auth_role_dim          AS role
+        ON role.role_dim_id = ari.role_id
+    JOIN auth_role_dim          AS parent
+        ON parent.role_dim_id = ari.parent_role_id
+WHERE
+    ari.organization_id = ?
+ORDER BY
+      role.role_name
+    , parent.role_name
+    `, inOrganizationID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var edges []RoleInheritance
+    for rows.Next() {
+        var edge RoleInheritance
+        if err := rows.Scan(&edge.RoleInheritanceID, &edge.RoleID, &edge.RoleName, &edge.ParentRoleID, &edge.ParentRoleName); err != nil {
+            return nil, err
+        }
+        edges = append(edges, edge)
+    }
+
+    return edges, rows.Err()
+}
+
+// roleInheritanceCycle tells whether letting inRoleID inherit from inParentRoleID would close a loop,
+// i.e. whether the role is already among the parent's ancestors
+func roleInheritanceCycle(inEdges []RoleInheritance, inRoleID int, inParentRoleID int) bool {
+    parents := map[int][]int{}
+    for _, edge := range inEdges {
+        parents[edge.RoleID] = append(parents[edge.RoleID], edge.ParentRoleID)
+    }
+
+    visited := map[int]bool{}
+    queue   := []int{inParentRoleID}
+    for len(queue) > 0 {
+        roleID := queue[0]
+        queue   = queue[1:]
+        if roleID == inRoleID {
+            return true
+        }
+        if visited[roleID] {
+            continue
+        }
+        visited[roleID] = true
+        queue           = append(queue, parents[roleID]...)
+    }
+
+    return false
+}
+
+// findRoleInheritance returns the edge from the role to the parent, if the hierarchy has one
+func findRoleInheritance(inEdges []RoleInheritance, inRoleID int, inParentRoleID int) (RoleInheritance, bool) {
+    for _, edge := range inEdges {
+        if edge.RoleID == inRoleID && edge.ParentRoleID == inParentRoleID {
+            return edge, true
+        }
+    }
+
+    return RoleInheritance{}, false
+}
+
+// addRoleInheritance lets the role inherit from the parent within the enforcer's organization, refusing edges that
+// close a cycle. The change and its audit event are written in one transaction.
+func addRoleInheritance(inDB *sql.DB, inEnforcer *OrgEnforcer, inRoleID int, inParentRoleID int) error {
+    tx, err := inDB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    edges, err := listRoleInheritance(tx, inEnforcer.OrganizationID)
+    if err != nil {
+        return err
+    }
+    if edge, found := findRoleInheritance(edges, inRoleID, inParentRoleID); found {
+        return newError("%s already inherits from %s", edge.RoleName, edge.ParentRoleName)
+    }
+    if roleInheritanceCycle(edges, inRoleID, inParentRoleID) {
+        return errRoleCycle
+    }
+
+    _, err = tx.Exec("INSERT INTO auth_role_inheritance (role_id, parent_role_id, organization_id) VALUES (?, ?, ?)", inRoleID, inParentRoleID, inEnforcer.OrganizationID)
+    if err != nil {
+        return err
+    }
+
+    // Read back for the names
+    edges, err = listRoleInheritance(tx, inEnforcer.OrganizationID)
+    if err != nil {
+        return err
+    }
+    edge, _ := findRoleInheritance(edges, inRoleID, inParentRoleID)
+    if err := recordAuditEvent(tx, inEnforcer.auditEvent(auditPolicyAdd, roleObject, "", edge.Text())); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// removeRoleInheritance drops one edge of the enforcer's organization's hierarchy, audited in the same transaction
+func removeRoleInheritance(inDB *sql.DB, inEnforcer *OrgEnforcer, inRoleID int, inParentRoleID int) error {
+    tx, err := inDB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    edges, err := listRoleInheritance(tx, inEnforcer.OrganizationID)
+    if err != nil {
+        return err
+    }
+    edge, found := findRoleInheritance(edges, inRoleID, inParentRoleID)
+    if !found {
+        return newError("the role does not inherit from that parent")
+    }
+
+    _, err = tx.Exec("DELETE FROM auth_role_inheritance WHERE role_inheritance_id = ?", edge.RoleInheritanceID)
+    if err != nil {
+        return err
+    }
+    if err := recordAuditEvent(tx, inEnforcer.auditEvent(auditPolicyRemove, roleObject, edge.Text(), "")); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+
+// factorBaseRole moves the rules every role of the enforcer's organization has in common into a base role and lets the roles
+// inherit from it, so each rule is kept once. What the roles may do stays the same. Returns the number of rules moved.
+func factorBaseRole(inDB *sql.DB, inEnforcer *OrgEnforcer, inBaseRoleName string) (int, error) {
+    inBaseRoleName = strings.TrimSpace(inBaseRoleName)
+    if inBaseRoleName == "" {
+        return 0, newError("please name the base role")
+    }
+
+    tx, err := inDB.Begin()
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    var baseRoleID int
+    err = tx.QueryRow("SELECT role_dim_id FROM auth_role_dim WHERE role_name = ?", inBaseRoleName).Scan(&baseRoleID)
+    if errors.Is(err, sql.ErrNoRows) {
+        result, insertErr := tx.Exec("INSERT INTO auth_role_dim (role_name) VALUES (?)", inBaseRoleName)
+        if insertErr != nil {
+            return 0, insertErr
+        }
+        newID, _  := result.LastInsertId()
+        baseRoleID = int(newID)
+    } else if err != nil {
+        return 0, err
+    }
+
+    // The roles with rules of their own in the organization, apart from the base
+    var roleIDs []int
+    rows, err := tx.Query("SELECT DISTINCT subject FROM auth_role_policy WHERE organization_id = ? AND subject <> ? ORDER BY subject", inEnforcer.OrganizationID, baseRoleID)
+    if err != nil {
+        return 0, err
+    }
+    for rows.Next() {
+        var roleID int
+        if err := rows.Scan(&roleID); err != nil {
+            rows.Close()
+            return 0, err
+        }
+        roleIDs = append(roleIDs, roleID)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return 0, err
+    }
+    if len(roleIDs) < 2 {
+        return 0, newError("there is nothing to share, it takes at least two roles with rules")
+    }
+
+    edges, err := listRoleInheritance(tx, inEnforcer.OrganizationID)
+    if err != nil {
+        return 0, err
+    }
+    for _, roleID := range roleIDs {
+        if roleInheritanceCycle(edges, roleID, baseRoleID) {
+            return 0, errRoleCycle
+        }
+    }
+
+    // A rule is common when every role has it with the same effect and condition, a condition only ever moves with its rule
+    var common [][4]string
+    rows, err = tx.Query(`
+SELECT
+      arp.object
+    , arp.action
+    , arp.effect
+    , arp.condition
+FROM
+    auth_role_policy    AS arp
+WHERE
+    arp.organization_id = ?
+    AND arp.subject <> ?
+GROUP BY
+      arp.object
+    , arp.action
+    , arp.effect
+    , arp.condition
+HAVING
+    COUNT(DISTINCT arp.subject) = ?
+ORDER BY
+      arp.object
+    , arp.action
+    `, inEnforcer.OrganizationID, baseRoleID, len(roleIDs))
+    if err != nil {
+        return 0, err
+    }
+    for rows.Next() {
+        var rule [4]string
+        if err := rows.Scan(&rule[0], &rule[1], &rule[2], &rule[3]); err != nil {
+            rows.Close()
+            return 0, err
+        }
+        common = append(common, rule)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return 0, err
+    }
+
+    for _, rule := range common {
+        _, err := tx.Exec(`
+INSERT INTO auth_role_policy (subject, object, action, effect, condition, organization_id)
+SELECT ?, ?, ?, ?, ?, ?
+WHERE NOT EXISTS (SELECT 1 FROM auth_role_policy WHERE subject = ? AND object = ? AND action = ? AND effect = ? AND condition = ? AND organization_id = ?)
+        `, baseRoleID, rule[0], rule[1], rule[2], rule[3], inEnforcer.OrganizationID, baseRoleID, rule[0], rule[1], rule[2], rule[3], inEnforcer.OrganizationID)
+        if err != nil {
+            return 0, err
+        }
+        _, err = tx.Exec("DELETE FROM auth_role_policy WHERE subject <> ? AND object = ? AND action = ? AND effect = ? AND condition = ? AND organization_id = ?",
+            baseRoleID, rule[0], rule[1], rule[2], rule[3], inEnforcer.OrganizationID)
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    for _, roleID := range roleIDs {
+        _, err := tx.Exec("INSERT OR IGNORE INTO auth_role_inheritance (role_id, parent_role_id, organization_id) VALUES (?, ?, ?)", roleID, baseRoleID, inEnforcer.OrganizationID)
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    event := inEnforcer.auditEvent(auditPolicySave, roleObject, "", fmt.Sprintf("moved %d shared rules into %s", len(common), inBaseRoleName))
+    if err := recordAuditEvent(tx, event); err != nil {
+        return 0, err
+    }
+
+    return len(common), tx.Commit()
+}
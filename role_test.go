@@ -0,0 +1,196 @@
+package main
+
+import (
+    "errors"
+    "testing"
+)
+
+func Test_roleInheritanceCycle(t *testing.T) {
+    // 1 and 2 inherit from 3, 3 from 4
+    edges := []RoleInheritance{
+        {RoleID: 1, ParentRoleID: 3},
+        {RoleID: 2, ParentRoleID: 3},
+        {RoleID: 3, ParentRoleID: 4},
+    }
+
+    tests := []struct {
+        name     string
+        roleID   int
+        parentID int
+        want     bool
+    }{
+        {"itself",                  1, 1, true},
+        {"direct loop",             3, 1, true},
+        {"loop through a parent",   4, 2, true},
+        {"sibling is fine",         1, 2, false},
+        {"new parent on top",       4, 5, false},
+        {"shortcut to grandparent", 1, 4, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := roleInheritanceCycle(edges, tt.roleID, tt.parentID); got != tt.want {
+                t.Errorf("roleInheritanceCycle() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func Test_addRoleInheritance(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    steaby     := openTestEnforcer(t, dbPath)
+    labs       := openTestOrgEnforcer(t, dbPath, 2)
+    steaby.SetActor(1)
+    labs.SetActor(2)
+
+    // B_admin and B_minion already inherit from B_base
+    if err := addRoleInheritance(db, steaby, 3, 1); !errors.Is(err, errRoleCycle) {
+        t.Errorf("addRoleInheritance() B_base from B_admin error = %v, want %v", err, errRoleCycle)
+    }
+    if err := addRoleInheritance(db, steaby, 1, 3); err == nil {
+        t.Errorf("addRoleInheritance() twice should fail")
+    }
+    if err := addRoleInheritance(db, steaby, 1, 2); err != nil {
+        t.Fatalf("addRoleInheritance() B_admin from B_minion error = %v", err)
+    }
+    // Now B_minion from B_admin closes the loop
+    if err := addRoleInheritance(db, steaby, 2, 1); !errors.Is(err, errRoleCycle) {
+        t.Errorf("addRoleInheritance() B_minion from B_admin error = %v, want %v", err, errRoleCycle)
+    }
+    // The other organization has its own hierarchy
+    if err := addRoleInheritance(db, labs, 2, 1); err != nil {
+        t.Errorf("addRoleInheritance() in the labs error = %v", err)
+    }
+
+    if err := removeRoleInheritance(db, steaby, 1, 2); err != nil {
+        t.Errorf("removeRoleInheritance() error = %v", err)
+    }
+    if err := removeRoleInheritance(db, steaby, 1, 2); err == nil {
+        t.Errorf("removeRoleInheritance() twice should fail")
+    }
+
+    edges, err := listRoleInheritance(db, 1)
+    if err != nil || len(edges) != 2 || edges[0].Text() != "B_admin inherits from B_base" {
+        t.Errorf("listRoleInheritance() = %+v, %v", edges, err)
+    }
+
+    // Each change that went through is audited, named
+    added, _   := listAuditEvents(db, AuditFilter{OrganizationID: 1, EventType: auditPolicyAdd})
+    removed, _ := listAuditEvents(db, AuditFilter{OrganizationID: 1, EventType: auditPolicyRemove})
+    if len(added) != 1 || added[0].After != "B_admin inherits from B_minion" || len(removed) != 1 || removed[0].Before != "B_admin inherits from B_minion" {
+        t.Errorf("listAuditEvents() of the roles = %+v and %+v", added, removed)
+    }
+    // Tadej is only a minion at Steaby
+    steaby.SetActor(2)
+    if err := steaby.checkActorWrite(db, roleObject, errRoleDenied); !errors.Is(err, errRoleDenied) {
+        t.Errorf("checkActorWrite() of Tadej error = %v, want %v", err, errRoleDenied)
+    }
+    if events, _ := listAuditEvents(db, AuditFilter{OrganizationID: 1, EventType: auditDenied}); len(events) != 1 || events[0].Object != roleObject {
+        t.Errorf("listAuditEvents() of the denials = %+v, want the roles", events)
+    }
+}
+
+func Test_roleInheritanceEnforce(t *testing.T) {
+    db, dbPath := openTestDb(t)
+
+    checks := []struct {
+        subject  string
+        object   string
+        action   string
+        want     bool
+    }{
+        {"u1", "report_text",   "read",  true},     // from B_base
+        {"u2", "time_entry",    "read",  true},     // from B_base
+        {"u2", "time_entry",    "write", true},     // B_minion's own
+        {"u1", "time_entry",    "write", false},    // B_admin's own deny
+        {"u3", "report_text",   "read",  false},    // Petar's deny beats the inherited allow
+        {"u2", "invoice",       "read",  false},
+        {"u1", "invoice",       "read",  false},
+    }
+    enforceAll := func(inName string) {
+        enforcer := openTestEnforcer(t, dbPath)
+        for _, check := range checks {
+            got, err := enforcer.Enforce(check.subject, check.object, check.action)
+            if err != nil || got != check.want {
+                t.Errorf("%s: Enforce(%s, %s, %s) = %v, %v, want %v", inName, check.subject, check.object, check.action, got, err, check.want)
+            }
+        }
+    }
+    enforceAll("inherited")
+
+    // Both roles get the same new rule, factoring moves it into B_base without changing any decision
+    if _, err := db.Exec("INSERT INTO auth_role_policy (subject, object, action, effect, organization_id) VALUES (1, 'invoice', 'read', 'allow', 1), (2, 'invoice', 'read', 'allow', 1)"); err != nil {
+        t.Fatal(err)
+    }
+    checks[5].want, checks[6].want = true, true
+    enforceAll("before factoring")
+
+    moved, err := factorBaseRole(db, openTestEnforcer(t, dbPath), "B_base")
+    if err != nil || moved != 1 {
+        t.Fatalf("factorBaseRole() = %d, %v, want 1", moved, err)
+    }
+    var left int
+    db.QueryRow("SELECT COUNT(*) FROM auth_role_policy WHERE object = 'invoice' AND action = 'read' AND organization_id = 1").Scan(&left)
+    if left != 1 {
+        t.Errorf("factorBaseRole() left %d invoice read rules, want the one of B_base", left)
+    }
+    enforceAll("after factoring")
+
+    // Nothing is shared any more
+    if moved, err := factorBaseRole(db, openTestEnforcer(t, dbPath), "B_base"); err != nil || moved != 0 {
+        t.Errorf("factorBaseRole() again = %d, %v, want 0", moved, err)
+    }
+}
+
+// Rules that only differ in their condition are different rules, factoring must not turn a conditional allow into a plain one
+func Test_factorBaseRoleConditions(t *testing.T) {
+    db, dbPath := openTestDb(t)
+
+    // B_minion may edit its own recent entries, B_admin any entry and its own recent ones too
+    ownRecent := "r.env.Owner == r.sub && withinDays(r.env.EntryDate, r.env.Today, 7)"
+    if _, err := db.Exec("INSERT INTO auth_role_policy (subject, object, action, effect, condition, organization_id) VALUES (1, 'time_entry', 'edit', 'allow', '', 1), (1, 'time_entry', 'edit', 'allow', ?, 1)", ownRecent); err != nil {
+        t.Fatal(err)
+    }
+
+    moved, err := factorBaseRole(db, openTestEnforcer(t, dbPath), "B_base")
+    if err != nil || moved != 1 {
+        t.Fatalf("factorBaseRole() = %d, %v, want 1", moved, err)
+    }
+
+    rules := map[string]string{}
+    rows, err := db.Query("SELECT subject, condition FROM auth_role_policy WHERE object = 'time_entry' AND action = 'edit' AND organization_id = 1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for rows.Next() {
+        var subject, condition string
+        rows.Scan(&subject, &condition)
+        rules[subject] += "[" + condition + "]"
+    }
+    rows.Close()
+    want := map[string]string{"1": "[]", "3": "[" + ownRecent + "]"}
+    if len(rules) != len(want) || rules["1"] != want["1"] || rules["3"] != want["3"] {
+        t.Errorf("time_entry edit rules after factoring = %v, want %v", rules, want)
+    }
+
+    enforcer := openTestEnforcer(t, dbPath)
+    today    := "2026-10-20"
+    checks := []struct {
+        name        string
+        subject     string
+        attributes  RequestAttributes
+        want        bool
+    }{
+        {"minion edits their own entry",    "u2", RequestAttributes{Owner: "u2", EntryDate: "2026-10-19", Today: today}, true},
+        {"minion edits someone else's",     "u2", RequestAttributes{Owner: "u1", EntryDate: "2026-10-19", Today: today}, false},
+        {"minion edits an old entry",       "u2", RequestAttributes{Owner: "u2", EntryDate: "2026-09-01", Today: today}, false},
+        {"admin edits anybody's",           "u1", RequestAttributes{Owner: "u2", EntryDate: "2026-09-01", Today: today}, true},
+    }
+    for _, check := range checks {
+        t.Run(check.name, func(t *testing.T) {
+            got, err := enforcer.EnforceWith(check.subject, "time_entry", "edit", check.attributes)
+            if err != nil || got != check.want {
+                t.Errorf("EnforceWith() = %v, %v, want %v", got, err, check.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "showcase_desktop/widgets"
+)
+
+
+// runRoles lets an admin edit which roles inherit the rules of which other roles in the organization
+func runRoles(inWindow *app.Window, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var roleTextbox         widget.Editor
+    var parentTextbox       widget.Editor
+    var addBtn              widget.Clickable
+    var removeBtn           widget.Clickable
+    var inheritanceList     widget.List
+    var edges               []RoleInheritance
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Roles")
+    inheritanceList.Axis     = layout.Vertical
+
+    refreshRoles := func() {
+        var err error
+        if edges, err = listRoleInheritance(inS3db, inEnforcer.OrganizationID); err != nil {
+            log.Print(err)
+            statusMsg = tr().Text("Could not load the roles")
+        }
+    }
+    refreshRoles()
+
+    // Both names must be existing roles, the change reaches running apps through the policy watcher
+    changeInheritance := func(inChange func(*sql.DB, *OrgEnforcer, int, int) error, inDoneMsg string) {
+        if err := inEnforcer.checkActorWrite(inS3db, roleObject, errRoleDenied); err != nil {
+            statusMsg = errorText(err)
+            return
+        }
+        roleID, err := roleIDByName(inS3db, roleTextbox.Text())
+        if err != nil {
+            statusMsg = errorText(err)
+            return
+        }
+        parentID, err := roleIDByName(inS3db, parentTextbox.Text())
+        if err != nil {
+            statusMsg = errorText(err)
+            return
+        }
+        if err := inChange(inS3db, inEnforcer, roleID, parentID); err != nil {
+            statusMsg = errorText(err)
+            return
+        }
+        statusMsg = fmt.Sprintf(inDoneMsg, roleTextbox.Text(), parentTextbox.Text())
+        refreshRoles()
+    }
+
+    inWindow.Option(app.Title(tr().Text("Roles")), app.Size(unit.Dp(800), unit.Dp(600)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            if addBtn.Clicked(gtx) {
+                changeInheritance(addRoleInheritance, tr().Text("%s now inherits from %s"))
+            }
+            if removeBtn.Clicked(gtx) {
+                changeInheritance(removeRoleInheritance, tr().Text("%s no longer inherits from %s"))
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                // Which role inherits from which
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &roleTextbox, tr().Text("Role, e.g. B_minion")).Layout(gtx) },
+                        func(gtx layout.Context) layout.Dimensions { return widgets.InputBox(theme, &parentTextbox, tr().Text("Inherits from, e.g. B_base")).Layout(gtx) },
+                    )
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        material.Button(theme.Theme, &addBtn, tr().Text("Add")).Layout,
+                        material.Button(theme.Theme, &removeBtn, tr().Text("Remove")).Layout,
+                    )
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+                // The organization's hierarchy
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &inheritanceList).Layout(gtx, len(edges), func(gtx layout.Context, index int) layout.Dimensions {
+                        return layout.UniformInset(unit.Dp(5)).Layout(gtx, material.Body1(theme.Theme, roleEdgeText(edges[index])).Layout)
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
+
+
+// roleEdgeText is an edge of the hierarchy in the user's language
+func roleEdgeText(inEdge RoleInheritance) string {
+    return tr().Sprintf("%s inherits from %s", inEdge.RoleName, inEdge.ParentRoleName)
+}
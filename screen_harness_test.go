@@ -0,0 +1,184 @@
+package main
+
+import (
+    "gioui.org/f32"
+    "gioui.org/io/event"
+    "gioui.org/io/input"
+    "gioui.org/io/key"
+    "gioui.org/io/pointer"
+    "gioui.org/io/semantic"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "image"
+    "strings"
+    "testing"
+    "time"
+)
+
+
+// screenHarness drives a screen's frames without a window. Events are queued on a router the way a window would
+// deliver them, and the widgets are found through the semantic tree the frame leaves behind, by their label.
+type screenHarness struct {
+    t           *testing.T
+    router      input.Router
+    ops         op.Ops
+    size        image.Point
+    now         time.Time
+    screen      func(gtx layout.Context)    // runs the screen's update and layout for one frame
+}
+
+func newScreenHarness(t *testing.T, inSize image.Point, inScreen func(gtx layout.Context)) *screenHarness {
+    t.Helper()
+
+    h := &screenHarness{t: t, size: inSize, now: time.Date(2030, 1, 8, 9, 0, 0, 0, time.UTC), screen: inScreen}
+    h.frame()
+
+    return h
+}
+
+// frame lays out one frame with the events queued since the last one
+func (h *screenHarness) frame() {
+    h.ops.Reset()
+    h.now = h.now.Add(20 * time.Millisecond)
+
+    gtx := layout.Context{
+        Ops:            &h.ops,
+        Source:         h.router.Source(),
+        Now:            h.now,
+        Metric:         unit.Metric{PxPerDp: 1, PxPerSp: 1},
+        Constraints:    layout.Exact(h.size),
+    }
+    h.screen(gtx)
+    h.router.Frame(&h.ops)
+}
+
+// nodes returns the semantic tree of the last frame
+func (h *screenHarness) nodes() []input.SemanticNode {
+    return h.router.AppendSemantics(nil)
+}
+
+// findLabel returns the first node labelled inLabel
+func (h *screenHarness) findLabel(inLabel string) (input.SemanticNode, bool) {
+    for _, node := range h.nodes() {
+        if node.Desc.Label == inLabel {
+            return node, true
+        }
+    }
+    return input.SemanticNode{}, false
+}
+
+// hasText reports whether any label of the last frame holds inText
+func (h *screenHarness) hasText(inText string) bool {
+    for _, node := range h.nodes() {
+        if strings.Contains(node.Desc.Label, inText) {
+            return true
+        }
+    }
+    return false
+}
+
+// clickable returns the node that takes the clicks for the widget labelled inLabel, the label itself or one of its parents
+func (h *screenHarness) clickable(inLabel string) (input.SemanticNode, bool) {
+    nodes := h.nodes()
+    byID  := make(map[input.SemanticID]input.SemanticNode, len(nodes))
+    for _, node := range nodes {
+        byID[node.ID] = node
+    }
+
+    node, found := h.findLabel(inLabel)
+    for found {
+        if node.Desc.Gestures&input.ClickGesture != 0 {
+            return node, true
+        }
+        node, found = byID[node.ParentID]
+    }
+    return input.SemanticNode{}, false
+}
+
+// canClick reports whether the widget labelled inLabel is shown and takes clicks
+func (h *screenHarness) canClick(inLabel string) bool {
+    node, found := h.clickable(inLabel)
+    return found && !node.Desc.Disabled
+}
+
+// settle runs the frame that sees the queued events and one more, as a window redraws after a change,
+// so widgets the change brought in take input too
+func (h *screenHarness) settle() {
+    h.frame()
+    h.frame()
+}
+
+// clickAt presses and releases the primary button at inPos
+func (h *screenHarness) clickAt(inPos image.Point) {
+    position := f32.Pt(float32(inPos.X), float32(inPos.Y))
+    h.router.Queue(
+        pointer.Event{Kind: pointer.Press,   Source: pointer.Mouse, Buttons: pointer.ButtonPrimary, Position: position, Time: h.now.Sub(time.Time{})},
+        pointer.Event{Kind: pointer.Release, Source: pointer.Mouse, Buttons: pointer.ButtonPrimary, Position: position, Time: h.now.Sub(time.Time{})},
+    )
+    h.settle()
+}
+
+// click clicks the middle of the widget labelled inLabel and fails the test if there is none that takes clicks
+func (h *screenHarness) click(inLabel string) {
+    h.t.Helper()
+
+    node, found := h.clickable(inLabel)
+    switch {
+    case !found:
+        h.t.Fatalf("no clickable %q in %v", inLabel, h.labels())
+    case node.Desc.Disabled:
+        h.t.Fatalf("%q is disabled", inLabel)
+    }
+    bounds := node.Desc.Bounds
+    h.clickAt(bounds.Min.Add(bounds.Max).Div(2))
+}
+
+// typeInto focuses the editor showing the hint inHint and types inText at its end
+func (h *screenHarness) typeInto(inHint string, inText string) {
+    h.t.Helper()
+
+    hint, found := h.findLabel(inHint)
+    if !found {
+        h.t.Fatalf("no editor with the hint %q in %v", inHint, h.labels())
+    }
+
+    // The hint is drawn next to its editor rather than in it, they start at about the same point
+    for _, node := range h.nodes() {
+        if node.Desc.Class != semantic.Editor || !hint.Desc.Bounds.Min.In(node.Desc.Bounds.Inset(-2)) {
+            continue
+        }
+        bounds := node.Desc.Bounds
+        h.clickAt(bounds.Min.Add(bounds.Max).Div(2))
+
+        h.router.Queue(key.EditEvent{Range: h.router.EditorState().Selection.Range, Text: inText})
+        h.settle()
+        return
+    }
+    h.t.Fatalf("no editor at the hint %q", inHint)
+}
+
+// press presses and releases a key with the modifiers, e.g. Ctrl+N, the way the window delivers it to the focus
+func (h *screenHarness) press(inName key.Name, inModifiers key.Modifiers) {
+    h.router.Queue(
+        key.Event{Name: inName, Modifiers: inModifiers, State: key.Press},
+        key.Event{Name: inName, Modifiers: inModifiers, State: key.Release},
+    )
+    h.settle()
+}
+
+// focused reports whether inTag, e.g. an editor, has the keyboard focus
+func (h *screenHarness) focused(inTag event.Tag) bool {
+    return h.router.Source().Focused(inTag)
+}
+
+// labels lists the labels of the last frame, for failure messages
+func (h *screenHarness) labels() []string {
+    var labels []string
+    for _, node := range h.nodes() {
+        if len(node.Desc.Label) > 0 {
+            labels = append(labels, node.Desc.Label)
+        }
+    }
+    return labels
+}
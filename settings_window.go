@@ -0,0 +1,124 @@
+package main
+
+import (
+    "database/sql"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "log"
+    "showcase_desktop/i18n"
+    "showcase_desktop/widgets"
+)
+
+
+// settingsScreen is the state of the settings window, where users pick their own preferences
+type settingsScreen struct {
+    db                  *sql.DB
+    userID              int
+    themeEnum           widget.Enum
+    languageEnum        widget.Enum
+    statusMsg           string
+}
+
+func newSettingsScreen(inS3db *sql.DB, inUserID int) *settingsScreen {
+    s := &settingsScreen{db: inS3db, userID: inUserID}
+    s.themeEnum.Value    = currentThemeName()
+    s.languageEnum.Value = tr().Tag()
+
+    return s
+}
+
+// update switches to a newly picked theme or language right away and keeps it for the user's next sign in
+func (s *settingsScreen) update(inGTX layout.Context) {
+    if s.themeEnum.Update(inGTX) {
+        setTheme(s.themeEnum.Value)
+        s.save(settingTheme, s.themeEnum.Value)
+    }
+    if s.languageEnum.Update(inGTX) {
+        setLanguage(s.languageEnum.Value)
+        s.save(settingLanguage, s.languageEnum.Value)
+    }
+}
+
+// save keeps the setting for the next sign in, a failure only leaves it switched until the user signs out
+func (s *settingsScreen) save(inKey string, inValue string) {
+    if err := setUserSetting(s.db, s.userID, inKey, inValue); err != nil {
+        log.Print(err)
+        s.statusMsg = tr().Sprintf("Could not save the setting, it only holds until you sign out: %s", errorText(err))
+        return
+    }
+    s.statusMsg = tr().Text("Saved")
+}
+
+func (s *settingsScreen) layout(inGTX layout.Context, inTheme *widgets.Theme) layout.Dimensions {
+    var languageNames []string
+    themeTitles := []string{tr().Text("Light"), tr().Text("Dark"), tr().Text("High contrast")}
+    for _, tag := range i18n.Languages {
+        languageNames = append(languageNames, i18n.Name(tag))
+    }
+
+    return layout.Flex{
+        Axis: layout.Vertical,
+    }.Layout(inGTX,
+        // Title on top
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return widgets.Title(inTheme, tr().Text("Settings"), widgets.TitleSmall, inTheme.Title).Layout(gtx)
+        }),
+
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return widgets.ReportBox(inTheme, s.statusMsg, inTheme.Success).Layout(gtx)
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+
+        // Theme presets, in the order of widgets.ThemeNames
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+                return radioRowElement(gtx, inTheme, tr().Text("Theme"), &s.themeEnum, widgets.ThemeNames, themeTitles)
+            })
+        }),
+
+        // Languages, each named in itself
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+                return radioRowElement(gtx, inTheme, tr().Text("Language"), &s.languageEnum, i18n.Languages, languageNames)
+            })
+        }),
+    )
+}
+
+
+// runSettings lets the signed in user pick their theme and language
+func runSettings(inWindow *app.Window, inUserID int, inS3db *sql.DB) error {
+    var ops                 op.Ops
+    var ownTheme            windowTheme
+
+    screen := newSettingsScreen(inS3db, inUserID)
+
+    inWindow.Option(app.Title(tr().Text("Settings")), app.Size(unit.Dp(600), unit.Dp(300)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx := app.NewContext(&ops, eventType)
+
+            screen.update(gtx)
+            screen.layout(gtx, ownTheme.current())
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
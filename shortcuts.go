@@ -0,0 +1,161 @@
+package main
+
+import (
+    "gioui.org/io/event"
+    "gioui.org/io/key"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/op/clip"
+    "gioui.org/op/paint"
+    "gioui.org/unit"
+    "gioui.org/widget/material"
+    "image"
+    "showcase_desktop/widgets"
+    "strings"
+)
+
+
+// Keys the windows react to wherever the focus is
+var (
+    nextFocusShortcut       = shortcut{name: key.NameTab}
+    previousFocusShortcut   = shortcut{name: key.NameTab, modifiers: key.ModShift}
+    submitShortcut          = shortcut{name: key.NameReturn}
+    newEntryShortcut        = shortcut{name: "N", modifiers: key.ModShortcut}
+    timerShortcut           = shortcut{name: "T", modifiers: key.ModShortcut}
+    signOutShortcut         = shortcut{name: "Q", modifiers: key.ModShortcut}
+    helpShortcut            = shortcut{name: key.NameF1}
+    closeHelpShortcut       = shortcut{name: key.NameEscape}
+)
+
+// keyNames are the keys whose names Gio writes as symbols the app's fonts don't have
+var keyNames = map[key.Name]string{
+    key.NameReturn: "Enter",
+    key.NameEscape: "Esc",
+}
+
+
+// shortcut is a key with the modifiers it needs, e.g. Ctrl+N
+type shortcut struct {
+    name        key.Name
+    modifiers   key.Modifiers
+}
+
+// filter asks for the shortcut's presses and releases, whichever widget has the focus
+func (s shortcut) filter() key.Filter {
+    return key.Filter{Name: s.name, Required: s.modifiers}
+}
+
+// pressed reports whether inEvent is a press of exactly this combination
+func (s shortcut) pressed(inEvent event.Event) bool {
+    keyEvent, ok := inEvent.(key.Event)
+    return ok && keyEvent.State == key.Press && keyEvent.Name == s.name && keyEvent.Modifiers == s.modifiers
+}
+
+// keys writes the combination the way the help overlay shows it, e.g. "Ctrl+N" or "Shift+Tab"
+func (s shortcut) keys() string {
+    name, ok := keyNames[s.name]
+    if !ok {
+        name = string(s.name)
+    }
+    if s.modifiers == 0 {
+        return name
+    }
+    return strings.ReplaceAll(s.modifiers.String(), "-", "+") + "+" + name
+}
+
+// shortcutEvents returns the shortcut presses of the frame, they come in wherever the focus is
+func shortcutEvents(inGTX layout.Context, inShortcuts ...shortcut) []event.Event {
+    filters := make([]event.Filter, len(inShortcuts))
+    for i, s := range inShortcuts {
+        filters[i] = s.filter()
+    }
+
+    var events []event.Event
+    for {
+        ev, ok := inGTX.Source.Event(filters...)
+        if !ok {
+            break
+        }
+        if keyEvent, isKey := ev.(key.Event); isKey && keyEvent.State == key.Press {
+            events = append(events, ev)
+        }
+    }
+
+    return events
+}
+
+
+// focusOrder is the Tab order of a window's editors and buttons. It is kept by hand, so it stays the same when the
+// layout moves things around, and widgets the user may not use are left out of it.
+type focusOrder []event.Tag
+
+// move focuses the widget after the focused one, or the one before with inBackward, wrapping around at the ends.
+// Without a focused widget Tab starts at the first and Shift+Tab at the last.
+func (o focusOrder) move(inGTX layout.Context, inBackward bool) {
+    if len(o) == 0 {
+        return
+    }
+
+    current := -1
+    for i, tag := range o {
+        if inGTX.Focused(tag) {
+            current = i
+            break
+        }
+    }
+
+    next := (current + 1) % len(o)
+    if inBackward {
+        next = (current - 1 + len(o)) % len(o)
+        if current < 0 {
+            next = len(o) - 1
+        }
+    }
+    inGTX.Execute(key.FocusCmd{Tag: o[next]})
+}
+
+
+// shortcutHelp is one line of the help overlay
+type shortcutHelp struct {
+    keys        string
+    action      string
+}
+
+// shortcutHelpElement draws the help overlay, a panel in the middle of the window that lists the shortcuts
+func shortcutHelpElement(inGTX layout.Context, inTheme *widgets.Theme, inTitle string, inLines []shortcutHelp) layout.Dimensions {
+    return layout.Center.Layout(inGTX, func(gtx layout.Context) layout.Dimensions {
+        gtx.Constraints.Min = image.Point{}
+
+        children := []layout.FlexChild{
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                return widgets.Title(inTheme, inTitle, widgets.TitleSmall, inTheme.Title).Layout(gtx)
+            }),
+        }
+        for _, line := range inLines {
+            children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+                    // Keys in a column of their own
+                    layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                        gtx.Constraints.Min.X = gtx.Dp(unit.Dp(120))
+                        return material.Body1(inTheme.Theme, line.keys).Layout(gtx)
+                    }),
+                    layout.Rigid(material.Body1(inTheme.Theme, line.action).Layout),
+                )
+            }))
+        }
+
+        // The panel covers the window below it, with a border in the theme's color
+        macro := op.Record(gtx.Ops)
+        dims  := layout.UniformInset(unit.Dp(20)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+            return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+        })
+        panel := macro.Stop()
+
+        shape := clip.UniformRRect(image.Rectangle{Max: dims.Size}, gtx.Dp(6))
+        paint.FillShape(gtx.Ops, inTheme.Bg, shape.Op(gtx.Ops))
+        paint.FillShape(gtx.Ops, inTheme.Border, clip.Stroke{Path: shape.Path(gtx.Ops), Width: float32(gtx.Dp(2))}.Op())
+        panel.Add(gtx.Ops)
+
+        return dims
+    })
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "gioui.org/io/key"
+    "gioui.org/layout"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "showcase_desktop/widgets"
+    "strconv"
+    "sync/atomic"
+)
+
+
+// signInScreen is the state of the sign in window. The window only feeds it frames, so tests can drive it without one.
+type signInScreen struct {
+    db                  *sql.DB
+    signInBtn           widget.Clickable
+    usernameTextbox     widget.Editor
+    passwordTextbox     widget.Editor
+    errorMsg            string
+    organizations       []Organization      // offered after signing in when the user is in more than one
+    organizationEnum    widget.Enum
+    continueBtn         widget.Clickable
+    signedInUserID      int
+    signedInUsername    string
+    signedOut           atomic.Bool         // set when the user signs out of the main window, the next frame clears the inputs
+}
+
+// signInResult is who signed in and the organization the main window opens in
+type signInResult struct {
+    UserID          int
+    Username        string
+    Organization    Organization
+}
+
+func newSignInScreen(inS3db *sql.DB) *signInScreen {
+    s := &signInScreen{db: inS3db}
+
+    // Enter in the username moves on to the password, Enter in the password signs in
+    for _, editor := range []*widget.Editor{&s.usernameTextbox, &s.passwordTextbox} {
+        editor.SingleLine = true
+        editor.Submit     = true
+    }
+
+    return s
+}
+
+// focusOrder is username, password, Sign In, then the organization's Continue once there is a choice
+func (s *signInScreen) focusOrder() focusOrder {
+    order := focusOrder{&s.usernameTextbox, &s.passwordTextbox, &s.signInBtn}
+    if len(s.organizations) > 0 {
+        order = append(order, &s.continueBtn)
+    }
+    return order
+}
+
+// submitted reports whether Enter was pressed in the editor this frame
+func submitted(inGTX layout.Context, inEditor *widget.Editor) bool {
+    for {
+        ev, ok := inEditor.Update(inGTX)
+        if !ok {
+            return false
+        }
+        if _, isSubmit := ev.(widget.SubmitEvent); isSubmit {
+            return true
+        }
+    }
+}
+
+// update handles the clicks of the frame and returns true with the result once the user is signed in to an organization
+func (s *signInScreen) update(inGTX layout.Context) (signInResult, bool) {
+    // The user signed out, the window is shown again for the next one
+    if s.signedOut.Swap(false) {
+        s.passwordTextbox.SetText("")
+        s.errorMsg      = ""
+        s.organizations = nil
+        inGTX.Execute(key.FocusCmd{Tag: &s.usernameTextbox})
+    }
+
+    // Tab and Shift+Tab follow the focus order rather than the layout
+    for _, ev := range shortcutEvents(inGTX, nextFocusShortcut, previousFocusShortcut) {
+        s.focusOrder().move(inGTX, previousFocusShortcut.pressed(ev))
+    }
+
+    // Enter in the username moves on to the password. The button is asked first, so its click comes in the frame
+    // that saw it.
+    signInClicked := s.signInBtn.Clicked(inGTX)
+    if submitted(inGTX, &s.usernameTextbox) {
+        inGTX.Execute(key.FocusCmd{Tag: &s.passwordTextbox})
+    }
+
+    // Set an action for button click, or for Enter in the password
+    if signInClicked || submitted(inGTX, &s.passwordTextbox) {
+        username := s.usernameTextbox.Text()
+        password := s.passwordTextbox.Text()
+
+        if len(username) == 0 || len(password) == 0 {
+            s.errorMsg = tr().Text("Please enter a username and a password")
+            return signInResult{}, false
+        }
+
+        // Check sign in credentials
+        success, userID := checkSignIn(username, password, s.db)
+
+        // Failures go to the audit log, without the password
+        if !success {
+            fmt.Printf("Sign-in failed: %s\n", username)
+            s.errorMsg = tr().Text("Wrong username or password")
+            return signInResult{}, false
+        }
+
+        // Users in several organizations pick one first
+        userOrganizations, orgErr := listUserOrganizations(s.db, userID)
+        switch {
+        case orgErr != nil:
+            s.errorMsg = tr().Sprintf("Could not load your organizations: %s", errorText(orgErr))
+        case len(userOrganizations) == 0:
+            s.errorMsg = tr().Text("You are not in any organization")
+        case len(userOrganizations) == 1:
+            return signInResult{userID, username, userOrganizations[0]}, true
+        default:
+            s.errorMsg               = ""
+            s.organizations          = userOrganizations
+            s.organizationEnum.Value = strconv.Itoa(userOrganizations[0].OrganizationID)
+            s.signedInUserID         = userID
+            s.signedInUsername       = username
+        }
+    }
+
+    // Continue in the picked organization
+    if s.continueBtn.Clicked(inGTX) {
+        for _, organization := range s.organizations {
+            if strconv.Itoa(organization.OrganizationID) == s.organizationEnum.Value {
+                return signInResult{s.signedInUserID, s.signedInUsername, organization}, true
+            }
+        }
+    }
+
+    return signInResult{}, false
+}
+
+func (s *signInScreen) layout(inGTX layout.Context, inTheme *widgets.Theme) layout.Dimensions {
+    titleText := tr().Text("Very Simple-teab app")
+    btnText   := tr().Text("Sign In")
+
+    return layout.Flex{
+        // Vertical alignment, from top to bottom
+        Axis: layout.Vertical,
+        // Empty space is left at the start, i.e. at the top
+        Spacing: layout.SpaceStart,
+    }.Layout(inGTX,
+        // Title on top - in Flex Layout Flexed objects start filling from the top
+        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+            return widgets.Title(inTheme, titleText, widgets.TitleLarge, inTheme.Title).Layout(gtx)
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(30)}.Layout),
+
+        // Error box, if there is an error to show
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return widgets.ErrorBox(inTheme, s.errorMsg).Layout(gtx)
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(50)}.Layout),
+
+        // Textbox for username
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return widgets.InputBox(inTheme, &s.usernameTextbox, tr().Text("Enter username")).Layout(gtx)
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+        // Textbox for password
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            // Hide user's input with a mask
+            s.passwordTextbox.Mask = '•'
+            return widgets.InputBox(inTheme, &s.passwordTextbox, tr().Text("Enter password")).Layout(gtx)
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(25)}.Layout),
+
+        // Button for submitting username and password
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            return widgets.Button(inTheme, &s.signInBtn, btnText).Layout(gtx)
+        }),
+
+        // Organization switcher, only once signed in to more than one
+        layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+            if len(s.organizations) == 0 {
+                return layout.Dimensions{}
+            }
+            var keys, names []string
+            for _, organization := range s.organizations {
+                keys  = append(keys, strconv.Itoa(organization.OrganizationID))
+                names = append(names, organization.OrganizationName)
+            }
+            return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+                        return radioRowElement(gtx, inTheme, tr().Text("Organization"), &s.organizationEnum, keys, names)
+                    })
+                }),
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Button(inTheme, &s.continueBtn, tr().Text("Continue")).Layout(gtx)
+                }),
+            )
+        }),
+
+        // Empty spacer
+        layout.Rigid(layout.Spacer{Height: unit.Dp(25)}.Layout),
+    )
+}
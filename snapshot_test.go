@@ -0,0 +1,299 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "gioui.org/gpu/headless"
+    "gioui.org/io/input"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/op/paint"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "image"
+    "image/color"
+    "image/png"
+    "os"
+    "path/filepath"
+    "showcase_desktop/widgets"
+    "testing"
+    "time"
+)
+
+
+// Golden images are compared with what the widgets draw now, so layout changes show up as changed PNGs in review.
+// After a change that is meant to look different, rewrite them with
+//
+//     go test -run Test_snapshots -update
+var updateGolden = flag.Bool("update", false, "rewrite the golden images in testdata/golden instead of comparing with them")
+
+const (
+    goldenDir               = "testdata/golden"
+    goldenPixelThreshold    = 0.1       // how different a pixel may look before it counts, from 0 to 1
+    goldenMaxDiffShare      = 0.002     // share of pixels that may count before the snapshot fails, for driver noise
+)
+
+// snapshotThemes are the themes every snapshot is drawn in
+var snapshotThemes = map[string]func() *widgets.Theme{
+    widgets.ThemeLight:         widgets.Light,
+    widgets.ThemeDark:          widgets.Dark,
+    widgets.ThemeHighContrast:  widgets.HighContrast,
+}
+
+// snapshot is one widget or screen drawn at a fixed size
+type snapshot struct {
+    name    string
+    size    image.Point
+    layout  func(gtx layout.Context, theme *widgets.Theme)
+}
+
+func Test_snapshots(t *testing.T) {
+    db, dbPath := openTestDb(t)
+
+    var textbox, filledTextbox widget.Editor
+    var btn                    widget.Clickable
+    filledTextbox.SetText("ACME")
+
+    signIn      := newSignInScreen(db)
+    signInError := newSignInScreen(db)
+    signInError.errorMsg = "Wrong username or password"
+
+    // The week and the budgets depend on today, they are pinned so the screen looks the same every day
+    minion := newMainScreen(db, openTestEnforcer(t, dbPath), 2, "Tadej", Organization{OrganizationID: 1, OrganizationName: "Steaby"})
+    admin  := newMainScreen(db, openTestEnforcer(t, dbPath), 1, "Ray", Organization{OrganizationID: 1, OrganizationName: "Steaby"})
+    help   := newMainScreen(db, openTestEnforcer(t, dbPath), 2, "Tadej", Organization{OrganizationID: 1, OrganizationName: "Steaby"})
+    for _, screen := range []*mainScreen{minion, admin, help} {
+        screen.weekText   = "Week of 2030-01-07: draft, 0.00h logged"
+        screen.budgetText = ""
+    }
+    help.helpOpen = true
+
+    widgetSize := image.Pt(400, 120)
+    snapshots  := []snapshot{
+        {"title_large", widgetSize, func(gtx layout.Context, theme *widgets.Theme) {
+            widgets.Title(theme, "Very Simple-teab app", widgets.TitleLarge, theme.Title).Layout(gtx)
+        }},
+        {"title_small", widgetSize, func(gtx layout.Context, theme *widgets.Theme) {
+            widgets.Title(theme, "Audit log", widgets.TitleSmall, theme.Title).Layout(gtx)
+        }},
+        {"error_box", widgetSize, func(gtx layout.Context, theme *widgets.Theme) {
+            widgets.ErrorBox(theme, "Wrong username or password").Layout(gtx)
+        }},
+        {"report_box", widgetSize, func(gtx layout.Context, theme *widgets.Theme) {
+            widgets.ReportBox(theme, "Logged 1.50h for ACME, confirmed 1 times", theme.Success).Layout(gtx)
+        }},
+        {"input_box_hint", widgetSize, func(gtx layout.Context, theme *widgets.Theme) {
+            widgets.InputBox(theme, &textbox, "Enter username").Layout(gtx)
+        }},
+        {"input_box_text", widgetSize, func(gtx layout.Context, theme *widgets.Theme) {
+            widgets.InputBox(theme, &filledTextbox, "Input for T&B client name").Layout(gtx)
+        }},
+        {"button", widgetSize, func(gtx layout.Context, theme *widgets.Theme) {
+            widgets.Button(theme, &btn, "Confirm").Layout(gtx)
+        }},
+        {"button_disabled", widgetSize, func(gtx layout.Context, theme *widgets.Theme) {
+            guardedElement(gtx, GuardDisabled, func(gtx layout.Context) layout.Dimensions {
+                return widgets.Button(theme, &btn, "Confirm").Layout(gtx)
+            })
+        }},
+        {"sign_in", image.Pt(800, 600), func(gtx layout.Context, theme *widgets.Theme) {
+            signIn.layout(gtx, theme)
+        }},
+        {"sign_in_error", image.Pt(800, 600), func(gtx layout.Context, theme *widgets.Theme) {
+            signInError.layout(gtx, theme)
+        }},
+        {"main_minion", image.Pt(1000, 1400), func(gtx layout.Context, theme *widgets.Theme) {
+            minion.layout(gtx, theme)
+        }},
+        {"main_admin", image.Pt(1000, 1400), func(gtx layout.Context, theme *widgets.Theme) {
+            admin.layout(gtx, theme)
+        }},
+        {"main_help", image.Pt(1000, 1400), func(gtx layout.Context, theme *widgets.Theme) {
+            help.layout(gtx, theme)
+        }},
+    }
+
+    for _, snap := range snapshots {
+        for themeName, newTheme := range snapshotThemes {
+            name := snap.name + "_" + themeName
+            t.Run(name, func(t *testing.T) {
+                theme := newTheme()
+                got   := renderSnapshot(t, snap.size, theme, func(gtx layout.Context) { snap.layout(gtx, theme) })
+                compareGolden(t, name, got)
+            })
+        }
+    }
+}
+
+// renderSnapshot draws inWidget on the theme's background with the GPU's headless renderer, and skips the test
+// where there is no GPU context to draw with
+func renderSnapshot(t *testing.T, inSize image.Point, inTheme *widgets.Theme, inWidget func(gtx layout.Context)) *image.RGBA {
+    t.Helper()
+
+    window, err := headless.NewWindow(inSize.X, inSize.Y)
+    if err != nil {
+        t.Skipf("no headless GPU context, try EGL_PLATFORM=surfaceless: %v", err)
+    }
+    defer window.Release()
+
+    // Without an event source the widgets would draw themselves disabled
+    var ops    op.Ops
+    var router input.Router
+    gtx := layout.Context{
+        Ops:            &ops,
+        Source:         router.Source(),
+        Now:            time.Date(2030, 1, 8, 9, 0, 0, 0, time.UTC),
+        Metric:         unit.Metric{PxPerDp: 1, PxPerSp: 1},
+        Constraints:    layout.Exact(inSize),
+    }
+    paint.Fill(gtx.Ops, inTheme.Bg)
+    inWidget(gtx)
+
+    if err := window.Frame(&ops); err != nil {
+        t.Fatal(err)
+    }
+    img := image.NewRGBA(image.Rectangle{Max: inSize})
+    if err := window.Screenshot(img); err != nil {
+        t.Fatal(err)
+    }
+
+    return img
+}
+
+// compareGolden fails the test when inGot looks different from the golden image, or rewrites the image with -update.
+// The image drawn and a diff with the changed pixels in red are left in the temp directory for a look.
+func compareGolden(t *testing.T, inName string, inGot *image.RGBA) {
+    t.Helper()
+
+    goldenPath := filepath.Join(goldenDir, inName+".png")
+    if *updateGolden {
+        if err := writePNG(goldenPath, inGot); err != nil {
+            t.Fatal(err)
+        }
+        return
+    }
+
+    want, err := readPNG(goldenPath)
+    if err != nil {
+        t.Fatalf("no golden image to compare with, rewrite it with -update: %v", err)
+    }
+
+    differing, diff := perceptualDiff(want, inGot)
+    share          := float64(differing) / float64(max(inGot.Bounds().Dx()*inGot.Bounds().Dy(), 1))
+    if share <= goldenMaxDiffShare {
+        return
+    }
+
+    failedDir := filepath.Join(os.TempDir(), "showcase_golden")
+    writePNG(filepath.Join(failedDir, inName+"_got.png"), inGot)
+    if diff != nil {
+        writePNG(filepath.Join(failedDir, inName+"_diff.png"), diff)
+    }
+    t.Errorf("%s looks different from %s: %d pixels (%.2f%%), see %s", inName, goldenPath, differing, share*100, failedDir)
+}
+
+// perceptualDiff counts the pixels of b that look different from a, with the YIQ colour distance pixelmatch uses,
+// and draws them red over a faded a. Images of different sizes differ in every pixel and have no diff image.
+func perceptualDiff(a image.Image, b image.Image) (int, *image.RGBA) {
+    bounds := a.Bounds()
+    if bounds.Size() != b.Bounds().Size() {
+        return max(bounds.Dx()*bounds.Dy(), b.Bounds().Dx()*b.Bounds().Dy()), nil
+    }
+
+    // 35215 is the largest distance, between black and white
+    maxDelta  := 35215 * goldenPixelThreshold * goldenPixelThreshold
+    diff      := image.NewRGBA(image.Rectangle{Max: bounds.Size()})
+    differing := 0
+    for y := 0; y < bounds.Dy(); y++ {
+        for x := 0; x < bounds.Dx(); x++ {
+            ca := color.NRGBAModel.Convert(a.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+            cb := color.NRGBAModel.Convert(b.At(b.Bounds().Min.X+x, b.Bounds().Min.Y+y)).(color.NRGBA)
+
+            if colorDelta(ca, cb) > maxDelta {
+                differing++
+                diff.Set(x, y, color.NRGBA{R: 255, A: 255})
+                continue
+            }
+            gray := uint8(255 - (255-int(yiq(ca)[0]))/4)
+            diff.Set(x, y, color.NRGBA{R: gray, G: gray, B: gray, A: 255})
+        }
+    }
+
+    return differing, diff
+}
+
+// colorDelta is the squared YIQ distance of two colours blended over white
+func colorDelta(a color.NRGBA, b color.NRGBA) float64 {
+    ya, yb := yiq(a), yiq(b)
+    dy, di, dq := ya[0]-yb[0], ya[1]-yb[1], ya[2]-yb[2]
+    return 0.5053*dy*dy + 0.299*di*di + 0.1957*dq*dq
+}
+
+func yiq(c color.NRGBA) [3]float64 {
+    blend := func(v uint8) float64 { return 255 + (float64(v)-255)*float64(c.A)/255 }
+    r, g, b := blend(c.R), blend(c.G), blend(c.B)
+
+    return [3]float64{
+        0.29889531*r + 0.58662247*g + 0.11448223*b,
+        0.59597799*r - 0.27417610*g - 0.32180189*b,
+        0.21147017*r - 0.52261711*g + 0.31114694*b,
+    }
+}
+
+func readPNG(inPath string) (image.Image, error) {
+    file, err := os.Open(inPath)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    return png.Decode(file)
+}
+
+func writePNG(inPath string, inImg image.Image) error {
+    if err := os.MkdirAll(filepath.Dir(inPath), 0o755); err != nil {
+        return err
+    }
+    file, err := os.Create(inPath)
+    if err != nil {
+        return err
+    }
+    if err := png.Encode(file, inImg); err != nil {
+        file.Close()
+        return fmt.Errorf("writing %s: %w", inPath, err)
+    }
+    return file.Close()
+}
+
+func Test_perceptualDiff(t *testing.T) {
+    base := image.NewRGBA(image.Rect(0, 0, 10, 10))
+    for i := range base.Pix {
+        base.Pix[i] = 255
+    }
+
+    tests := []struct {
+        name            string
+        change          color.NRGBA
+        wantDiffering   int
+    }{
+        {"same",            color.NRGBA{R: 255, G: 255, B: 255, A: 255}, 0},
+        {"slightly off",    color.NRGBA{R: 250, G: 252, B: 251, A: 255}, 0},
+        {"black pixel",     color.NRGBA{A: 255},                          1},
+        {"faint red",       color.NRGBA{R: 255, A: 40},                   1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            changed := image.NewRGBA(base.Bounds())
+            copy(changed.Pix, base.Pix)
+            changed.Set(4, 4, tt.change)
+
+            if differing, _ := perceptualDiff(base, changed); differing != tt.wantDiffering {
+                t.Errorf("perceptualDiff() = %d pixels, want %d", differing, tt.wantDiffering)
+            }
+        })
+    }
+
+    if differing, diff := perceptualDiff(base, image.NewRGBA(image.Rect(0, 0, 5, 5))); differing != 100 || diff != nil {
+        t.Errorf("perceptualDiff() of different sizes = %d, %v", differing, diff)
+    }
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+    "showcase_desktop/widgets"
+    "slices"
+    "sync"
+)
+
+
+// appTheme is the preset every window is drawn in. The settings window switches it, open windows are told to redraw.
+var appTheme = struct {
+    sync.Mutex
+    name        string
+}{name: widgets.ThemeLight}
+
+
+// currentThemeName returns the name of the preset windows are drawn in
+func currentThemeName() string {
+    appTheme.Lock()
+    defer appTheme.Unlock()
+    return appTheme.name
+}
+
+// setTheme switches every window to the named preset, names it doesn't know switch to the light one
+func setTheme(inName string) {
+    if !slices.Contains(widgets.ThemeNames, inName) {
+        inName = widgets.ThemeLight
+    }
+
+    appTheme.Lock()
+    appTheme.name = inName
+    appTheme.Unlock()
+
+    notifySettingChange()
+}
+
+
+// windowTheme is a window's own copy of the app's theme, text shapers can't be shared between the windows' go routines
+type windowTheme struct {
+    theme       *widgets.Theme
+}
+
+// current returns the window's copy, made again when the app's theme was switched since the last frame
+func (w *windowTheme) current() *widgets.Theme {
+    if name := currentThemeName(); w.theme == nil || w.theme.Name != name {
+        w.theme = widgets.NewTheme(name)
+    }
+    return w.theme
+}
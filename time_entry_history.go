@@ -0,0 +1,375 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "log"
+    "slices"
+    "time"
+)
+
+
+// Changes kept in the history of a time entry
+const (
+    entryCreated    = "created"
+    entryUpdated    = "updated"
+    entryDeleted    = "deleted"
+    entryRestored   = "restored"
+    entryUndone     = "undone"
+)
+
+// How many of their own changes a user undoes when they don't say
+const defaultUndoCount = 1
+
+var (
+    errVersionNotFound = errors.New("time entry version not found")
+    errNothingToUndo   = errors.New("you have no changes left to undo")
+)
+
+// TimeEntryVersion is one change to a time entry and the entry as it was after it
+type TimeEntryVersion struct {
+    VersionID           int
+    Version             int
+    Change              string
+    ChangedBy           int
+    ChangedByName       string
+    ChangedAt           string
+    Entry               TimeEntry   // before the change for deletes
+    UndoneBy            int         // version that undid this change, zero while it holds
+}
+
+func (v TimeEntryVersion) Text() string {
+    text := tr().Sprintf("v%d %s by %s at %s: %s %s %s", v.Version, changeText(v.Change), v.ChangedByName, localDateTime(v.ChangedAt),
+        tr().Date(v.Entry.EntryDate), v.Entry.ClientName, tr().Duration(v.Entry.Minutes))
+    if v.UndoneBy != 0 {
+        text = tr().Sprintf("%s (undone)", text)
+    }
+    return text
+}
+
+// Diff lists the fields the change set, compared with the version before it. Nil for the first version.
+func (v TimeEntryVersion) Diff(inPrevious *TimeEntryVersion) []string {
+    if inPrevious == nil || v.Change == entryDeleted {
+        return nil
+    }
+
+    var fields []string
+    before, after := inPrevious.Entry, v.Entry
+    if inPrevious.Change == entryDeleted {
+        return []string{tr().Text("entry back")}
+    }
+    if before.Minutes != after.Minutes {
+        fields = append(fields, tr().Sprintf("time %s -> %s", tr().Duration(before.Minutes), tr().Duration(after.Minutes)))
+    }
+    if dateKey(before.EntryDate) != dateKey(after.EntryDate) {
+        fields = append(fields, tr().Sprintf("date %s -> %s", tr().Date(before.EntryDate), tr().Date(after.EntryDate)))
+    }
+    if before.ClientName != after.ClientName {
+        fields = append(fields, tr().Sprintf("client %s -> %s", before.ClientName, after.ClientName))
+    }
+    if before.ProjectID != after.ProjectID || before.TaskID != after.TaskID {
+        fields = append(fields, tr().Sprintf("task %d -> %d", before.TaskID, after.TaskID))
+    }
+    return fields
+}
+
+
+// recordTimeEntryVersion adds the next version of the entry. For deletes pass the entry as it was.
+func recordTimeEntryVersion(inDB dbRunner, inActorID int, inChange string, inEntry TimeEntry) (int, error) {
+    result, err := inDB.Exec(`
+INSERT INTO time_entry_version (time_entry_id, version, change, changed_by, changed_at, timesheet_id, user_id, client_name, project_id, task_id, entry_date, minutes_spent)
+VALUES (?, (SELECT COALESCE(MAX(version), 0) + 1 FROM time_entry_version WHERE time_entry_id = ?), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, inEntry.TimeEntryID, inEntry.TimeEntryID, inChange, inActorID, time.Now().UTC().Format(time.RFC3339), inEntry.TimesheetID, inEntry.UserID,
+        inEntry.ClientName, nullableID(inEntry.ProjectID), nullableID(inEntry.TaskID), dateKey(inEntry.EntryDate), inEntry.Minutes)
+    if err != nil {
+        return 0, err
+    }
+    versionID, _ := result.LastInsertId()
+
+    return int(versionID), nil
+}
+
+// timeEntryHistory returns every version of one entry, oldest first
+func timeEntryHistory(inDB dbRunner, inTimeEntryID int) ([]TimeEntryVersion, error) {
+    return queryTimeEntryVersions(inDB, "tev.time_entry_id = ?", inTimeEntryID)
+}
+
+// timesheetHistory returns the versions of every entry of the week, deleted ones included, oldest first
+func timesheetHistory(inDB dbRunner, inTimesheetID int) ([]TimeEntryVersion, error) {
+    return queryTimeEntryVersions(inDB, "tev.timesheet_id = ?", inTimesheetID)
+}
+
+func getTimeEntryVersion(inDB dbRunner, inVersionID int) (TimeEntryVersion, error) {
+    versions, err := queryTimeEntryVersions(inDB, "tev.time_entry_version_id = ?", inVersionID)
+    if err != nil {
+        return TimeEntryVersion{}, err
+    }
+    if len(versions) == 0 {
+        return TimeEntryVersion{}, errVersionNotFound
+    }
+
+    return versions[0], nil
+}
+
+func queryTimeEntryVersions(inDB dbRunner, inWhere string, inArgs ...any) ([]TimeEntryVersion, error) {
+    rows, err := inDB.Query(`
+SELECT
+      tev.time_entry_version_id
+    , tev.version
+    , tev.change
+    , tev.changed_by
+    , COALESCE(ud.username, '')
+    , tev.changed_at
+    , tev.time_entry_id
+    , tev.timesheet_id
+    , tev.user_id
+    , tev.client_name
+    , COALESCE(tev.project_id, 0)
+    , COALESCE(tev.task_id, 0)
+    , tev.entry_date
+    , tev.minutes_spent
+    , COALESCE(tev.undone_by_version_id, 0)
+FROM
+    time_entry_version          AS tev
+    LEFT JOIN user_dim          AS ud
+        ON ud.user_id = tev.changed_by
+WHERE
+    `+inWhere+`
+ORDER BY
+      tev.time_entry_version_id
+    `, inArgs...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var versions []TimeEntryVersion
+    for rows.Next() {
+        var v TimeEntryVersion
+        if err := rows.Scan(&v.VersionID, &v.Version, &v.Change, &v.ChangedBy, &v.ChangedByName, &v.ChangedAt, &v.Entry.TimeEntryID, &v.Entry.TimesheetID,
+            &v.Entry.UserID, &v.Entry.ClientName, &v.Entry.ProjectID, &v.Entry.TaskID, &v.Entry.EntryDate, &v.Entry.Minutes, &v.UndoneBy); err != nil {
+            return nil, err
+        }
+        versions = append(versions, v)
+    }
+
+    return versions, rows.Err()
+}
+
+// previousTimeEntryVersion is the version before inVersion of the same entry, nil when it is the first
+func previousTimeEntryVersion(inDB dbRunner, inVersion TimeEntryVersion) (*TimeEntryVersion, error) {
+    versions, err := queryTimeEntryVersions(inDB, `
+    tev.time_entry_version_id = (
+        SELECT MAX(time_entry_version_id) FROM time_entry_version WHERE time_entry_id = ? AND version < ?
+    )`, inVersion.Entry.TimeEntryID, inVersion.Version)
+    if err != nil || len(versions) == 0 {
+        return nil, err
+    }
+
+    return &versions[0], nil
+}
+
+// inTimeEntryTx runs one change of an entry in a transaction, so the entry, its history and the audit log never disagree.
+// A denied change wrote nothing but its audit event, which is kept.
+func inTimeEntryTx(inDB *sql.DB, inChange func(inTx *sql.Tx) error) error {
+    tx, err := inDB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := inChange(tx); err != nil {
+        if errors.Is(err, errTimeEntryDenied) {
+            if commitErr := tx.Commit(); commitErr != nil {
+                log.Print(commitErr)
+            }
+        }
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// setTimeEntryState makes the entry look like inTarget, nil deletes it. An entry that is gone comes back under its old ID.
+// Both the entry as it is and as it will be have to be writable. Returns the new version's ID.
+// Run it in inTimeEntryTx, it writes the entry, its version and the audit event one after the other.
+func setTimeEntryState(inDB dbRunner, inEnforcer *OrgEnforcer, inActorID int, inTimeEntryID int, inTarget *TimeEntry, inChange string, inNow time.Time) (int, error) {
+    current, err := getTimeEntry(inDB, inTimeEntryID)
+    exists     := err == nil
+    if err != nil && !errors.Is(err, errTimeEntryNotFound) {
+        return 0, err
+    }
+    if exists {
+        if err := checkTimeEntryWritable(inDB, inEnforcer, inActorID, current, inNow); err != nil {
+            return 0, err
+        }
+    }
+    // The target needs its own check when the rules would see it differently, e.g. a deleted entry coming back
+    if inTarget != nil && (!exists || inTarget.TimesheetID != current.TimesheetID || entryAttributes(*inTarget, inNow) != entryAttributes(current, inNow)) {
+        if err := checkTimeEntryWritable(inDB, inEnforcer, inActorID, *inTarget, inNow); err != nil {
+            return 0, err
+        }
+    }
+
+    // Time the change adds to a project counts against its budget, the same as a new entry
+    var project Project
+    added := 0
+    if inTarget != nil && inTarget.ProjectID != 0 {
+        added = inTarget.Minutes
+        if exists && current.ProjectID == inTarget.ProjectID {
+            added -= current.Minutes
+        }
+    }
+    if added > 0 {
+        if project, err = getProject(inDB, inTarget.ProjectID); err != nil {
+            return 0, err
+        }
+        if err := checkBudget(inDB, inEnforcer, inTarget.UserID, project, inTarget.EntryDate, added); err != nil {
+            return 0, err
+        }
+    }
+
+    var before, after string
+    switch {
+    case inTarget == nil && !exists:
+        return 0, errTimeEntryNotFound
+    case inTarget == nil:
+        before = timeEntryAuditValue(current)
+        _, err = inDB.Exec("DELETE FROM time_entry WHERE time_entry_id = ?", inTimeEntryID)
+    case exists:
+        before = timeEntryAuditValue(current)
+        after  = timeEntryAuditValue(*inTarget)
+        _, err = inDB.Exec("UPDATE time_entry SET client_name = ?, project_id = ?, task_id = ?, entry_date = ?, minutes_spent = ? WHERE time_entry_id = ?",
+            inTarget.ClientName, nullableID(inTarget.ProjectID), nullableID(inTarget.TaskID), dateKey(inTarget.EntryDate), inTarget.Minutes, inTimeEntryID)
+    default:
+        after  = timeEntryAuditValue(*inTarget)
+        _, err = inDB.Exec("INSERT INTO time_entry (time_entry_id, timesheet_id, user_id, client_name, project_id, task_id, entry_date, minutes_spent) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+            inTimeEntryID, inTarget.TimesheetID, inTarget.UserID, inTarget.ClientName, nullableID(inTarget.ProjectID), nullableID(inTarget.TaskID),
+            dateKey(inTarget.EntryDate), inTarget.Minutes)
+    }
+    if err != nil {
+        return 0, err
+    }
+
+    snapshot := current
+    if inTarget != nil {
+        snapshot = *inTarget
+    }
+    versionID, err := recordTimeEntryVersion(inDB, inActorID, inChange, snapshot)
+    if err != nil {
+        return 0, err
+    }
+    if added > 0 {
+        updateBudgetAlerts(inDB, project)
+    }
+
+    eventType := auditTimeEntryRestore
+    switch inChange {
+    case entryUpdated:
+        eventType = auditTimeEntryUpdate
+    case entryDeleted:
+        eventType = auditTimeEntryDelete
+    }
+    logAuditEvent(inDB, AuditEvent{ActorID: inActorID, OrganizationID: inEnforcer.OrganizationID, EventType: eventType,
+        Object: fmt.Sprintf("%s %d", timeEntryObject, inTimeEntryID), Before: before, After: after})
+
+    return versionID, nil
+}
+
+// restoreTimeEntryVersion puts the entry back the way it was in the version, also when it was deleted since.
+// Only possible while the week is editable and the edit rules allow it.
+func restoreTimeEntryVersion(inDB *sql.DB, inEnforcer *OrgEnforcer, inActorID int, inVersionID int, inNow time.Time) (TimeEntry, error) {
+    version, err := getTimeEntryVersion(inDB, inVersionID)
+    if err != nil {
+        return TimeEntry{}, err
+    }
+    if version.Change == entryDeleted {
+        return TimeEntry{}, newError("v%d is the delete, restore the version before it", version.Version)
+    }
+
+    err = inTimeEntryTx(inDB, func(inTx *sql.Tx) error {
+        _, err := setTimeEntryState(inTx, inEnforcer, inActorID, version.Entry.TimeEntryID, &version.Entry, entryRestored, inNow)
+        return err
+    })
+    if err != nil {
+        return TimeEntry{}, err
+    }
+
+    return version.Entry, nil
+}
+
+// undoTimeEntryChanges reverts the actor's own last inCount changes that were not undone yet, newest first.
+// Each change is undone in a transaction of its own. It stops at the first change that can't be undone any more and
+// returns how many were.
+func undoTimeEntryChanges(inDB *sql.DB, inEnforcer *OrgEnforcer, inActorID int, inCount int, inNow time.Time) (int, error) {
+    changes, err := queryTimeEntryVersions(inDB, `
+    tev.time_entry_version_id IN (
+        SELECT
+            time_entry_version_id
+        FROM
+            time_entry_version
+        WHERE
+                changed_by = ?
+            AND change <> ?
+            AND undone_by_version_id IS NULL
+        ORDER BY
+            time_entry_version_id DESC
+        LIMIT ?
+    )`, inActorID, entryUndone, inCount)
+    if err != nil {
+        return 0, err
+    }
+    if len(changes) == 0 {
+        return 0, errNothingToUndo
+    }
+    slices.Reverse(changes)
+
+    for undone, change := range changes {
+        err := inTimeEntryTx(inDB, func(inTx *sql.Tx) error {
+            // The version before the change is what the entry goes back to, nothing before a create
+            previous, err := previousTimeEntryVersion(inTx, change)
+            if err != nil {
+                return err
+            }
+            var target *TimeEntry
+            if previous != nil && previous.Change != entryDeleted {
+                target = &previous.Entry
+            }
+
+            versionID, err := setTimeEntryState(inTx, inEnforcer, inActorID, change.Entry.TimeEntryID, target, entryUndone, inNow)
+            if errors.Is(err, errTimeEntryNotFound) && target == nil {
+                // Already gone, e.g. someone deleted the entry the change created
+                versionID, err = recordTimeEntryVersion(inTx, inActorID, entryUndone, change.Entry)
+            }
+            if err != nil {
+                return fmt.Errorf("%w: %w", newError("could not undo v%d of the %s entry", change.Version, change.Entry.EntryDate), err)
+            }
+
+            _, err = inTx.Exec("UPDATE time_entry_version SET undone_by_version_id = ? WHERE time_entry_version_id = ?", versionID, change.VersionID)
+            return err
+        })
+        if err != nil {
+            return undone, err
+        }
+    }
+
+    return len(changes), nil
+}
+
+// nextTimeEntryID is the ID a new entry gets - above every ID in the history too, so a deleted entry's versions
+// never end up under a new entry
+func nextTimeEntryID(inDB dbRunner) (int, error) {
+    var entryID int
+    err := inDB.QueryRow(`
+SELECT
+    COALESCE(MAX(time_entry_id), 0) + 1
+FROM (
+    SELECT time_entry_id FROM time_entry
+    UNION ALL
+    SELECT time_entry_id FROM time_entry_version
+)
+    `).Scan(&entryID)
+
+    return entryID, err
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+    "errors"
+    "strings"
+    "testing"
+    "time"
+)
+
+func Test_timeEntryHistory(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    day        := time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)
+
+    entry, err := addTimeEntry(db, 1, 2, "ACME", day, 60)
+    if err != nil {
+        t.Fatal(err)
+    }
+    updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 90, day)
+    if err := deleteTimeEntry(db, enforcer, 2, entry.TimeEntryID, day); err != nil {
+        t.Fatal(err)
+    }
+
+    versions, err := timeEntryHistory(db, entry.TimeEntryID)
+    if err != nil || len(versions) != 3 {
+        t.Fatalf("timeEntryHistory() = %v, %v, want 3 versions", versions, err)
+    }
+    if versions[0].Change != entryCreated || versions[2].Change != entryDeleted || versions[2].Entry.Minutes != 90 {
+        t.Errorf("timeEntryHistory() = %+v", versions)
+    }
+    if diff := versions[1].Diff(&versions[0]); len(diff) != 1 || diff[0] != "time 1.00h -> 1.50h" {
+        t.Errorf("Diff() = %q", diff)
+    }
+
+    // A new entry never takes the deleted one's ID, its history stays its own
+    other, err := addTimeEntry(db, 1, 2, "ACME", day, 30)
+    if err != nil || other.TimeEntryID == entry.TimeEntryID {
+        t.Errorf("addTimeEntry() after a delete = %+v, %v", other, err)
+    }
+
+    // Restoring the first version brings the entry back under its ID
+    restored, err := restoreTimeEntryVersion(db, enforcer, 2, versions[0].VersionID, day)
+    if err != nil || restored.Minutes != 60 {
+        t.Fatalf("restoreTimeEntryVersion() = %+v, %v", restored, err)
+    }
+    if back, err := getTimeEntry(db, entry.TimeEntryID); err != nil || back.Minutes != 60 {
+        t.Errorf("getTimeEntry() after the restore = %+v, %v", back, err)
+    }
+    if _, err := restoreTimeEntryVersion(db, enforcer, 2, versions[2].VersionID, day); err == nil || !strings.Contains(err.Error(), "the delete") {
+        t.Errorf("restoreTimeEntryVersion() of the delete error = %v", err)
+    }
+    if _, err := restoreTimeEntryVersion(db, enforcer, 3, versions[1].VersionID, day); !errors.Is(err, errTimeEntryDenied) {
+        t.Errorf("restoreTimeEntryVersion() by Petar error = %v, want %v", err, errTimeEntryDenied)
+    }
+
+    // Not once the week is submitted
+    db.Exec("UPDATE timesheet SET state = ? WHERE timesheet_id = ?", timesheetSubmitted, entry.TimesheetID)
+    if _, err := restoreTimeEntryVersion(db, enforcer, 2, versions[1].VersionID, day); !errors.Is(err, errTimesheetReadOnly) {
+        t.Errorf("restoreTimeEntryVersion() in a submitted week error = %v, want %v", err, errTimesheetReadOnly)
+    }
+}
+
+// A change that can't be recorded in the history doesn't happen either
+func Test_timeEntryChangeAtomic(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    day        := time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)
+
+    entry, err := addTimeEntry(db, 1, 2, "ACME", day, 60)
+    if err != nil {
+        t.Fatal(err)
+    }
+    updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 90, day)
+    if _, err := db.Exec("CREATE TRIGGER fail_version BEFORE INSERT ON time_entry_version BEGIN SELECT RAISE(ABORT, 'disk full'); END"); err != nil {
+        t.Fatal(err)
+    }
+
+    if _, err := updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 120, day); err == nil {
+        t.Error("updateTimeEntry() without a history succeeded")
+    }
+    if err := deleteTimeEntry(db, enforcer, 2, entry.TimeEntryID, day); err == nil {
+        t.Error("deleteTimeEntry() without a history succeeded")
+    }
+    if undone, err := undoTimeEntryChanges(db, enforcer, 2, 1, day); err == nil || undone != 0 {
+        t.Errorf("undoTimeEntryChanges() without a history = %d, %v", undone, err)
+    }
+
+    if current, err := getTimeEntry(db, entry.TimeEntryID); err != nil || current.Minutes != 90 {
+        t.Errorf("entry after the failed changes = %+v, %v, want 90 minutes", current, err)
+    }
+    versions, _ := timeEntryHistory(db, entry.TimeEntryID)
+    if len(versions) != 2 || versions[1].UndoneBy != 0 {
+        t.Errorf("timeEntryHistory() after the failed changes = %+v", versions)
+    }
+}
+
+func Test_undoTimeEntryChanges(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer   := openTestEnforcer(t, dbPath)
+    day        := time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)
+
+    entry, err := addTimeEntry(db, 1, 2, "ACME", day, 60)
+    if err != nil {
+        t.Fatal(err)
+    }
+    updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 90, day)
+    updateTimeEntry(db, enforcer, 2, entry.TimeEntryID, 120, day)
+    deleteTimeEntry(db, enforcer, 2, entry.TimeEntryID, day)
+
+    tests := []struct {
+        name        string
+        count       int
+        wantUndone  int
+        wantMinutes int         // zero when the entry should be gone
+        wantErr     error
+    }{
+        {"the delete",          1, 1, 120, nil},
+        {"both updates",        2, 2, 60,  nil},
+        {"the create",          5, 1, 0,   nil},
+        {"nothing left",        1, 0, 0,   errNothingToUndo},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            undone, err := undoTimeEntryChanges(db, enforcer, 2, tt.count, day)
+            if undone != tt.wantUndone || !errors.Is(err, tt.wantErr) {
+                t.Fatalf("undoTimeEntryChanges() = %d, %v, want %d, %v", undone, err, tt.wantUndone, tt.wantErr)
+            }
+            current, err := getTimeEntry(db, entry.TimeEntryID)
+            switch {
+            case tt.wantMinutes == 0 && !errors.Is(err, errTimeEntryNotFound):
+                t.Errorf("getTimeEntry() = %+v, %v, want the entry gone", current, err)
+            case tt.wantMinutes != 0 && current.Minutes != tt.wantMinutes:
+                t.Errorf("getTimeEntry() = %+v, %v, want %d minutes", current, err, tt.wantMinutes)
+            }
+        })
+    }
+
+    // Petar's undo never touches Tadej's changes
+    if _, err := undoTimeEntryChanges(db, enforcer, 3, 1, day); !errors.Is(err, errNothingToUndo) {
+        t.Errorf("undoTimeEntryChanges() by Petar error = %v, want %v", err, errNothingToUndo)
+    }
+}
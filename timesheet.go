@@ -0,0 +1,464 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+
+// Timesheet states - a week goes draft -> submitted -> approved/rejected -> locked
+const (
+    timesheetDraft      = "draft"
+    timesheetSubmitted  = "submitted"
+    timesheetApproved   = "approved"
+    timesheetRejected   = "rejected"
+    timesheetLocked     = "locked"
+)
+
+// Timesheet actions - submit, approve and reject are also Casbin actions on the "timesheet" object
+const (
+    timesheetObject     = "timesheet"
+    timesheetActSubmit  = "submit"
+    timesheetActApprove = "approve"
+    timesheetActReject  = "reject"
+    timesheetActLock    = "lock"
+)
+
+// timesheetTransitions maps a state and an action to the state the timesheet ends up in
+var timesheetTransitions = map[string]map[string]string{
+    timesheetDraft:     {timesheetActSubmit: timesheetSubmitted},
+    timesheetRejected:  {timesheetActSubmit: timesheetSubmitted},
+    timesheetSubmitted: {timesheetActApprove: timesheetApproved, timesheetActReject: timesheetRejected},
+    timesheetApproved:  {timesheetActLock: timesheetLocked},
+}
+
+var (
+    errTimesheetReadOnly   = errors.New("timesheet is read-only in its current state")
+    errTimesheetTransition = errors.New("timesheet cannot make this transition")
+    errTimesheetDenied     = errors.New("you shall not pass!.. the timesheet")
+    errTimesheetNotFound   = errors.New("timesheet not found")
+    errTimeEntryNotFound   = errors.New("time entry not found")
+    errTimeEntryDenied     = errors.New("you may not change this time entry")
+)
+
+// Timesheet is one user's week of time entries in one organization
+type Timesheet struct {
+    TimesheetID     int
+    OrganizationID  int
+    UserID          int
+    Username        string
+    WeekStart       time.Time
+    State           string
+    ReviewNote      string
+    TotalMinutes    int
+}
+
+// TimeEntry is a single logged amount of time for a client, optionally on a task of one of its projects
+type TimeEntry struct {
+    TimeEntryID  int
+    TimesheetID  int
+    UserID       int
+    ClientName   string
+    ProjectID    int            // zero when logged on the client only
+    TaskID       int
+    EntryDate    time.Time
+    Minutes      int
+}
+
+// Editable tells if the submitter may still add or change entries
+func (t Timesheet) Editable() bool {
+    return t.State == timesheetDraft || t.State == timesheetRejected
+}
+
+
+// weekStart returns the Monday of the week the date falls into
+func weekStart(inDate time.Time) time.Time {
+    offset := (int(inDate.Weekday()) + 6) % 7
+    y, m, d := inDate.AddDate(0, 0, -offset).Date()
+
+    return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// dateKey formats dates the same way everywhere they are written to the DB
+func dateKey(inDate time.Time) string {
+    return inDate.Format("2006-01-02")
+}
+
+// parseTimeSpent accepts "1.5" (hours), "1:30" or Go durations like "1h30m" and returns minutes
+func parseTimeSpent(inText string) (int, error) {
+    inText = strings.TrimSpace(inText)
+
+    if hours, minutes, found := strings.Cut(inText, ":"); found {
+        h, hErr := strconv.Atoi(hours)
+        m, mErr := strconv.Atoi(minutes)
+        if hErr != nil || mErr != nil || h < 0 || m < 0 || m >= 60 {
+            return 0, newError("invalid time spent %q", inText)
+        }
+        return h*60 + m, validMinutes(h*60 + m, inText)
+    }
+
+    if hours, err := strconv.ParseFloat(strings.Replace(inText, ",", ".", 1), 64); err == nil {
+        minutes := int(hours*60 + 0.5)
+        return minutes, validMinutes(minutes, inText)
+    }
+
+    duration, err := time.ParseDuration(inText)
+    if err != nil {
+        return 0, newError("invalid time spent %q", inText)
+    }
+
+    return int(duration.Minutes()), validMinutes(int(duration.Minutes()), inText)
+}
+
+func validMinutes(inMinutes int, inText string) error {
+    if inMinutes <= 0 || inMinutes > 24*60 {
+        return newError("time spent %q must be between 1 minute and 24 hours", inText)
+    }
+    return nil
+}
+
+// formatMinutes shows minutes as hours with two decimals, the way billing reads them
+func formatMinutes(inMinutes int) string {
+    return fmt.Sprintf("%.2fh", float64(inMinutes)/60)
+}
+
+
+// DB functions for timesheets
+func getOrCreateTimesheet(inDB dbRunner, inOrganizationID int, inUserID int, inDate time.Time) (Timesheet, error) {
+    week := dateKey(weekStart(inDate))
+
+    _, err := inDB.Exec("INSERT OR IGNORE INTO timesheet (organization_id, user_id, week_start) VALUES (?, ?, ?)", inOrganizationID, inUserID, week)
+    if err != nil {
+        return Timesheet{}, err
+    }
+
+    var timesheetID int
+    err = inDB.QueryRow("SELECT timesheet_id FROM timesheet WHERE organization_id = ? AND user_id = ? AND week_start = ?", inOrganizationID, inUserID, week).Scan(&timesheetID)
+    if err != nil {
+        return Timesheet{}, err
+    }
+
+    return getTimesheet(inDB, timesheetID)
+}
+
+func getTimesheet(inDB dbRunner, inTimesheetID int) (Timesheet, error) {
+    timesheets, err := queryTimesheets(inDB, "ts.timesheet_id = ?", inTimesheetID)
+    if err != nil {
+        return Timesheet{}, err
+    }
+    if len(timesheets) == 0 {
+        return Timesheet{}, errTimesheetNotFound
+    }
+
+    return timesheets[0], nil
+}
+
+// pendingTimesheets lists the organization's submitted weeks of every user reporting to the manager
+func pendingTimesheets(inDB *sql.DB, inOrganizationID int, inManagerID int) ([]Timesheet, error) {
+    return queryTimesheets(inDB, `
+        ts.state = ?
+    AND ts.organization_id = ?
+    AND ts.user_id IN (SELECT user_id FROM user_manager_map WHERE manager_id = ?)`, timesheetSubmitted, inOrganizationID, inManagerID)
+}
+
+func queryTimesheets(inDB dbRunner, inWhere string, inArgs ...any) ([]Timesheet, error) {
+    timesheetsQuery := fmt.Sprintf(`
+SELECT
+      ts.timesheet_id
+    , ts.organization_id
+    , ts.user_id
+    , COALESCE(ud.username, '')
+    , ts.week_start
+    , ts.state
+    , ts.review_note
+    , COALESCE(SUM(te.minutes_spent), 0)
+FROM
+    timesheet                   AS ts
+    LEFT JOIN user_dim          AS ud
+        ON ud.user_id = ts.user_id
+    LEFT JOIN time_entry        AS te
+        ON te.timesheet_id = ts.timesheet_id
+WHERE
+    %s
+GROUP BY
+    ts.timesheet_id
+ORDER BY
+    ts.week_start, ud.username
+    `, inWhere)
+
+    rows, err := inDB.Query(timesheetsQuery, inArgs...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var timesheets []Timesheet
+    for rows.Next() {
+        var ts Timesheet
+        if err := rows.Scan(&ts.TimesheetID, &ts.OrganizationID, &ts.UserID, &ts.Username, &ts.WeekStart, &ts.State, &ts.ReviewNote, &ts.TotalMinutes); err != nil {
+            return nil, err
+        }
+        timesheets = append(timesheets, ts)
+    }
+
+    return timesheets, rows.Err()
+}
+
+func timesheetEntries(inDB dbRunner, inTimesheetID int) ([]TimeEntry, error) {
+    return queryTimeEntries(inDB, "timesheet_id = ?", inTimesheetID)
+}
+
+func getTimeEntry(inDB dbRunner, inTimeEntryID int) (TimeEntry, error) {
+    entries, err := queryTimeEntries(inDB, "time_entry_id = ?", inTimeEntryID)
+    if err != nil {
+        return TimeEntry{}, err
+    }
+    if len(entries) == 0 {
+        return TimeEntry{}, errTimeEntryNotFound
+    }
+
+    return entries[0], nil
+}
+
+func queryTimeEntries(inDB dbRunner, inWhere string, inArgs ...any) ([]TimeEntry, error) {
+    rows, err := inDB.Query(`
+SELECT
+      time_entry_id
+    , timesheet_id
+    , user_id
+    , client_name
+    , COALESCE(project_id, 0)
+    , COALESCE(task_id, 0)
+    , entry_date
+    , minutes_spent
+FROM
+    time_entry
+WHERE
+    `+inWhere+`
+ORDER BY
+    entry_date, time_entry_id
+    `, inArgs...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var entries []TimeEntry
+    for rows.Next() {
+        var te TimeEntry
+        if err := rows.Scan(&te.TimeEntryID, &te.TimesheetID, &te.UserID, &te.ClientName, &te.ProjectID, &te.TaskID, &te.EntryDate, &te.Minutes); err != nil {
+            return nil, err
+        }
+        entries = append(entries, te)
+    }
+
+    return entries, rows.Err()
+}
+
+// addTimeEntry logs time on the client only into the user's timesheet for the week of inDate, as long as that week is
+// still editable. Budgets belong to projects and count only their own entries, so this time skips the budget checks.
+func addTimeEntry(inDB dbRunner, inOrganizationID int, inUserID int, inClientName string, inDate time.Time, inMinutes int) (TimeEntry, error) {
+    return insertTimeEntry(inDB, inOrganizationID, TimeEntry{UserID: inUserID, ClientName: inClientName, EntryDate: inDate, Minutes: inMinutes})
+}
+
+// insertTimeEntry puts the entry into the organization's timesheet of its week and fills in the IDs
+func insertTimeEntry(inDB dbRunner, inOrganizationID int, inEntry TimeEntry) (TimeEntry, error) {
+    ts, err := getOrCreateTimesheet(inDB, inOrganizationID, inEntry.UserID, inEntry.EntryDate)
+    if err != nil {
+        return TimeEntry{}, err
+    }
+    if !ts.Editable() {
+        return TimeEntry{}, errTimesheetReadOnly
+    }
+
+    entryID, err := nextTimeEntryID(inDB)
+    if err != nil {
+        return TimeEntry{}, err
+    }
+    _, err = inDB.Exec("INSERT INTO time_entry (time_entry_id, timesheet_id, user_id, client_name, project_id, task_id, entry_date, minutes_spent) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+        entryID, ts.TimesheetID, inEntry.UserID, inEntry.ClientName, nullableID(inEntry.ProjectID), nullableID(inEntry.TaskID), dateKey(inEntry.EntryDate), inEntry.Minutes)
+    if err != nil {
+        return TimeEntry{}, err
+    }
+
+    inEntry.TimeEntryID = entryID
+    inEntry.TimesheetID = ts.TimesheetID
+
+    // The owner logs their own time, the history starts with them
+    if _, err := recordTimeEntryVersion(inDB, inEntry.UserID, entryCreated, inEntry); err != nil {
+        return TimeEntry{}, err
+    }
+
+    return inEntry, nil
+}
+
+// canEditTimeEntry asks the time_entry "edit" rules about this entry, their conditions see its owner, date and client
+func canEditTimeEntry(inEnforcer *OrgEnforcer, inActorID int, inEntry TimeEntry, inNow time.Time) (bool, error) {
+    return inEnforcer.EnforceWith(fmt.Sprintf("u%d", inActorID), timeEntryObject, timeEntryActEdit, entryAttributes(inEntry, inNow))
+}
+
+// checkTimeEntryWritable tells if the actor may change the entry - its week has to be editable and the edit rules have to allow it
+func checkTimeEntryWritable(inDB dbRunner, inEnforcer *OrgEnforcer, inActorID int, inEntry TimeEntry, inNow time.Time) error {
+    ts, err := getTimesheet(inDB, inEntry.TimesheetID)
+    if err != nil {
+        return err
+    }
+    // Entries of the other organizations are out of reach, whatever the rules of this one say
+    if ts.OrganizationID != inEnforcer.OrganizationID {
+        return errTimeEntryNotFound
+    }
+    if !ts.Editable() {
+        return errTimesheetReadOnly
+    }
+
+    allowed, err := canEditTimeEntry(inEnforcer, inActorID, inEntry, inNow)
+    if err != nil {
+        return err
+    }
+    if !allowed {
+        logAuditEvent(inDB, AuditEvent{ActorID: inActorID, OrganizationID: inEnforcer.OrganizationID, EventType: auditDenied,
+            Object: fmt.Sprintf("%s %d", timeEntryObject, inEntry.TimeEntryID), After: timeEntryActEdit})
+        return errTimeEntryDenied
+    }
+
+    return nil
+}
+
+// updateTimeEntry changes the time spent of an entry
+func updateTimeEntry(inDB *sql.DB, inEnforcer *OrgEnforcer, inActorID int, inTimeEntryID int, inMinutes int, inNow time.Time) (TimeEntry, error) {
+    var entry TimeEntry
+    err := inTimeEntryTx(inDB, func(inTx *sql.Tx) error {
+        var err error
+        if entry, err = getTimeEntry(inTx, inTimeEntryID); err != nil {
+            return err
+        }
+
+        entry.Minutes = inMinutes
+        _, err = setTimeEntryState(inTx, inEnforcer, inActorID, inTimeEntryID, &entry, entryUpdated, inNow)
+        return err
+    })
+    if err != nil {
+        return TimeEntry{}, err
+    }
+
+    return entry, nil
+}
+
+// deleteTimeEntry removes an entry under the same rules as updateTimeEntry, its history stays
+func deleteTimeEntry(inDB *sql.DB, inEnforcer *OrgEnforcer, inActorID int, inTimeEntryID int, inNow time.Time) error {
+    return inTimeEntryTx(inDB, func(inTx *sql.Tx) error {
+        _, err := setTimeEntryState(inTx, inEnforcer, inActorID, inTimeEntryID, nil, entryDeleted, inNow)
+        return err
+    })
+}
+
+func isManagerOf(inDB *sql.DB, inManagerID int, inUserID int) (bool, error) {
+    var cnt int
+    err := inDB.QueryRow("SELECT COUNT(*) FROM user_manager_map WHERE manager_id = ? AND user_id = ?", inManagerID, inUserID).Scan(&cnt)
+
+    return cnt > 0, err
+}
+
+// transitionTimesheet moves a timesheet through the state machine on behalf of the actor.
+// Submit is only allowed for the owner, approve and reject only for the owner's manager,
+// and all three need the matching Casbin action on the "timesheet" object.
+func transitionTimesheet(inDB *sql.DB, inEnforcer *OrgEnforcer, inActorID int, inTimesheetID int, inAction string, inNote string) (Timesheet, error) {
+    ts, err := getTimesheet(inDB, inTimesheetID)
+    if err != nil {
+        return Timesheet{}, err
+    }
+    // Each organization approves its own weeks
+    if ts.OrganizationID != inEnforcer.OrganizationID {
+        return Timesheet{}, errTimesheetNotFound
+    }
+
+    newState, ok := timesheetTransitions[ts.State][inAction]
+    if !ok {
+        return ts, fmt.Errorf("%w: %w", errTimesheetTransition, newError("the week is %s", catalogText(ts.State)))
+    }
+
+    allowed, err := inEnforcer.Enforce(fmt.Sprintf("u%d", inActorID), timesheetObject, inAction)
+    if err != nil {
+        return ts, err
+    }
+    if !allowed {
+        logAuditEvent(inDB, AuditEvent{ActorID: inActorID, OrganizationID: inEnforcer.OrganizationID, EventType: auditDenied,
+            Object: fmt.Sprintf("%s %d", timesheetObject, ts.TimesheetID), After: inAction})
+        return ts, errTimesheetDenied
+    }
+
+    switch inAction {
+    case timesheetActSubmit:
+        if ts.UserID != inActorID {
+            return ts, errTimesheetDenied
+        }
+    case timesheetActApprove, timesheetActReject:
+        isManager, err := isManagerOf(inDB, inActorID, ts.UserID)
+        if err != nil {
+            return ts, err
+        }
+        if !isManager || ts.UserID == inActorID {
+            return ts, errTimesheetDenied
+        }
+    default:
+        // Locking is done by invoicing, not by people clicking around
+        return ts, errTimesheetDenied
+    }
+
+    oldState := ts.State
+    if err := setTimesheetState(inDB, &ts, inActorID, newState, inNote); err != nil {
+        return ts, err
+    }
+
+    // Approvals and rejections with the manager's note
+    after := ts.State
+    if inNote != "" {
+        after += ": " + inNote
+    }
+    logAuditEvent(inDB, AuditEvent{ActorID: inActorID, OrganizationID: inEnforcer.OrganizationID, EventType: auditTimesheet,
+        Object: fmt.Sprintf("%s %d", timesheetObject, ts.TimesheetID), Before: oldState, After: after})
+
+    return ts, nil
+}
+
+// lockTimesheet makes an approved week permanently read-only, e.g. once it has been billed
+func lockTimesheet(inDB *sql.DB, inTimesheetID int) error {
+    ts, err := getTimesheet(inDB, inTimesheetID)
+    if err != nil {
+        return err
+    }
+    if _, ok := timesheetTransitions[ts.State][timesheetActLock]; !ok {
+        return fmt.Errorf("%w: %w", errTimesheetTransition, newError("the week is %s", catalogText(ts.State)))
+    }
+
+    _, err = inDB.Exec("UPDATE timesheet SET state = ? WHERE timesheet_id = ? AND state = ?", timesheetLocked, inTimesheetID, ts.State)
+
+    return err
+}
+
+func setTimesheetState(inDB *sql.DB, inTimesheet *Timesheet, inActorID int, inNewState string, inNote string) error {
+    var reviewer any
+
+    if inNewState != timesheetSubmitted {
+        reviewer = inActorID
+    }
+
+    // The state in the WHERE clause makes sure two managers can't act on the same week at once
+    result, err := inDB.Exec("UPDATE timesheet SET state = ?, reviewer_id = ?, review_note = ? WHERE timesheet_id = ? AND state = ?",
+        inNewState, reviewer, inNote, inTimesheet.TimesheetID, inTimesheet.State)
+    if err != nil {
+        return err
+    }
+    if changed, _ := result.RowsAffected(); changed == 0 {
+        return fmt.Errorf("%w: %w", errTimesheetTransition, newError("it was changed in the meantime"))
+    }
+
+    inTimesheet.State      = inNewState
+    inTimesheet.ReviewNote = inNote
+
+    return nil
+}
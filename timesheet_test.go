@@ -0,0 +1,194 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "github.com/casbin/casbin/v2"
+    "io"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+// openTestDb copies the showcase DB with its test data into a temp dir, so tests can write freely
+func openTestDb(t *testing.T) (*sql.DB, string) {
+    t.Helper()
+
+    src, err := os.Open("data/database/showcase_db")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer src.Close()
+
+    dbPath := filepath.Join(t.TempDir(), "showcase_db")
+    dst, err := os.Create(dbPath)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := io.Copy(dst, src); err != nil {
+        t.Fatal(err)
+    }
+    dst.Close()
+
+    db, err := sql.Open("sqlite3", dbPath)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { db.Close() })
+
+    return db, dbPath
+}
+
+func openTestEnforcer(t *testing.T, inDbPath string) *OrgEnforcer {
+    t.Helper()
+
+    return openTestOrgEnforcer(t, inDbPath, 1)
+}
+
+func openTestOrgEnforcer(t *testing.T, inDbPath string, inOrganizationID int) *OrgEnforcer {
+    t.Helper()
+
+    adapter, err := NewCustomAdapter(inDbPath)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { adapter.Close() })
+
+    enforcer, err := casbin.NewSyncedEnforcer("data/steaby_casbin_model.conf", adapter)
+    if err != nil {
+        t.Fatal(err)
+    }
+    registerConditionFunctions(enforcer)
+
+    return &OrgEnforcer{SyncedEnforcer: enforcer, OrganizationID: inOrganizationID}
+}
+
+func Test_weekStart(t *testing.T) {
+    tests := []struct {
+        name string
+        date time.Time
+        want string
+    }{
+        {"monday",  time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC), "2026-10-19"},
+        {"sunday",  time.Date(2026, 10, 25, 23, 0, 0, 0, time.UTC), "2026-10-19"},
+        {"new year", time.Date(2027, 1, 1, 8, 0, 0, 0, time.UTC),   "2026-12-28"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := dateKey(weekStart(tt.date)); got != tt.want {
+                t.Errorf("weekStart() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func Test_parseTimeSpent(t *testing.T) {
+    tests := []struct {
+        name    string
+        text    string
+        want    int
+        wantErr bool
+    }{
+        {"decimal hours",  "1.5",    90, false},
+        {"decimal comma",  "0,25",   15, false},
+        {"clock",          "2:05",  125, false},
+        {"duration",       "1h30m",  90, false},
+        {"zero",           "0",       0, true},
+        {"too much",       "25",   1500, true},
+        {"garbage",        "soon",    0, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := parseTimeSpent(tt.text)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("parseTimeSpent() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if !tt.wantErr && got != tt.want {
+                t.Errorf("parseTimeSpent() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func Test_timesheetWorkflow(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer    := openTestEnforcer(t, dbPath)
+    day         := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    // Tadej (minion) logs time, Ray (admin) manages him
+    entry, err := addTimeEntry(db, 1, 2, "ACME", day, 90)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    steps := []struct {
+        name      string
+        actorID   int
+        action    string
+        wantState string
+        wantErr   error
+    }{
+        {"admin cannot approve a draft",   1, timesheetActApprove, timesheetDraft,     errTimesheetTransition},
+        {"other minion cannot submit",     3, timesheetActSubmit,  timesheetDraft,     errTimesheetDenied},
+        {"owner submits",                  2, timesheetActSubmit,  timesheetSubmitted, nil},
+        {"owner cannot approve himself",   2, timesheetActApprove, timesheetSubmitted, errTimesheetDenied},
+        {"manager rejects",                1, timesheetActReject,  timesheetRejected,  nil},
+        {"owner resubmits",                2, timesheetActSubmit,  timesheetSubmitted, nil},
+        {"manager approves",               1, timesheetActApprove, timesheetApproved,  nil},
+        {"nobody locks by hand",           1, timesheetActLock,    timesheetApproved,  errTimesheetDenied},
+    }
+    for _, step := range steps {
+        t.Run(step.name, func(t *testing.T) {
+            _, err := transitionTimesheet(db, enforcer, step.actorID, entry.TimesheetID, step.action, "")
+            if !errors.Is(err, step.wantErr) {
+                t.Fatalf("transitionTimesheet() error = %v, want %v", err, step.wantErr)
+            }
+            ts, _ := getTimesheet(db, entry.TimesheetID)
+            if ts.State != step.wantState {
+                t.Errorf("state = %v, want %v", ts.State, step.wantState)
+            }
+        })
+    }
+
+    // Approved weeks are read-only for the submitter
+    if _, err := addTimeEntry(db, 1, 2, "ACME", day, 30); !errors.Is(err, errTimesheetReadOnly) {
+        t.Errorf("addTimeEntry() on approved week error = %v, want %v", err, errTimesheetReadOnly)
+    }
+
+    if err := lockTimesheet(db, entry.TimesheetID); err != nil {
+        t.Fatal(err)
+    }
+    ts, _ := getTimesheet(db, entry.TimesheetID)
+    if ts.State != timesheetLocked || ts.TotalMinutes != 90 {
+        t.Errorf("locked timesheet = %+v", ts)
+    }
+}
+
+func Test_pendingTimesheets(t *testing.T) {
+    db, dbPath := openTestDb(t)
+    enforcer    := openTestEnforcer(t, dbPath)
+    day         := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+    for _, userID := range []int{2, 3} {
+        entry, err := addTimeEntry(db, 1, userID, "ACME", day, 60)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if _, err := transitionTimesheet(db, enforcer, userID, entry.TimesheetID, timesheetActSubmit, ""); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    pending, err := pendingTimesheets(db, 1, 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(pending) != 2 || pending[0].Username != "Petar" || pending[1].Username != "Tadej" {
+        t.Errorf("pendingTimesheets() = %+v", pending)
+    }
+
+    if pending, _ := pendingTimesheets(db, 1, 2); len(pending) != 0 {
+        t.Errorf("minion sees pending timesheets: %+v", pending)
+    }
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+    "database/sql"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "showcase_desktop/widgets"
+)
+
+
+// timesheetRow keeps the buttons of one pending timesheet between frames
+type timesheetRow struct {
+    timesheet   Timesheet
+    approveBtn  widget.Clickable
+    rejectBtn   widget.Clickable
+}
+
+
+// runTimesheetApproval shows the manager every submitted week of their reports
+func runTimesheetApproval(inWindow *app.Window, inUserID int, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var noteTextbox         widget.Editor
+    var pendingList         widget.List
+    var rows                []*timesheetRow
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    titleText               := tr().Text("Timesheets waiting for approval")
+    pendingList.Axis         = layout.Vertical
+
+    refreshRows := func() {
+        pending, err := pendingTimesheets(inS3db, inEnforcer.OrganizationID, inUserID)
+        if err != nil {
+            log.Print(err)
+            statusMsg = tr().Text("Could not load pending timesheets")
+            return
+        }
+
+        rows = rows[:0]
+        for _, ts := range pending {
+            rows = append(rows, &timesheetRow{timesheet: ts})
+        }
+        // The result of the last approval or rejection stays in view, also when it emptied the list
+        if len(rows) == 0 && statusMsg == "" {
+            statusMsg = tr().Text("Nothing to approve, go get a coffee")
+        }
+    }
+    refreshRows()
+
+    inWindow.Option(app.Title(tr().Text("Approvals")))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            // Handle approve and reject clicks before drawing, so the list is already refreshed
+            for _, row := range rows {
+                action := ""
+                if row.approveBtn.Clicked(gtx) {
+                    action = timesheetActApprove
+                }
+                if row.rejectBtn.Clicked(gtx) {
+                    action = timesheetActReject
+                }
+                if action == "" {
+                    continue
+                }
+
+                ts, err := transitionTimesheet(inS3db, inEnforcer, inUserID, row.timesheet.TimesheetID, action, noteTextbox.Text())
+                if err != nil {
+                    statusMsg = tr().Sprintf("Could not approve %s's week: %s", row.timesheet.Username, errorText(err))
+                    if action == timesheetActReject {
+                        statusMsg = tr().Sprintf("Could not reject %s's week: %s", row.timesheet.Username, errorText(err))
+                    }
+                } else {
+                    statusMsg = tr().Sprintf("%s's week of %s is now %s", ts.Username, tr().Date(ts.WeekStart), timesheetStateText(ts.State))
+                    noteTextbox.SetText("")
+                }
+                refreshRows()
+                break
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, titleText, widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                // Result of the last action
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+                // Note that goes along with the next approval or rejection
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.InputBox(theme, &noteTextbox, tr().Text("Note for the submitter")).Layout(gtx)
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+                // Pending timesheets
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &pendingList).Layout(gtx, len(rows), func(gtx layout.Context, index int) layout.Dimensions {
+                        return timesheetRowElement(gtx, theme, rows[index])
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
+
+
+func timesheetRowElement(inGTX layout.Context, inTheme *widgets.Theme, inRow *timesheetRow) layout.Dimensions {
+    rowText := tr().Sprintf("%s - week of %s - %s", inRow.timesheet.Username, tr().Date(inRow.timesheet.WeekStart), tr().Duration(inRow.timesheet.TotalMinutes))
+
+    return layout.UniformInset(unit.Dp(5)).Layout(inGTX, func(gtx layout.Context) layout.Dimensions {
+        return layout.Flex{
+            Axis:      layout.Horizontal,
+            Alignment: layout.Middle,
+        }.Layout(gtx,
+            // Who and which week
+            layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                return material.Body1(inTheme.Theme, rowText).Layout(gtx)
+            }),
+            // Row buttons sit next to each other, so they can't use the centered btnElement
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                return material.Button(inTheme.Theme, &inRow.approveBtn, tr().Text("Approve")).Layout(gtx)
+            }),
+            layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                return material.Button(inTheme.Theme, &inRow.rejectBtn, tr().Text("Reject")).Layout(gtx)
+            }),
+        )
+    })
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "sync"
+)
+
+
+// Keys of the settings a user picks for themselves
+const (
+    settingTheme    = "theme"
+    settingLanguage = "language"
+)
+
+// settingListeners are told when the theme or the language was switched, windows register their Invalidate
+var settingListeners = struct {
+    sync.Mutex
+    byID        map[int]func()
+    nextID      int
+}{byID: map[int]func(){}}
+
+
+// getUserSetting returns the user's value for the key, or inDefault when they never picked one
+func getUserSetting(inDB dbRunner, inUserID int, inKey string, inDefault string) (string, error) {
+    var value string
+    err := inDB.QueryRow(`
+SELECT
+    setting_value
+FROM
+    user_setting
+WHERE
+        user_id     = ?
+    AND setting_key = ?
+`, inUserID, inKey).Scan(&value)
+
+    if errors.Is(err, sql.ErrNoRows) {
+        return inDefault, nil
+    }
+    if err != nil {
+        return "", err
+    }
+    return value, nil
+}
+
+// setUserSetting stores the user's value for the key, replacing the one they picked before
+func setUserSetting(inDB dbRunner, inUserID int, inKey string, inValue string) error {
+    _, err := inDB.Exec(`
+INSERT INTO user_setting (user_id, setting_key, setting_value)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id, setting_key) DO UPDATE SET setting_value = excluded.setting_value
+`, inUserID, inKey, inValue)
+    return err
+}
+
+
+// onSettingChange calls inListener after every switch of the theme or the language until the returned cancel is called
+func onSettingChange(inListener func()) (cancel func()) {
+    settingListeners.Lock()
+    defer settingListeners.Unlock()
+
+    id := settingListeners.nextID
+    settingListeners.nextID++
+    settingListeners.byID[id] = inListener
+
+    return func() {
+        settingListeners.Lock()
+        defer settingListeners.Unlock()
+        delete(settingListeners.byID, id)
+    }
+}
+
+// notifySettingChange calls the listeners, outside the lock so they may register or cancel
+func notifySettingChange() {
+    settingListeners.Lock()
+    listeners := make([]func(), 0, len(settingListeners.byID))
+    for _, listener := range settingListeners.byID {
+        listeners = append(listeners, listener)
+    }
+    settingListeners.Unlock()
+
+    for _, listener := range listeners {
+        listener()
+    }
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+    "showcase_desktop/widgets"
+    "testing"
+)
+
+func Test_userSetting(t *testing.T) {
+    db, _ := openTestDb(t)
+
+    // Nothing picked yet
+    if got, err := getUserSetting(db, 2, settingTheme, widgets.ThemeLight); err != nil || got != widgets.ThemeLight {
+        t.Errorf("getUserSetting() = %q, %v, want the default", got, err)
+    }
+
+    // The second pick replaces the first, other users keep theirs
+    for _, value := range []string{widgets.ThemeDark, widgets.ThemeHighContrast} {
+        if err := setUserSetting(db, 2, settingTheme, value); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if got, err := getUserSetting(db, 2, settingTheme, widgets.ThemeLight); err != nil || got != widgets.ThemeHighContrast {
+        t.Errorf("getUserSetting() = %q, %v, want %q", got, err, widgets.ThemeHighContrast)
+    }
+    if got, err := getUserSetting(db, 3, settingTheme, widgets.ThemeLight); err != nil || got != widgets.ThemeLight {
+        t.Errorf("getUserSetting() of another user = %q, %v, want the default", got, err)
+    }
+}
+
+func Test_setTheme(t *testing.T) {
+    t.Cleanup(func() { setTheme(widgets.ThemeLight) })
+
+    var redraws int
+    cancel := onSettingChange(func() { redraws++ })
+
+    var window windowTheme
+    setTheme(widgets.ThemeDark)
+    if got := window.current(); got.Name != widgets.ThemeDark || got.Bg != widgets.Dark().Bg || redraws != 1 {
+        t.Errorf("after switching to dark: theme %q, %d redraws", got.Name, redraws)
+    }
+
+    // Unknown names fall back to light, listeners that were cancelled are not told
+    cancel()
+    setTheme("sepia")
+    if got := window.current(); got.Name != widgets.ThemeLight || redraws != 1 {
+        t.Errorf("after switching to an unknown theme: theme %q, %d redraws", got.Name, redraws)
+    }
+}
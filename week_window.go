@@ -0,0 +1,309 @@
+package main
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "gioui.org/app"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "log"
+    "showcase_desktop/widgets"
+    "strconv"
+    "strings"
+    "time"
+)
+
+
+// entryRow keeps the editor and buttons of one time entry between frames
+type entryRow struct {
+    entry       TimeEntry
+    canEdit     bool
+    hoursEditor widget.Editor
+    saveBtn     widget.Clickable
+    deleteBtn   widget.Clickable
+}
+
+// versionRow keeps the restore button of one version in the history panel
+type versionRow struct {
+    version     TimeEntryVersion
+    diffText    string
+    canRestore  bool
+    restoreBtn  widget.Clickable
+}
+
+
+// runMyWeek lists the user's entries of a week and lets them change the ones the edit rules allow.
+// The history panel under them shows every change of the week, with undo and restore while the week is editable.
+func runMyWeek(inWindow *app.Window, inUserID int, inS3db *sql.DB, inEnforcer *OrgEnforcer) error {
+    var ops                 op.Ops
+    var previousBtn         widget.Clickable
+    var nextBtn             widget.Clickable
+    var undoCountTextbox    widget.Editor
+    var undoBtn             widget.Clickable
+    var entryList           widget.List
+    var historyList         widget.List
+    var rows                []*entryRow
+    var historyRows         []*versionRow
+    var statusMsg           string
+
+    var ownTheme            windowTheme
+
+    week                    := weekStart(time.Now())
+    entryList.Axis           = layout.Vertical
+    historyList.Axis         = layout.Vertical
+    undoCountTextbox.SingleLine = true
+
+    refreshRows := func() {
+        rows        = rows[:0]
+        historyRows = historyRows[:0]
+
+        ts, err := getOrCreateTimesheet(inS3db, inEnforcer.OrganizationID, inUserID, week)
+        var entries []TimeEntry
+        if err == nil {
+            entries, err = timesheetEntries(inS3db, ts.TimesheetID)
+        }
+        if err != nil {
+            log.Print(err)
+            statusMsg = tr().Text("Could not load the week")
+            return
+        }
+
+        // Conditions decide per entry, e.g. only the last 7 days
+        for _, entry := range entries {
+            row           := &entryRow{entry: entry}
+            canEdit, err  := canEditTimeEntry(inEnforcer, inUserID, entry, time.Now())
+            if err != nil {
+                log.Print(err)
+            }
+            row.canEdit = canEdit && ts.Editable()
+            row.hoursEditor.SingleLine = true
+            row.hoursEditor.SetText(fmt.Sprintf("%.2f", float64(entry.Minutes)/60))
+            rows = append(rows, row)
+        }
+
+        // Newest change on top, each compared with the entry's version before it
+        versions, err := timesheetHistory(inS3db, ts.TimesheetID)
+        if err != nil {
+            log.Print(err)
+        }
+        previous := map[int]*TimeEntryVersion{}
+        for i := range versions {
+            version := versions[i]
+            row     := &versionRow{version: version, canRestore: ts.Editable() && version.Change != entryDeleted}
+            row.diffText = strings.Join(version.Diff(previous[version.Entry.TimeEntryID]), ", ")
+            previous[version.Entry.TimeEntryID] = &versions[i]
+            historyRows = append([]*versionRow{row}, historyRows...)
+        }
+        statusMsg = tr().Plural(len(entries), "Week of %[2]s is %[3]s, %[1]d entry", "Week of %[2]s is %[3]s, %[1]d entries", len(entries), tr().Date(week), timesheetStateText(ts.State))
+    }
+    refreshRows()
+
+    inWindow.Option(app.Title(tr().Text("My week")), app.Size(unit.Dp(1000), unit.Dp(800)))
+
+    // Redraw when the user switches the theme or the language in the settings
+    defer onSettingChange(inWindow.Invalidate)()
+
+    for {
+        event := inWindow.Event()
+
+        switch eventType := event.(type) {
+        // This one triggers when the window is closed
+        case app.DestroyEvent:
+            return eventType.Err
+        // FrameEvent runs before the window is presented on screen
+        case app.FrameEvent:
+            gtx   := app.NewContext(&ops, eventType)
+            theme := ownTheme.current()
+
+            if previousBtn.Clicked(gtx) {
+                week = week.AddDate(0, 0, -7)
+                refreshRows()
+            }
+            if nextBtn.Clicked(gtx) {
+                week = week.AddDate(0, 0, 7)
+                refreshRows()
+            }
+
+            for _, row := range rows {
+                var err error
+                var doneMsg string
+                switch {
+                case row.saveBtn.Clicked(gtx):
+                    var minutes int
+                    if minutes, err = parseTimeSpent(row.hoursEditor.Text()); err == nil {
+                        _, err = updateTimeEntry(inS3db, inEnforcer, inUserID, row.entry.TimeEntryID, minutes, time.Now())
+                    }
+                    doneMsg = tr().Sprintf("Changed the entry of %s", tr().Date(row.entry.EntryDate))
+                case row.deleteBtn.Clicked(gtx):
+                    err     = deleteTimeEntry(inS3db, inEnforcer, inUserID, row.entry.TimeEntryID, time.Now())
+                    doneMsg = tr().Sprintf("Deleted the entry of %s", tr().Date(row.entry.EntryDate))
+                default:
+                    continue
+                }
+
+                refreshRows()
+                if err != nil {
+                    statusMsg = errorText(err)
+                } else {
+                    statusMsg = doneMsg
+                }
+                break
+            }
+
+            // Undo reaches the user's own changes in every week, newest first
+            if undoBtn.Clicked(gtx) {
+                var err error
+                count := defaultUndoCount
+                if text := strings.TrimSpace(undoCountTextbox.Text()); text != "" {
+                    count, err = strconv.Atoi(text)
+                    if err != nil || count < 1 {
+                        err = errors.New(tr().Sprintf("undo needs a number of changes, not %q", text))
+                    }
+                }
+                var undone int
+                if err == nil {
+                    undone, err = undoTimeEntryChanges(inS3db, inEnforcer, inUserID, count, time.Now())
+                }
+                refreshRows()
+                switch {
+                case err != nil && undone > 0:
+                    statusMsg = tr().Plural(undone, "Undid %d change, then: %s", "Undid %d changes, then: %s", undone, errorText(err))
+                case err != nil:
+                    statusMsg = errorText(err)
+                default:
+                    statusMsg = tr().Plural(undone, "Undid your last %d change", "Undid your last %d changes", undone)
+                }
+            }
+
+            for _, row := range historyRows {
+                if !row.restoreBtn.Clicked(gtx) {
+                    continue
+                }
+                _, err := restoreTimeEntryVersion(inS3db, inEnforcer, inUserID, row.version.VersionID, time.Now())
+                refreshRows()
+                if err != nil {
+                    statusMsg = errorText(err)
+                } else {
+                    statusMsg = tr().Sprintf("Restored v%d of the entry of %s", row.version.Version, tr().Date(row.version.Entry.EntryDate))
+                }
+                break
+            }
+
+            layout.Flex{
+                Axis: layout.Vertical,
+            }.Layout(gtx,
+                // Title on top
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, tr().Text("My week"), widgets.TitleSmall, theme.Title).Layout(gtx)
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.ReportBox(theme, statusMsg, theme.Success).Layout(gtx)
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        material.Button(theme.Theme, &previousBtn, tr().Text("Previous week")).Layout,
+                        material.Button(theme.Theme, &nextBtn, tr().Text("Next week")).Layout,
+                    )
+                }),
+
+                // Empty spacer
+                layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+
+                // The week's entries
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &entryList).Layout(gtx, len(rows), func(gtx layout.Context, index int) layout.Dimensions {
+                        return entryRowElement(gtx, theme, rows[index])
+                    })
+                }),
+
+                // History panel
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return widgets.Title(theme, tr().Text("History"), widgets.TitleSmall, theme.Subtitle).Layout(gtx)
+                }),
+
+                layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                    return formRowElement(gtx,
+                        func(gtx layout.Context) layout.Dimensions {
+                            return widgets.InputBox(theme, &undoCountTextbox, tr().Sprintf("Changes to undo, default %d", defaultUndoCount)).Layout(gtx)
+                        },
+                        material.Button(theme.Theme, &undoBtn, tr().Text("Undo my last changes")).Layout,
+                    )
+                }),
+
+                layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                    return material.List(theme.Theme, &historyList).Layout(gtx, len(historyRows), func(gtx layout.Context, index int) layout.Dimensions {
+                        return versionRowElement(gtx, theme, historyRows[index])
+                    })
+                }),
+            )
+
+            // Pass the drawing operations to the GPU
+            eventType.Frame(gtx.Ops)
+        }
+    }
+}
+
+
+// entryRowElement draws an entry, greyed out when the edit rules don't allow changing it
+func entryRowElement(inGTX layout.Context, inTheme *widgets.Theme, inRow *entryRow) layout.Dimensions {
+    rowText := fmt.Sprintf("%s - %s", tr().Date(inRow.entry.EntryDate), inRow.entry.ClientName)
+    if !inRow.canEdit {
+        inGTX = inGTX.Disabled()
+    }
+    inRow.hoursEditor.ReadOnly = !inRow.canEdit
+
+    return layout.UniformInset(unit.Dp(5)).Layout(inGTX, func(gtx layout.Context) layout.Dimensions {
+        return layout.Flex{
+            Axis:      layout.Horizontal,
+            Alignment: layout.Middle,
+        }.Layout(gtx,
+            layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                return material.Body1(inTheme.Theme, rowText).Layout(gtx)
+            }),
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                gtx.Constraints.Max.X = gtx.Dp(100)
+                return material.Editor(inTheme.Theme, &inRow.hoursEditor, tr().Text("Hours")).Layout(gtx)
+            }),
+            layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                return material.Button(inTheme.Theme, &inRow.saveBtn, tr().Text("Save")).Layout(gtx)
+            }),
+            layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                return material.Button(inTheme.Theme, &inRow.deleteBtn, tr().Text("Delete")).Layout(gtx)
+            }),
+        )
+    })
+}
+
+
+// versionRowElement draws one change of the history panel with what it changed
+func versionRowElement(inGTX layout.Context, inTheme *widgets.Theme, inRow *versionRow) layout.Dimensions {
+    rowText := inRow.version.Text()
+    if inRow.diffText != "" {
+        rowText += " - " + inRow.diffText
+    }
+
+    return layout.UniformInset(unit.Dp(3)).Layout(inGTX, func(gtx layout.Context) layout.Dimensions {
+        return layout.Flex{
+            Axis:      layout.Horizontal,
+            Alignment: layout.Middle,
+        }.Layout(gtx,
+            layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+                return material.Body2(inTheme.Theme, rowText).Layout(gtx)
+            }),
+            layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+                if !inRow.canRestore {
+                    gtx = gtx.Disabled()
+                }
+                return material.Button(inTheme.Theme, &inRow.restoreBtn, tr().Text("Restore")).Layout(gtx)
+            }),
+        )
+    })
+}
@@ -0,0 +1,104 @@
+package widgets_test
+
+import (
+    "fmt"
+    "gioui.org/font"
+    "gioui.org/io/input"
+    "gioui.org/layout"
+    "gioui.org/op"
+    "gioui.org/text"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "image"
+    "image/color"
+    "showcase_desktop/widgets"
+)
+
+
+// newContext stands in for the context a window's FrameEvent gives
+func newContext(inSize image.Point) layout.Context {
+    var router input.Router
+    return layout.Context{
+        Ops:            new(op.Ops),
+        Source:         router.Source(),
+        Metric:         unit.Metric{PxPerDp: 1, PxPerSp: 1},
+        Constraints:    layout.Exact(inSize),
+    }
+}
+
+// A sign in form, laid out the way the app's windows are
+func Example() {
+    var usernameTextbox widget.Editor
+    var signInBtn       widget.Clickable
+
+    theme := widgets.Light()
+    gtx   := newContext(image.Pt(800, 600))
+
+    layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+        layout.Rigid(widgets.Title(theme, "Very Simple-teab app", widgets.TitleLarge, theme.Title).Layout),
+        layout.Rigid(widgets.ErrorBox(theme, "Please enter a username and a password").Layout),
+        layout.Rigid(widgets.InputBox(theme, &usernameTextbox, "Enter username").Layout),
+        layout.Rigid(widgets.Button(theme, &signInBtn, "Sign In").Layout),
+    )
+}
+
+func ExampleTitle() {
+    theme := widgets.Light()
+    gtx   := newContext(image.Pt(800, 100))
+
+    // Section titles are the small ones, with some air around them
+    title      := widgets.Title(theme, "History", widgets.TitleSmall, theme.Subtitle)
+    title.Inset = layout.UniformInset(unit.Dp(8))
+    title.Layout(gtx)
+}
+
+func ExampleReportBox() {
+    theme := widgets.Light()
+    gtx   := newContext(image.Pt(800, 100))
+
+    // A status that keeps to the left of a 200dp column instead of the middle of the window
+    status          := widgets.ReportBox(theme, "3 events, the chain is intact", theme.Success)
+    status.Width     = unit.Dp(200)
+    status.Alignment = text.Start
+    dims            := status.Layout(gtx)
+
+    fmt.Println(dims.Size.X)
+    // Output: 200
+}
+
+func ExampleInputBox() {
+    var rateTextbox widget.Editor
+
+    theme := widgets.Light()
+    gtx   := newContext(image.Pt(800, 100))
+
+    // A narrower box with the theme's regular font and a darker border
+    box             := widgets.InputBox(theme, &rateTextbox, "Hourly rate, e.g. 60.00")
+    box.Width        = unit.Dp(150)
+    box.Font         = font.Font{}
+    box.Border.Color = color.NRGBA{R: 90, G: 90, B: 90, A: 255}
+    box.Layout(gtx)
+}
+
+func ExampleButton() {
+    var exportBtn widget.Clickable
+
+    theme := widgets.Light()
+    gtx   := newContext(image.Pt(800, 100))
+
+    // Clicks are read before the frame is laid out, as in the windows
+    if exportBtn.Clicked(gtx) {
+        fmt.Println("export")
+    }
+
+    button           := widgets.Button(theme, &exportBtn, "Export CSV")
+    button.Background = color.NRGBA{R: 0, G: 120, B: 60, A: 255}
+    button.Layout(gtx)
+}
+
+func ExampleNewTheme() {
+    // Presets are looked up by the name a user setting keeps, names it doesn't know get the light one
+    theme := widgets.NewTheme(widgets.ThemeHighContrast)
+    fmt.Println(theme.Name, widgets.NewTheme("sepia").Name)
+    // Output: high_contrast light
+}
@@ -0,0 +1,106 @@
+package widgets
+
+import (
+    "gioui.org/widget/material"
+    "image/color"
+)
+
+
+// Theme is a material theme with the colors the app gives a meaning to. Windows take these tokens instead of
+// color literals, so a preset changes every window the same way.
+type Theme struct {
+    *material.Theme
+    Name        string
+    Title       color.NRGBA     // window and section titles
+    Subtitle    color.NRGBA     // secondary headings and informational lines
+    Error       color.NRGBA     // errors and alerts
+    Success     color.NRGBA     // statuses and results
+    Border      color.NRGBA     // input box borders
+    Muted       color.NRGBA     // rows that are shown but won't be acted on
+    Tooltip     color.NRGBA     // tooltip background
+    TooltipText color.NRGBA
+}
+
+// Names of the presets, as they are stored in the user settings
+const (
+    ThemeLight          = "light"
+    ThemeDark           = "dark"
+    ThemeHighContrast   = "high_contrast"
+)
+
+// ThemeNames lists the presets in the order a settings screen offers them
+var ThemeNames = []string{ThemeLight, ThemeDark, ThemeHighContrast}
+
+// NewTheme returns the preset called inName, and the light one for a name it doesn't know
+func NewTheme(inName string) *Theme {
+    switch inName {
+    case ThemeDark:
+        return Dark()
+    case ThemeHighContrast:
+        return HighContrast()
+    default:
+        return Light()
+    }
+}
+
+// Light is the app's original look, dark text on white
+func Light() *Theme {
+    return &Theme{
+        Theme:          material.NewTheme(),
+        Name:           ThemeLight,
+        Title:          color.NRGBA{R: 127, G: 0,   B: 0,   A: 255},
+        Subtitle:       color.NRGBA{R: 12,  G: 13,  B: 114, A: 240},
+        Error:          color.NRGBA{R: 200, G: 0,   B: 0,   A: 192},
+        Success:        color.NRGBA{R: 127, G: 152, B: 0,   A: 160},
+        Border:         color.NRGBA{R: 204, G: 204, B: 204, A: 255},
+        Muted:          color.NRGBA{R: 120, G: 120, B: 120, A: 255},
+        Tooltip:        color.NRGBA{R: 50,  G: 50,  B: 50,  A: 230},
+        TooltipText:    color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+    }
+}
+
+// Dark is light text on a dark grey, with the accents lightened to keep their contrast
+func Dark() *Theme {
+    theme := &Theme{
+        Theme:          material.NewTheme(),
+        Name:           ThemeDark,
+        Title:          color.NRGBA{R: 255, G: 183, B: 130, A: 255},
+        Subtitle:       color.NRGBA{R: 174, G: 203, B: 250, A: 255},
+        Error:          color.NRGBA{R: 255, G: 99,  B: 99,  A: 255},
+        Success:        color.NRGBA{R: 180, G: 210, B: 80,  A: 230},
+        Border:         color.NRGBA{R: 95,  G: 99,  B: 104, A: 255},
+        Muted:          color.NRGBA{R: 154, G: 160, B: 166, A: 255},
+        Tooltip:        color.NRGBA{R: 232, G: 234, B: 237, A: 240},
+        TooltipText:    color.NRGBA{R: 32,  G: 33,  B: 36,  A: 255},
+    }
+    theme.Palette = material.Palette{
+        Bg:         color.NRGBA{R: 32,  G: 33,  B: 36,  A: 255},
+        Fg:         color.NRGBA{R: 232, G: 234, B: 237, A: 255},
+        ContrastBg: color.NRGBA{R: 138, G: 180, B: 248, A: 255},
+        ContrastFg: color.NRGBA{R: 32,  G: 33,  B: 36,  A: 255},
+    }
+    return theme
+}
+
+// HighContrast is white and yellow on black, without transparent colors
+func HighContrast() *Theme {
+    theme := &Theme{
+        Theme:          material.NewTheme(),
+        Name:           ThemeHighContrast,
+        Title:          color.NRGBA{R: 255, G: 255, B: 0,   A: 255},
+        Subtitle:       color.NRGBA{R: 0,   G: 255, B: 255, A: 255},
+        Error:          color.NRGBA{R: 255, G: 96,  B: 96,  A: 255},
+        Success:        color.NRGBA{R: 0,   G: 255, B: 0,   A: 255},
+        Border:         color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+        Muted:          color.NRGBA{R: 200, G: 200, B: 200, A: 255},
+        Tooltip:        color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+        TooltipText:    color.NRGBA{R: 0,   G: 0,   B: 0,   A: 255},
+    }
+    theme.Palette = material.Palette{
+        Bg:         color.NRGBA{R: 0,   G: 0,   B: 0,   A: 255},
+        Fg:         color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+        ContrastBg: color.NRGBA{R: 255, G: 255, B: 0,   A: 255},
+        ContrastFg: color.NRGBA{R: 0,   G: 0,   B: 0,   A: 255},
+    }
+    return theme
+}
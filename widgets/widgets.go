@@ -0,0 +1,192 @@
+// Package widgets holds the elements every window of the app is built from - titles, report and error lines,
+// input boxes and buttons - so other tools can have the same look.
+//
+// Each element is made like a material one: a function returns its style with the app's defaults filled in, the
+// style's Options can be changed before it is laid out. Colors come from a Theme, pick one of its presets or fill
+// one in.
+//
+//     title      := widgets.Title(theme, "Audit log", widgets.TitleSmall, theme.Title)
+//     title.Inset = layout.UniformInset(unit.Dp(8))
+//     return title.Layout(gtx)
+package widgets
+
+import (
+    "gioui.org/font"
+    "gioui.org/layout"
+    "gioui.org/text"
+    "gioui.org/unit"
+    "gioui.org/widget"
+    "gioui.org/widget/material"
+    "image/color"
+)
+
+
+// Options are what the elements let a caller change. The constructors fill them with the app's look.
+type Options struct {
+    Width       unit.Dp             // fixed width of the element, zero takes the width it is given
+    Color       color.NRGBA         // text color
+    Alignment   text.Alignment
+    Font        font.Font
+    Inset       layout.Inset        // space around a label's text, or inside a button
+}
+
+// TitleSize picks between the window title and the smaller section titles
+type TitleSize int
+
+const (
+    TitleLarge TitleSize = iota
+    TitleSmall
+)
+
+const (
+    inputBoxWidth   = unit.Dp(300)
+    buttonWidth     = unit.Dp(150)
+    reportTextSize  = unit.Sp(12)
+)
+
+
+// LabelStyle is a line of text: a title, a report or an error
+type LabelStyle struct {
+    Options
+    label           material.LabelStyle
+}
+
+// Title is a centered heading, the large one for a window's title
+func Title(inTheme *Theme, inTxt string, inSize TitleSize, inColor color.NRGBA) LabelStyle {
+    label := material.H3(inTheme.Theme, inTxt)
+    if inSize == TitleSmall {
+        label = material.H4(inTheme.Theme, inTxt)
+    }
+    return newLabel(label, inColor)
+}
+
+// ErrorBox is a centered error in the theme's large error text. An empty error takes no space.
+func ErrorBox(inTheme *Theme, inErrTxt string) LabelStyle {
+    return newLabel(material.H4(inTheme.Theme, inErrTxt), inTheme.Error)
+}
+
+// ReportBox is a centered line of small text, for statuses and results
+func ReportBox(inTheme *Theme, inTxt string, inColor color.NRGBA) LabelStyle {
+    return newLabel(material.Label(inTheme.Theme, reportTextSize, inTxt), inColor)
+}
+
+func newLabel(inLabel material.LabelStyle, inColor color.NRGBA) LabelStyle {
+    return LabelStyle{
+        Options:    Options{Color: inColor, Alignment: text.Middle, Font: inLabel.Font},
+        label:      inLabel,
+    }
+}
+
+func (l LabelStyle) Layout(inGTX layout.Context) layout.Dimensions {
+    label          := l.label
+    label.Color     = l.Color
+    label.Alignment = l.Alignment
+    label.Font      = l.Font
+
+    return fixedWidth(inGTX, l.Width, func(gtx layout.Context) layout.Dimensions {
+        return l.Inset.Layout(gtx, label.Layout)
+    })
+}
+
+
+// InputBoxStyle is an editor with a border, centered in the space it is given
+type InputBoxStyle struct {
+    Options
+    Border          widget.Border
+    editor          material.EditorStyle
+}
+
+// InputBox shows inHint while the editor is empty
+func InputBox(inTheme *Theme, inEditor *widget.Editor, inHint string) InputBoxStyle {
+    editor := material.Editor(inTheme.Theme, inEditor, inHint)
+
+    return InputBoxStyle{
+        Options: Options{
+            Width:      inputBoxWidth,
+            Color:      editor.Color,
+            Alignment:  text.Middle,
+            Font:       font.Font{Typeface: "Light"},
+        },
+        Border:     widget.Border{Color: inTheme.Border, CornerRadius: unit.Dp(3), Width: unit.Dp(2)},
+        editor:     editor,
+    }
+}
+
+func (b InputBoxStyle) Layout(inGTX layout.Context) layout.Dimensions {
+    editor                 := b.editor
+    editor.Color            = b.Color
+    editor.Font             = b.Font
+    editor.Editor.Alignment = b.Alignment
+
+    return centered(inGTX, func(gtx layout.Context) layout.Dimensions {
+        return fixedWidth(gtx, b.Width, func(gtx layout.Context) layout.Dimensions {
+            return b.Border.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+                return b.Inset.Layout(gtx, editor.Layout)
+            })
+        })
+    })
+}
+
+
+// ButtonStyle is a fixed width button, centered in the space it is given
+type ButtonStyle struct {
+    Options
+    Background      color.NRGBA
+    button          material.ButtonStyle
+}
+
+func Button(inTheme *Theme, inButton *widget.Clickable, inTxt string) ButtonStyle {
+    button := material.Button(inTheme.Theme, inButton, inTxt)
+
+    return ButtonStyle{
+        Options: Options{
+            Width:      buttonWidth,
+            Color:      button.Color,
+            Alignment:  text.Middle,
+            Font:       font.Font{Typeface: "ExtraLight"},
+            Inset:      layout.UniformInset(unit.Dp(10)),
+        },
+        Background: button.Background,
+        button:     button,
+    }
+}
+
+func (b ButtonStyle) Layout(inGTX layout.Context) layout.Dimensions {
+    button           := b.button
+    button.Color      = b.Color
+    button.Font       = b.Font
+    button.Inset      = b.Inset
+    button.Background = b.Background
+
+    return centered(inGTX, func(gtx layout.Context) layout.Dimensions {
+        return fixedWidth(gtx, b.Width, button.Layout)
+    })
+}
+
+
+// centered keeps the widget in the middle without stretching it to the window's edge
+func centered(inGTX layout.Context, inWidget layout.Widget) layout.Dimensions {
+    return layout.Flex{
+        Axis:    layout.Horizontal,
+        Spacing: layout.SpaceAround,
+    }.Layout(inGTX,
+        // Empty flexible space to push content to center
+        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+            return layout.Dimensions{}
+        }),
+        layout.Rigid(inWidget),
+        // Empty flexible space to balance layout
+        layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+            return layout.Dimensions{}
+        }),
+    )
+}
+
+// fixedWidth lays the widget out exactly inWidth wide, or as wide as it is given for zero
+func fixedWidth(inGTX layout.Context, inWidth unit.Dp, inWidget layout.Widget) layout.Dimensions {
+    if inWidth > 0 {
+        inGTX.Constraints.Min.X = inGTX.Dp(inWidth)
+        inGTX.Constraints.Max.X = inGTX.Dp(inWidth)
+    }
+    return inWidget(inGTX)
+}
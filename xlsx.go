@@ -0,0 +1,153 @@
+package main
+
+import (
+    "archive/zip"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+
+// Minimal SpreadsheetML parts - one sheet, inline strings and a few cell styles, enough for Excel and LibreOffice
+const (
+    xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+    xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+    xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Time entries" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+    xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+    // Cell styles: 0 plain, 1 bold header, 2 locale date, 3 number with two decimals
+    xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="%s"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="4">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+</styleSheet>`
+)
+
+const (
+    xlsxStyleHeader = 1
+    xlsxStyleDate   = 2
+    xlsxStyleNumber = 3
+)
+
+// Excel counts days from 1899-12-30
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+
+// writeExportXLSX writes the rows as a one-sheet workbook. Dates and hours are real cell values,
+// so the spreadsheet shows them with the reader's decimal separator and the locale's date format.
+func writeExportXLSX(inWriter io.Writer, inRows []ExportRow, inColumns []string, inLocale exportLocale) error {
+    var sheet strings.Builder
+
+    sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+    sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+    var header []any
+    for _, column := range inColumns {
+        header = append(header, exportColumns[column].Header)
+    }
+    writeXLSXRow(&sheet, 1, header, xlsxStyleHeader)
+
+    for i, row := range inRows {
+        var values []any
+        for _, column := range inColumns {
+            values = append(values, exportColumns[column].Value(row))
+        }
+        writeXLSXRow(&sheet, i+2, values, 0)
+    }
+    sheet.WriteString(`</sheetData></worksheet>`)
+
+    var dateFormat strings.Builder
+    xml.EscapeText(&dateFormat, []byte(inLocale.XlsxDateFormat))
+
+    parts := []struct {
+        name    string
+        content string
+    }{
+        {"[Content_Types].xml",        xlsxContentTypes},
+        {"_rels/.rels",                xlsxRootRels},
+        {"xl/workbook.xml",            xlsxWorkbook},
+        {"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+        {"xl/styles.xml",              fmt.Sprintf(xlsxStyles, dateFormat.String())},
+        {"xl/worksheets/sheet1.xml",   sheet.String()},
+    }
+
+    archive := zip.NewWriter(inWriter)
+    for _, part := range parts {
+        partWriter, err := archive.Create(part.name)
+        if err != nil {
+            return err
+        }
+        if _, err := io.WriteString(partWriter, part.content); err != nil {
+            return err
+        }
+    }
+
+    return archive.Close()
+}
+
+func writeXLSXRow(inSheet *strings.Builder, inRowNo int, inValues []any, inStyle int) {
+    fmt.Fprintf(inSheet, `<row r="%d">`, inRowNo)
+
+    for i, value := range inValues {
+        ref := fmt.Sprintf("%s%d", xlsxColumnName(i), inRowNo)
+
+        switch v := value.(type) {
+        case time.Time:
+            days := v.Sub(xlsxEpoch).Hours() / 24
+            fmt.Fprintf(inSheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleDate, strconv.FormatFloat(days, 'f', -1, 64))
+        case float64:
+            fmt.Fprintf(inSheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleNumber, strconv.FormatFloat(v, 'f', -1, 64))
+        case int:
+            fmt.Fprintf(inSheet, `<c r="%s" s="%d"><v>%d</v></c>`, ref, inStyle, v)
+        default:
+            var text strings.Builder
+            xml.EscapeText(&text, []byte(fmt.Sprint(v)))
+            fmt.Fprintf(inSheet, `<c r="%s" t="inlineStr" s="%d"><is><t>%s</t></is></c>`, ref, inStyle, text.String())
+        }
+    }
+
+    inSheet.WriteString(`</row>`)
+}
+
+// xlsxColumnName turns a zero-based column index into A, B, ..., Z, AA, AB, ...
+func xlsxColumnName(inIndex int) string {
+    name := ""
+    for inIndex >= 0 {
+        name    = string(rune('A'+inIndex%26)) + name
+        inIndex = inIndex/26 - 1
+    }
+
+    return name
+}